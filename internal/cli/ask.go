@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/bench"
+	"github.com/shivanshkc/llmb/pkg/jsonschema"
+	"github.com/shivanshkc/llmb/pkg/streams"
+	"github.com/shivanshkc/llmb/pkg/watch"
+)
+
+// watchInterval is how often the file of `ask --watch` is checked for changes.
+const watchInterval = 250 * time.Millisecond
+
+var (
+	askWatchFile  string
+	askOutputFile string
+	askImages     []string
+	askQuiet      bool
+	askStatsJSON  bool
+	askChoices    int
+	// askTemplate is the name of the stored prompt template to send, with the values of --var.
+	askTemplate string
+
+	askSchemaFile    string
+	askSchemaRetries int
+	// askSchema is the schema of --schema, which the answers are validated against.
+	askSchema *jsonschema.Schema
+)
+
+// askStats are the metrics of a response, printed to stderr as a JSON line with --stats-json.
+type askStats struct {
+	Model  string `json:"model"`
+	Choice int    `json:"choice"`
+	// The times are in milliseconds, since the request was sent.
+	TTFTMillis  float64 `json:"ttft_ms"`
+	TotalMillis float64 `json:"total_ms"`
+	// Tokens is the number of generated tokens, or else of streamed events, as TokensEstimated tells.
+	Tokens          int        `json:"tokens"`
+	TokensEstimated bool       `json:"tokens_estimated"`
+	TokensPerSecond float64    `json:"tokens_per_second"`
+	FinishReason    string     `json:"finish_reason,omitempty"`
+	Usage           *api.Usage `json:"usage,omitempty"`
+}
+
+// askCmd represents the `ask` command, which sends a single prompt and streams the response.
+var askCmd = &cobra.Command{
+	Use:   "ask [prompt]",
+	Short: "Send a single prompt and print the response.",
+	Long: `Sends the given prompt to the model and streams the response to standard output.
+
+With --output, the answer is written to a file instead, and with --quiet, only the answer is printed,
+without the reasoning, colors or any decoration, which suits scripts. With --stats-json, the timing and
+token usage of the response are printed to stderr as a JSON line, for scripts to collect.
+
+When standard input is piped, its content is the prompt, or the context appended to the given prompt,
+like in: git diff | llmb ask "Review this diff."
+
+With --template, the prompt is a stored template, with its variables substituted with the values of --var,
+like with "prompt run". Piped input is appended to it as context too.
+
+With --image, images are attached to the prompt, for vision models.
+
+With --schema, the answer is JSON that matches the given JSON schema. The model is asked to correct an
+invalid answer, which is never printed, so that only valid JSON is ever written.
+
+With --watch, the prompt is the content of a file instead, and it is sent again whenever the file
+changes, replacing the previous response. This is a tight feedback loop for prompt engineering.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateAskFlags(args)) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if askQuiet {
+			text.DisableColors()
+		}
+		if askSchemaFile != "" {
+			schema, format, err := loadSchema(askSchemaFile)
+			if err != nil {
+				return err
+			}
+			askSchema, requestOptions.ResponseFormat = schema, format
+		}
+
+		client := newClient()
+		if askWatchFile != "" {
+			return askWatch(cmd.Context(), client, askWatchFile)
+		}
+		prompt := strings.Join(args, " ")
+		if askTemplate != "" {
+			rendered, err := renderPrompt(askTemplate, promptVars)
+			if err != nil {
+				return err
+			}
+			prompt = rendered
+		}
+		prompt, err := askPrompt(prompt)
+		if err != nil {
+			return err
+		}
+		return ask(cmd.Context(), client, prompt)
+	},
+}
+
+// init registers the ask command with the root command and defines its local flags.
+func init() {
+	rootCmd.AddCommand(askCmd)
+
+	askCmd.Flags().StringVarP(&askWatchFile, "watch", "w",
+		"", "File containing the prompt, which is sent again whenever the file changes.")
+
+	askCmd.Flags().StringVarP(&askOutputFile, "output", "o",
+		"", "File to write the answer to, instead of standard output. It is replaced if it exists.")
+
+	askCmd.Flags().StringVar(&askTemplate, "template",
+		"", "Name of a stored prompt template to send as the prompt, with the values of --var.")
+
+	askCmd.Flags().StringArrayVar(&promptVars, "var",
+		nil, "Value of a template variable as name=value, or name=@file for the content of a file. Can be repeated.")
+
+	askCmd.Flags().StringArrayVar(&askImages, "image",
+		nil, "Path of an image to attach to the prompt. Can be repeated.")
+
+	askCmd.Flags().BoolVarP(&askQuiet, "quiet", "q",
+		false, "Print only the answer, without the reasoning, colors or any decoration.")
+
+	askCmd.Flags().BoolVar(&askStatsJSON, "stats-json",
+		false, "Print the timing and token usage of the response to stderr, as a JSON line.")
+
+	askCmd.Flags().IntVar(&askChoices, "choices",
+		1, "Number of responses to generate for the prompt, with the n parameter, printed one after the other.")
+
+	askCmd.Flags().StringVar(&askSchemaFile, "schema",
+		"", "JSON schema file that the answer must match. Only valid JSON is printed.")
+
+	askCmd.Flags().IntVar(&askSchemaRetries, "schema-retries",
+		2, "Number of times the model may correct an answer that does not match the schema.")
+
+	addParamFlags(askCmd.Flags())
+	addCacheFlags(askCmd.Flags())
+	addDryRunFlag(askCmd.Flags())
+	addTimeoutFlags(askCmd.Flags())
+	addNotifyFlag(askCmd.Flags(), "the response")
+}
+
+// askPrompt returns the given prompt and, when standard input is piped, its content,
+// which is appended to the prompt as context, after a blank line.
+func askPrompt(prompt string) (string, error) {
+	if isTerminal(os.Stdin) {
+		return prompt, nil
+	}
+
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read standard input: %w", err)
+	}
+	piped := strings.TrimSpace(string(content))
+
+	switch {
+	case piped == "" && prompt == "":
+		return "", asUsageError(errors.New("a prompt is required, as an argument or on standard input"))
+	case piped == "":
+		return prompt, nil
+	case prompt == "":
+		return piped, nil
+	default:
+		return prompt + "\n\n" + piped, nil
+	}
+}
+
+// ask streams the model's response to the prompt, along with the images, to standard output, or the answer
+// to the output file. With more than one choice, the responses are printed one after the other.
+func ask(ctx context.Context, client *api.Client, prompt string) (err error) {
+	defer func() { notify("The response", err) }()
+
+	message := api.ChatMessage{Role: api.RoleUser, Content: prompt}
+	for _, path := range askImages {
+		url, err := imageDataURL(path)
+		if err != nil {
+			return err
+		}
+		message.Parts = append(message.Parts, api.NewImagePart(url))
+	}
+	messages := []api.ChatMessage{message}
+	options := requestOptions
+	if askChoices > 1 {
+		options.N = askChoices
+	}
+	if askSchema != nil {
+		return askStructured(ctx, client, messages, options)
+	}
+
+	ctx, timeouts := newResponseTimeouts(ctx)
+	defer timeouts.stop()
+	start := time.Now()
+	eventStream, err := client.ChatCompletionStream(ctx, rootModel, messages, options)
+	if err != nil {
+		return timeouts.wrap(err)
+	}
+	eventStream = timeouts.watch(eventStream)
+
+	var out io.Writer = os.Stdout
+	if askOutputFile != "" {
+		file, err := os.Create(askOutputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() { _ = file.Close() }()
+		out = file
+	}
+
+	choices := []*streams.Stream[api.ChatCompletionEvent]{eventStream}
+	if askChoices > 1 {
+		choices = api.SplitChoices(eventStream, askChoices)
+	}
+	for i, choice := range choices {
+		if i > 0 {
+			fmt.Fprintln(out) // A blank line between the responses.
+		}
+		if len(choices) > 1 && !askQuiet {
+			fmt.Println(text.Faint.Sprintf("Choice %d of %d:", i+1, len(choices)))
+		}
+		if err := renderChoice(ctx, choice, i, start, out); err != nil {
+			return timeouts.wrap(err)
+		}
+	}
+
+	if askOutputFile != "" && !askQuiet {
+		fmt.Println(text.Faint.Sprint("Wrote the answer to " + askOutputFile))
+	}
+	return nil
+}
+
+// renderChoice renders the response of the choice with the given index, from its stream, to the writer.
+// The start is the time the request was sent, which the stats are timed from.
+func renderChoice(ctx context.Context, stream *streams.Stream[api.ChatCompletionEvent], index int, start time.Time,
+	out io.Writer,
+) error {
+	// Code is only highlighted for reading in a terminal, never in files or pipes.
+	highlight := out == os.Stdout && !askQuiet && isTerminal(os.Stdout)
+	renderer := &responseRenderer{showReasoning: true, quiet: askQuiet, out: out, highlight: highlight}
+	result := api.StreamResult{Index: index}
+	timer := bench.NewStreamTimer(start)
+	for {
+		event, ok, err := stream.NextContext(ctx)
+		if err != nil {
+			renderer.finish() // The partial response still ends its line.
+			return err
+		}
+		if !ok {
+			break
+		}
+		if len(event.Choices) > 0 {
+			renderer.write(event.Choices[0].Delta)
+		}
+		result.Add(event)
+		timer.Add(event)
+	}
+	renderer.finish()
+	stats := timer.Stop(time.Now())
+
+	// The notice goes to stderr, so that it never mixes with the answer.
+	if notice := finishNotice(result); notice != "" && !askQuiet {
+		fmt.Fprintln(os.Stderr, text.FgYellow.Sprint(notice))
+	}
+
+	if askStatsJSON {
+		return writeAskStats(index, stats, result)
+	}
+	return nil
+}
+
+// writeAskStats prints the stats of the response of the choice with the given index to stderr, as a JSON line.
+func writeAskStats(index int, stats bench.StreamStats, result api.StreamResult) error {
+	err := json.NewEncoder(os.Stderr).Encode(askStats{
+		Model:           rootModel,
+		Choice:          index,
+		TTFTMillis:      float64(stats.TTFT) / float64(time.Millisecond),
+		TotalMillis:     float64(stats.Total) / float64(time.Millisecond),
+		Tokens:          stats.Tokens,
+		TokensEstimated: stats.Estimated,
+		TokensPerSecond: stats.TokensPerSecond,
+		FinishReason:    string(result.FinishReason),
+		Usage:           result.Usage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	return nil
+}
+
+// askStructured prints the model's answer to the messages, once it matches the schema of --schema, to standard
+// output, or writes it to the output file. The notices of the corrections go to stderr, apart from the answer.
+func askStructured(ctx context.Context, client *api.Client, messages []api.ChatMessage, options api.ChatOptions) error {
+	var notices io.Writer = os.Stderr
+	if askQuiet {
+		notices = io.Discard
+	}
+	response, err := requestStructured(ctx, client, messages, options, askSchema, askSchemaRetries, notices, nil)
+	if err != nil {
+		return err
+	}
+
+	if askOutputFile == "" {
+		fmt.Println(response.answer)
+	} else {
+		if err := os.WriteFile(askOutputFile, []byte(response.answer+"\n"), 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		if !askQuiet {
+			fmt.Println(text.Faint.Sprint("Wrote the answer to " + askOutputFile))
+		}
+	}
+
+	if askStatsJSON {
+		return writeAskStats(0, response.stats, response.result)
+	}
+	return nil
+}
+
+// askWatch sends the content of the file as the prompt, again whenever it changes, until the context
+// is canceled. A change cancels the response in progress. Errors are shown instead of ending the command.
+func askWatch(ctx context.Context, client *api.Client, path string) error {
+	contents, err := watch.File(ctx, path, watchInterval)
+	if err != nil {
+		return err
+	}
+
+	content := <-contents // The current content is always sent first.
+	for {
+		// Clear the screen for the new response.
+		if !askQuiet {
+			fmt.Print("\033[H\033[2J")
+			fmt.Println(text.Faint.Sprintf("Watching %s, sent at %s. Press Ctrl+C to stop.",
+				path, time.Now().Format(time.TimeOnly)))
+			fmt.Println()
+		}
+
+		askCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- ask(askCtx, client, string(content)) }()
+
+		// Wait for the response, unless the file changes first.
+		var next []byte
+		var changed bool
+		select {
+		case err = <-done:
+		case next, changed = <-contents:
+			cancel()
+			err = <-done
+		}
+		cancel()
+
+		if err != nil && !errors.Is(err, context.Canceled) {
+			fmt.Println(text.FgRed.Sprint("Error: " + err.Error()))
+		}
+
+		// Without a change during the response, wait for one.
+		if !changed {
+			if next, changed = <-contents; !changed {
+				return nil // Context canceled.
+			}
+		}
+		content = next
+	}
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+// TestAskStructured verifies that an answer that does not match the schema is corrected,
+// and that only the valid one is written.
+func TestAskStructured(t *testing.T) {
+	// The first answer misses a required field, and the correction has it.
+	answers := []string{`{"city": "Paris"}`, "```json\n{\"city\": \"Paris\", \"country\": \"France\"}\n```"}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := json.Marshal(answers[min(requests, len(answers)-1)])
+		requests++
+		fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%s}}]}\n\ndata: [DONE]\n\n", content)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	schemaPath, outputPath := filepath.Join(dir, "city.json"), filepath.Join(dir, "answer.json")
+	schema := `{"type": "object", "required": ["city", "country"]}`
+	require.NoError(t, os.WriteFile(schemaPath, []byte(schema), 0o644))
+
+	parseFlags(t, askCmd, "--schema", schemaPath, "--output", outputPath, "--quiet")
+	loaded, format, err := loadSchema(schemaPath)
+	require.NoError(t, err)
+	askSchema = loaded
+	t.Cleanup(func() { askSchema = nil })
+
+	messages := []api.ChatMessage{{Role: api.RoleUser, Content: "Where is the Eiffel Tower?"}}
+	options := api.ChatOptions{ResponseFormat: format}
+	require.NoError(t, askStructured(context.Background(), api.NewClient(server.URL), messages, options))
+
+	assert.Equal(t, 2, requests)
+	written, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"city": "Paris", "country": "France"}`, string(written))
+}
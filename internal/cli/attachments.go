@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFileSize is the size of the largest file that can be included in a message.
+const maxFileSize = 1 << 20
+
+// imageDataURL reads the image file at the given path and encodes it as a
+// base64 data URL, which is the format vision models accept inline.
+func imageDataURL(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	// Prefer the extension for the media type and fall back to content sniffing.
+	mediaType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(content)
+	}
+
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("not an image: %s (%s)", path, mediaType)
+	}
+
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(content), nil
+}
+
+// fileBlock reads the text file at the given path and formats it as a Markdown fenced
+// code block, headed by its path, so that the model can tell the files of a message apart.
+func fileBlock(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if info.Size() > maxFileSize {
+		return "", fmt.Errorf("file is too large: %s (%d bytes, at most %d)", path, info.Size(), maxFileSize)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if !utf8.Valid(content) {
+		return "", fmt.Errorf("not a text file: %s", path)
+	}
+
+	// The fence must be longer than any run of backticks in the content, so that it's not closed early.
+	fence := "```"
+	for strings.Contains(string(content), fence) {
+		fence += "`"
+	}
+	language := strings.TrimPrefix(filepath.Ext(path), ".")
+	return fmt.Sprintf("%s:\n%s%s\n%s\n%s", path, fence, language,
+		strings.TrimRight(string(content), "\n"), fence), nil
+}
+
+// inlineFilePaths returns the paths of the existing files mentioned in the given message with
+// the @path syntax, like "@main.go". Mentions that are not files, like "@someone", are ignored.
+func inlineFilePaths(message string) []string {
+	var paths []string
+	for _, word := range strings.Fields(message) {
+		path, ok := strings.CutPrefix(word, "@")
+		// Trailing punctuation belongs to the sentence, not to the path.
+		path = strings.TrimRight(path, ".,;:!?)'\"")
+		if !ok || path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
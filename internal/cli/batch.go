@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+var (
+	batchEndpoint   string
+	batchListLimit  int
+	batchListAfter  string
+	batchOutputFile string
+	batchErrors     bool
+)
+
+// batchCmd is the parent command for managing batches of requests with the batch API.
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Run large offline workloads with the batch API.",
+	Long: `Manage batches of requests with the /v1/batches API of OpenAI-compatible servers and gateways,
+which process them asynchronously, within 24 hours, usually at a discount.
+
+The requests file is a JSONL file with one request per line, like:
+{"custom_id": "1", "method": "POST", "url": "/v1/chat/completions", "body": {"model": "gpt-4.1", "messages": [...]}}`,
+}
+
+// batchCreateCmd uploads a requests file and creates a batch of its requests.
+var batchCreateCmd = &cobra.Command{
+	Use:     "create <requests-file>",
+	Short:   "Create a batch from a JSONL file of requests.",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateBatchFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		created, err := newClient().CreateBatch(cmd.Context(), args[0], batchEndpoint)
+		if err != nil {
+			return batchError("create batch", err)
+		}
+		fmt.Printf("Created batch %s, which is %s. Check it with `llmb batch status %s`.\n",
+			created.ID, created.Status, created.ID)
+		return nil
+	},
+}
+
+// batchStatusCmd prints the details of a batch.
+var batchStatusCmd = &cobra.Command{
+	Use:     "status <batch-id>",
+	Short:   "Show the status of a batch.",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateBatchFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		found, err := newClient().GetBatch(cmd.Context(), args[0])
+		if err != nil {
+			return batchError("get batch", err)
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.SetStyle(table.StyleColoredDark)
+		t.AppendRows([]table.Row{
+			{"ID", found.ID},
+			{"Status", found.Status},
+			{"Endpoint", found.Endpoint},
+			{"Requests", formatRequestCounts(found.RequestCounts)},
+			{"Created", formatUnixTime(found.CreatedAt)},
+			{"Completed", formatUnixTime(found.CompletedAt)},
+			{"Expires", formatUnixTime(found.ExpiresAt)},
+			{"Input File", found.InputFileID},
+			{"Output File", found.OutputFileID},
+			{"Error File", found.ErrorFileID},
+		})
+		t.Render()
+		return nil
+	},
+}
+
+// batchListCmd lists the batches, from the most recent.
+var batchListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List the batches, from the most recent.",
+	Args:    cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateBatchFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, err := newClient().ListBatches(cmd.Context(), batchListAfter, batchListLimit)
+		if err != nil {
+			return batchError("list batches", err)
+		}
+
+		if len(list.Data) == 0 {
+			fmt.Println("No batches. Create one with `llmb batch create`.")
+			return nil
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.SetStyle(table.StyleColoredDark)
+		t.AppendHeader(table.Row{"ID", "Status", "Endpoint", "Requests", "Created"})
+		for _, listed := range list.Data {
+			t.AppendRow(table.Row{listed.ID, listed.Status, listed.Endpoint,
+				formatRequestCounts(listed.RequestCounts), formatUnixTime(listed.CreatedAt)})
+		}
+		t.Render()
+
+		if list.HasMore {
+			fmt.Printf("There are more batches. List them with --after %s.\n", list.LastID)
+		}
+		return nil
+	},
+}
+
+// batchResultsCmd downloads the responses of a completed batch.
+var batchResultsCmd = &cobra.Command{
+	Use:     "results <batch-id>",
+	Short:   "Download the responses of a batch, as JSONL.",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateBatchFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		found, err := client.GetBatch(cmd.Context(), args[0])
+		if err != nil {
+			return batchError("get batch", err)
+		}
+
+		fileID, kind := found.OutputFileID, "responses"
+		if batchErrors {
+			fileID, kind = found.ErrorFileID, "errors"
+		}
+		if fileID == "" {
+			if !found.Done() {
+				return fmt.Errorf("batch %s is %s, and its %s are not ready yet", found.ID, found.Status, kind)
+			}
+			return fmt.Errorf("batch %s is %s, and has no %s", found.ID, found.Status, kind)
+		}
+
+		content, err := client.FileContent(cmd.Context(), fileID)
+		if err != nil {
+			return batchError("download "+kind, err)
+		}
+		defer func() { _ = content.Close() }()
+
+		var out io.Writer = os.Stdout
+		if batchOutputFile != "" {
+			file, err := os.Create(batchOutputFile)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer func() { _ = file.Close() }()
+			out = file
+		}
+		if _, err := io.Copy(out, content); err != nil {
+			return fmt.Errorf("failed to download %s: %w", kind, err)
+		}
+
+		// The note goes to stderr, so that it never mixes with the responses.
+		if !batchErrors && found.ErrorFileID != "" {
+			fmt.Fprintf(os.Stderr, "%d of the requests failed. Download their errors with --errors.\n", found.RequestCounts.Failed)
+		}
+		return nil
+	},
+}
+
+// batchCancelCmd cancels a batch.
+var batchCancelCmd = &cobra.Command{
+	Use:     "cancel <batch-id>",
+	Short:   "Cancel a batch.",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateBatchFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cancelled, err := newClient().CancelBatch(cmd.Context(), args[0])
+		if err != nil {
+			return batchError("cancel batch", err)
+		}
+		fmt.Printf("Batch %s is %s.\n", cancelled.ID, cancelled.Status)
+		return nil
+	},
+}
+
+// init registers the batch commands and defines their local flags.
+func init() {
+	rootCmd.AddCommand(batchCmd)
+	batchCmd.AddCommand(batchCreateCmd, batchStatusCmd, batchListCmd, batchResultsCmd, batchCancelCmd)
+
+	batchCreateCmd.Flags().StringVar(&batchEndpoint, "endpoint",
+		"/v1/chat/completions", "Endpoint of the requests of the batch.")
+
+	batchListCmd.Flags().IntVar(&batchListLimit, "limit",
+		20, "Maximum number of batches to list.")
+
+	batchListCmd.Flags().StringVar(&batchListAfter, "after",
+		"", "List the batches older than the one with this ID.")
+
+	batchResultsCmd.Flags().StringVarP(&batchOutputFile, "output", "o",
+		"", "File to write the results to, instead of standard output. It is replaced if it exists.")
+
+	batchResultsCmd.Flags().BoolVar(&batchErrors, "errors",
+		false, "Download the errors of the failed requests, instead of the responses.")
+}
+
+// batchError describes the failure of a batch operation, ignoring context cancellation errors.
+func batchError(operation string, err error) error {
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return fmt.Errorf("failed to %s: %w", operation, err)
+}
+
+// formatRequestCounts formats the request counts of a batch, like "90/100 done, 2 failed".
+func formatRequestCounts(counts api.BatchRequestCounts) string {
+	formatted := fmt.Sprintf("%d/%d done", counts.Completed+counts.Failed, counts.Total)
+	if counts.Failed > 0 {
+		formatted += fmt.Sprintf(", %d failed", counts.Failed)
+	}
+	return formatted
+}
+
+// formatUnixTime formats a Unix timestamp in the local time zone, or returns an empty string for zero.
+func formatUnixTime(timestamp int64) string {
+	if timestamp == 0 {
+		return ""
+	}
+	return time.Unix(timestamp, 0).Format(time.DateTime)
+}
@@ -4,23 +4,42 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/api/tokenizer"
 	"github.com/shivanshkc/llmb/pkg/bench"
 	"github.com/shivanshkc/llmb/pkg/streams"
 )
 
 var (
 	benchPrompt       string
+	benchPromptTokens int
 	benchRequestCount int
 	benchConcurrency  int
+	benchMaxErrors    int
+	benchMaxErrorRate string
+	benchSaveFile     string
+	benchOutput       string
+	benchRawOut       string
+	benchRawGaps      bool
+	benchWarmup       int
+	benchDuration     time.Duration
+	benchRPS          float64
+	benchArrivals     string
 )
 
+// benchOutputTable is the format of the results for reading, as opposed to the formats of bench.Report for scripts.
+const benchOutputTable = "table"
+
 // benchCmd represents the `bench` command for running performance benchmarks
 // against an OpenAI-compatible API.
 //
@@ -34,9 +53,9 @@ var benchCmd = &cobra.Command{
 	Use:     "bench",
 	Short:   "Benchmark an Open AI compatible REST API.",
 	Long:    "Concurrently executes requests against a streaming API and reports performance metrics.",
-	PreRunE: func(cmd *cobra.Command, args []string) error { return validateBenchFlags() },
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateBenchFlags(cmd.Flags())) },
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := api.NewClient(rootBaseURL)
+		client := newClient()
 
 		// streamFunc is the core function to be benchmarked. It's a factory that
 		// captures user flags and creates a cancellable API stream each time it's
@@ -45,9 +64,14 @@ var benchCmd = &cobra.Command{
 		// This closure is a clean "adapter" between the CLI layer and the reusable
 		// benchmark package. It adapts the specific `api.ChatCompletionEvent`
 		// stream into the generic `bench.Event` stream required by the runner.
+		prompt := benchPrompt
+		if benchPromptTokens > 0 {
+			prompt = tokenizer.Repeat(tokenizer.ForModel(rootModel), benchPrompt, benchPromptTokens)
+		}
+
 		streamFunc := func(ctx context.Context) (*streams.Stream[bench.Event], error) {
-			messages := []api.ChatMessage{{Role: api.RoleUser, Content: benchPrompt}}
-			cceStream, err := client.ChatCompletionStream(ctx, rootModel, messages)
+			messages := []api.ChatMessage{{Role: api.RoleUser, Content: prompt}}
+			cceStream, err := client.ChatCompletionStream(ctx, rootModel, messages, requestOptions)
 			if err != nil {
 				return nil, fmt.Errorf("error in ChatCompletionStream call: %w", err)
 			}
@@ -55,8 +79,73 @@ var benchCmd = &cobra.Command{
 			return streams.Map(cceStream, func(e api.ChatCompletionEvent) bench.Event { return e }), nil
 		}
 
+		// A dry run prints a single request, as all of them are the same.
+		if dryRun {
+			_, err := streamFunc(cmd.Context())
+			return err
+		}
+
+		var options []bench.Option
+		if benchDuration > 0 {
+			options = append(options, bench.WithDuration(benchDuration))
+		}
+		if benchRPS > 0 {
+			options = append(options, bench.WithRate(benchRPS, benchArrivals))
+		}
+		// The measurements of every request are written as they complete, so that they are kept on interruption.
+		var rawErr error
+		if benchRawOut != "" {
+			file, writer, err := createRawOutput()
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			options = append(options, bench.WithMeasurements(func(m bench.Measurement) {
+				if rawErr == nil {
+					rawErr = writer.Write(m)
+				}
+			}))
+		}
+		if maxErrors := benchErrorLimit(); maxErrors > 0 {
+			options = append(options, bench.WithMaxErrors(maxErrors))
+		}
+
+		// The warm-up requests are not part of the run, so its metadata starts after them.
+		if benchWarmup > 0 {
+			fmt.Fprintln(benchNoticeOutput(), "Warming up...")
+			if err := bench.Warmup(cmd.Context(), benchWarmup, benchConcurrency, streamFunc); err != nil {
+				notify("The benchmark", err)
+				// Ignore context cancellation errors.
+				if errors.Is(err, context.Canceled) {
+					return nil
+				}
+				return err
+			}
+		}
+
+		metadata := newBenchMetadata(cmd.Flags())
+
 		// Delegate all concurrent execution and aggregation to the benchmark package.
-		results, err := bench.BenchmarkStream(cmd.Context(), benchRequestCount, benchConcurrency, streamFunc)
+		progress := startBenchProgress()
+		options = append(options, bench.WithProgress(progress.update))
+		results, err := bench.BenchmarkStream(cmd.Context(), benchRequestCount, benchConcurrency, streamFunc, options...)
+		progress.stop()
+		notify("The benchmark", err)
+		metadata.FinishedAt = time.Now()
+		if rawErr != nil {
+			return rawErr
+		}
+		if errors.Is(err, bench.ErrTooManyErrors) {
+			// The results gathered until the benchmark stopped are still worth seeing.
+			if err := outputBenchmarkResults(metadata, results); err != nil {
+				return err
+			}
+			if err := saveBenchmarkReport(metadata, results); err != nil {
+				return err
+			}
+			return &sloViolationError{err: err}
+		}
 		if err != nil {
 			// Ignore context cancellation errors.
 			if errors.Is(err, context.Canceled) {
@@ -65,8 +154,10 @@ var benchCmd = &cobra.Command{
 			return fmt.Errorf("failed to benchmark: %w", err)
 		}
 
-		displayBenchmarkResults(results)
-		return nil
+		if err := outputBenchmarkResults(metadata, results); err != nil {
+			return err
+		}
+		return saveBenchmarkReport(metadata, results)
 	},
 }
 
@@ -77,11 +168,162 @@ func init() {
 	benchCmd.Flags().StringVarP(&benchPrompt, "prompt", "p",
 		"", "Prompt to use for all requests.")
 
+	benchCmd.Flags().IntVar(&benchPromptTokens, "prompt-tokens",
+		0, "Repeat or cut the prompt to exactly this many tokens, as counted by the model's tokenizer.")
+
 	benchCmd.Flags().IntVarP(&benchRequestCount, "request-count", "n",
 		12, "Total number of requests to perform.")
 
 	benchCmd.Flags().IntVarP(&benchConcurrency, "concurrency", "c",
 		3, "Number of multiple requests to make at a time.")
+
+	benchCmd.Flags().DurationVar(&benchDuration, "duration",
+		0, "Keep making requests for this long, like 60s, instead of a number of them. In-flight requests are completed.")
+
+	benchCmd.Flags().Float64Var(&benchRPS, "rps",
+		0, "Start this many requests per second, however many are in flight, instead of a concurrency.")
+
+	benchCmd.Flags().StringVar(&benchArrivals, "arrivals",
+		bench.ArrivalsPoisson, "Arrival times of the requests with --rps: poisson, at random, or uniform, regularly.")
+
+	benchCmd.Flags().IntVar(&benchWarmup, "warmup",
+		0, "Number of unrecorded requests to perform before the benchmark, so that cold starts don't skew it.")
+
+	benchCmd.Flags().IntVar(&benchMaxErrors, "max-errors",
+		0, "Number of failed requests tolerated before the benchmark stops. By default, the first failure stops it.")
+
+	benchCmd.Flags().StringVar(&benchMaxErrorRate, "max-error-rate",
+		"", "Share of the requests that may fail before the benchmark stops, like 5% or 0.05.")
+
+	benchCmd.Flags().StringVar(&benchSaveFile, "save",
+		"", "Save the results, along with metadata about the run, to this JSON file.")
+
+	benchCmd.Flags().StringVar(&benchOutput, "output",
+		benchOutputTable, "Format of the results: table, or json or yaml for scripts, with the metadata of the run.")
+
+	benchCmd.Flags().StringVar(&benchRawOut, "raw-out",
+		"", "Write the measurements of every request to this .csv or .jsonl file, for your own analysis.")
+
+	benchCmd.Flags().BoolVar(&benchRawGaps, "raw-gaps",
+		false, "Include the time between every two tokens of the requests in the --raw-out file.")
+
+	addParamFlags(benchCmd.Flags())
+	addDryRunFlag(benchCmd.Flags())
+	addNotifyFlag(benchCmd.Flags(), "the benchmark")
+}
+
+// newBenchMetadata describes the environment of the benchmark run that starts now,
+// with the values of the given flags.
+func newBenchMetadata(flags *pflag.FlagSet) bench.Metadata {
+	values := map[string]string{}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Name == "help" {
+			return
+		}
+		// Flags may hold secrets, like the API key, gateway tokens in headers, or credentials in the base and proxy URLs.
+		switch flag.Name {
+		case "api-key":
+			if flag.Value.String() != "" {
+				values[flag.Name] = "REDACTED"
+			}
+		case "header":
+			if len(rootHeaders) > 0 {
+				values[flag.Name] = "REDACTED"
+			}
+		case "base-url", "proxy":
+			values[flag.Name] = bench.SanitizeURL(flag.Value.String())
+		default:
+			values[flag.Name] = flag.Value.String()
+		}
+	})
+
+	return bench.Metadata{
+		RunID:     bench.NewRunID(),
+		StartedAt: time.Now(),
+		Version:   appVersion(),
+		URL:       bench.SanitizeURL(rootBaseURL),
+		Model:     rootModel,
+		Flags:     values,
+		Host:      bench.CurrentHost(),
+	}
+}
+
+// saveBenchmarkReport saves the results with the metadata of the run, if asked to with `--save`.
+func saveBenchmarkReport(metadata bench.Metadata, results bench.StreamBenchmarkResults) error {
+	if benchSaveFile == "" {
+		return nil
+	}
+
+	report := &bench.Report{Metadata: metadata, Results: results}
+	if err := report.Save(benchSaveFile); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(benchNoticeOutput(), "Saved the results of run %s to %s\n", metadata.RunID[:7], benchSaveFile)
+	return nil
+}
+
+// rawOutFormat returns the format of the measurements for the extension of the given file,
+// or an empty string if the extension is unknown.
+func rawOutFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return bench.FormatCSV
+	case ".jsonl", ".ndjson":
+		return bench.FormatJSONL
+	default:
+		return ""
+	}
+}
+
+// createRawOutput creates the file of `--raw-out`, and a writer of measurements to it, in the format of its extension.
+// The file must be closed once the benchmark ends.
+func createRawOutput() (*os.File, *bench.RawWriter, error) {
+	file, err := os.Create(benchRawOut)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create raw output file: %w", err)
+	}
+
+	writer, err := bench.NewRawWriter(file, rawOutFormat(benchRawOut), benchRawGaps)
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	return file, writer, nil
+}
+
+// benchNoticeOutput returns where the notices of the benchmark, like its progress, are printed.
+// They go to stderr when the results are printed for scripts, so that they don't mix.
+func benchNoticeOutput() io.Writer {
+	if benchOutput == benchOutputTable {
+		return os.Stdout
+	}
+	return os.Stderr
+}
+
+// outputBenchmarkResults prints the results in the format of `--output`: a table, or the report of the run.
+func outputBenchmarkResults(metadata bench.Metadata, results bench.StreamBenchmarkResults) error {
+	if benchOutput == benchOutputTable {
+		displayBenchmarkResults(results)
+		return nil
+	}
+
+	report := &bench.Report{Metadata: metadata, Results: results}
+	return report.Encode(os.Stdout, benchOutput)
+}
+
+// benchErrorLimit returns the number of failed requests tolerated by the flags.
+// If both flags are given, the stricter one applies.
+func benchErrorLimit() int {
+	limit := benchMaxErrors
+	if benchMaxErrorRate != "" {
+		rate, _ := parseRate(benchMaxErrorRate) // Validated already.
+		rateLimit := int(rate * float64(benchRequestCount))
+		if limit == 0 || rateLimit < limit {
+			limit = rateLimit
+		}
+	}
+	return limit
 }
 
 // displayBenchmarkResults formats and prints the given benchmark results in a
@@ -101,6 +343,20 @@ func displayBenchmarkResults(results bench.StreamBenchmarkResults) {
 
 	// AppendRows is formatted vertically to adhere to the line length limit
 	// and improve readability.
+	if results.Failed > 0 {
+		fmt.Printf("\n%d requests succeeded, %d failed. The metrics are of the successful requests.\n",
+			results.Succeeded, results.Failed)
+	}
+	if results.Succeeded > 0 {
+		fmt.Printf("\n%d requests completed in %s, %.2f requests/s.\n",
+			results.Succeeded, fd(results.Elapsed), results.RequestsPerSecond)
+	}
+	// The token throughput is known only if the server reports the token usage.
+	if results.CompletionTokens > 0 {
+		fmt.Printf("Throughput: %.2f tokens/s per request, %.2f tokens/s in total, %d tokens generated in total.\n",
+			results.TokensPerSecond, float64(results.CompletionTokens)/results.Elapsed.Seconds(), results.CompletionTokens)
+	}
+
 	t.AppendRows([]table.Row{
 		{
 			"Time To First Token (TTFT)",
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shivanshkc/llmb/pkg/bench"
+)
+
+// benchProgressInterval is the time between two redraws of the progress bar, which keep the elapsed time current.
+const benchProgressInterval = 250 * time.Millisecond
+
+// benchProgressWidth is the number of cells of the progress bar.
+const benchProgressWidth = 30
+
+// benchProgress shows the progress of a benchmark. On a terminal, it draws a bar on stderr with the share of
+// the run that is done, the running error count, the elapsed time and the estimated time left, redrawn in
+// place. Otherwise, it prints a line whenever a request completes.
+type benchProgress struct {
+	// bar is true if the bar is drawn, and false if lines are printed instead.
+	bar bool
+	// duration is how long the benchmark runs, if it runs for a duration instead of a number of requests.
+	duration time.Duration
+	start    time.Time
+
+	mu       sync.Mutex
+	progress bench.Progress
+
+	// stopped is closed to stop the redraws, and redrawn once they stopped.
+	stopped, redrawn chan struct{}
+}
+
+// startBenchProgress starts showing the progress of the benchmark of the flags, which starts now.
+// It must be stopped once the benchmark ends.
+func startBenchProgress() *benchProgress {
+	p := &benchProgress{bar: isTerminal(os.Stderr), duration: benchDuration, start: time.Now(),
+		progress: bench.Progress{Total: benchRequestCount}, stopped: make(chan struct{}), redrawn: make(chan struct{})}
+	if benchDuration > 0 {
+		p.progress.Total = 0
+	}
+
+	if !p.bar {
+		close(p.redrawn)
+		return p
+	}
+
+	p.draw()
+	go func() {
+		defer close(p.redrawn)
+		ticker := time.NewTicker(benchProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stopped:
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				p.draw()
+				p.mu.Unlock()
+			}
+		}
+	}()
+	return p
+}
+
+// update records the progress of the benchmark, as told by bench.WithProgress, and shows it.
+// Tolerated failures are reported above the bar.
+func (p *benchProgress) update(progress bench.Progress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.progress = progress
+
+	if progress.Err != nil {
+		if p.bar {
+			fmt.Fprint(os.Stderr, "\r"+clearToEndOfLine)
+		}
+		fmt.Fprintf(benchNoticeOutput(), "Request failed (%d of %d tolerated): %v\n",
+			progress.Failed, benchErrorLimit(), progress.Err)
+	}
+
+	switch {
+	case p.bar:
+		p.draw()
+	case progress.Err != nil:
+		// The failure is reported instead of the progress.
+	case progress.Total == 0:
+		fmt.Fprintf(benchNoticeOutput(), "[%d] requests complete.\n", progress.Completed)
+	default:
+		fmt.Fprintf(benchNoticeOutput(), "[%d/%d] requests complete.\n", progress.Completed, progress.Total)
+	}
+}
+
+// stop stops showing the progress, leaving the final state of the bar on its own line.
+func (p *benchProgress) stop() {
+	close(p.stopped)
+	<-p.redrawn
+	if p.bar {
+		p.draw()
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// draw draws the bar over the current line of stderr. It must be called with the lock held.
+func (p *benchProgress) draw() {
+	elapsed := time.Since(p.start)
+	done, total := p.progress.Completed+p.progress.Failed, p.progress.Total
+
+	// The time left is estimated from the rate of the requests so far, unless the benchmark runs for a duration.
+	var fraction float64
+	left := "?"
+	count := fmt.Sprintf("%d requests", done)
+	switch {
+	case p.duration > 0:
+		fraction = min(elapsed.Seconds()/p.duration.Seconds(), 1)
+		left = max(p.duration-elapsed, 0).Round(time.Second).String()
+	case total > 0:
+		fraction = float64(done) / float64(total)
+		count = fmt.Sprintf("%d/%d requests", done, total)
+		if done > 0 {
+			left = (elapsed * time.Duration(total-done) / time.Duration(done)).Round(time.Second).String()
+		}
+	}
+
+	filled := int(fraction * benchProgressWidth)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", benchProgressWidth-filled)
+	fmt.Fprintf(os.Stderr, "\r%s %3.0f%% %s, %d failed, elapsed %s, ETA %s"+clearToEndOfLine,
+		bar, fraction*100, count, p.progress.Failed, elapsed.Round(time.Second), left)
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/shivanshkc/llmb/pkg/cache"
+)
+
+var (
+	// cacheEnabled, cacheTTL and cacheRefresh hold the values of the cache flags,
+	// which are defined by the commands that support caching.
+	cacheEnabled bool
+	cacheTTL     time.Duration
+	cacheRefresh bool
+
+	// responseCache is set when the response cache is enabled.
+	responseCache *cache.Transport
+)
+
+// cacheCmd is the parent command for managing the response cache.
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of responses.",
+	Long: `Manage the on-disk cache of responses used by the ask, run and eval commands with --cache.
+Identical requests, with the same model, messages and parameters, are answered from the cache.`,
+}
+
+// cacheClearCmd deletes all cached responses.
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete all cached responses.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := cacheDir()
+		if err != nil {
+			return err
+		}
+		count, err := cache.Clear(dir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %d cached responses.\n", count)
+		return nil
+	},
+}
+
+// init registers the cache commands.
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
+
+// addCacheFlags defines the cache flags on a command that supports caching.
+func addCacheFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&cacheEnabled, "cache",
+		false, "Answer identical requests from the on-disk cache of responses. [env: LLMB_CACHE]")
+
+	flags.DurationVar(&cacheTTL, "cache-ttl",
+		24*time.Hour, "How long cached responses remain valid. Zero means forever. [env: LLMB_CACHE_TTL]")
+
+	flags.BoolVar(&cacheRefresh, "cache-refresh",
+		false, "Bypass the lookup of cached responses, while still caching the new ones.")
+}
+
+// setupCache prepares the response cache, if it is enabled by the flags. A refresh enables it too.
+func setupCache(flags *pflag.FlagSet) error {
+	if flags.Lookup("cache") == nil {
+		return nil
+	}
+	if err := resolveFlag(flags, "cache", "LLMB_CACHE", ""); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "cache-ttl", "LLMB_CACHE_TTL", ""); err != nil {
+		return err
+	}
+	if !cacheEnabled && !cacheRefresh {
+		return nil
+	}
+
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	responseCache = &cache.Transport{Dir: dir, TTL: cacheTTL, Refresh: cacheRefresh}
+	return nil
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shivanshkc/llmb/pkg/cassette"
+)
+
+var (
+	// rootRecordCassette and rootReplayCassette are the paths of the cassettes to record to and replay from.
+	rootRecordCassette string
+	rootReplayCassette string
+
+	// recorder and player are set when a cassette is being recorded or replayed respectively.
+	recorder *cassette.Recorder
+	player   *cassette.Player
+)
+
+// setupCassette prepares the recording or replaying of a cassette, as asked by the flags.
+func setupCassette() error {
+	if rootRecordCassette != "" && rootReplayCassette != "" {
+		return errors.New("cannot record and replay a cassette at the same time")
+	}
+
+	if rootRecordCassette != "" {
+		recorder = &cassette.Recorder{}
+	}
+
+	if rootReplayCassette != "" {
+		loaded, err := cassette.Load(rootReplayCassette)
+		if err != nil {
+			return err
+		}
+		player = cassette.NewPlayer(loaded)
+	}
+
+	return nil
+}
+
+// saveCassette saves the recorded cassette, if one is being recorded.
+func saveCassette() error {
+	if recorder == nil {
+		return nil
+	}
+
+	recorded := recorder.Cassette()
+	if err := recorded.Save(rootRecordCassette); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recorded %d interactions to %s\n", len(recorded.Interactions), rootRecordCassette)
+	return nil
+}
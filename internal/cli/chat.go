@@ -5,13 +5,58 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/spf13/cobra"
 
 	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/bench"
+	"github.com/shivanshkc/llmb/pkg/history"
+	"github.com/shivanshkc/llmb/pkg/pricing"
+	"github.com/shivanshkc/llmb/pkg/rag"
+	"github.com/shivanshkc/llmb/pkg/session"
+	"github.com/shivanshkc/llmb/pkg/transcript"
+)
+
+const (
+	// defaultBranch is the name of the conversation branch every chat starts on.
+	defaultBranch = "main"
+	// lastSessionName is the name under which every chat is saved when it ends,
+	// so that it can be resumed with a plain `--resume`.
+	lastSessionName = "last"
+	// historyLimit is the number of past inputs kept in the history.
+	historyLimit = 1000
+)
+
+var (
+	chatKB     string
+	chatKBTopK int
+	chatImages []string
+
+	chatTranscriptDir string
+	chatLogFile       string
+	chatShowReasoning bool
+	chatHideReasoning bool
+	chatStats         bool
+	chatPricingFile   string
+	chatAutosave      bool
+	chatSchemaFile    string
+	chatSchemaRetries int
+	chatResume        string
+	chatSessionName   string
+	chatSystem        string
+	chatSystemFile    string
+	chatContextBudget int
+	chatContextWindow int
+	chatStallAfter    time.Duration
+	chatNoHistory     bool
+	chatTools         []string
+	chatRolePrefixes  bool
 )
 
 // chatCmd represents the `chat` command, providing an interactive, REPL-style
@@ -24,80 +69,366 @@ var chatCmd = &cobra.Command{
 	Use:     "chat",
 	Short:   "Start an interactive chat with the LLM.",
 	Long:    "Starts an interactive chat session with the specified language model, maintaining conversation history.",
-	PreRunE: func(cmd *cobra.Command, args []string) error { return validateChatFlags() },
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateChatFlags(args)) },
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// chatMessages holds the full conversation history for the current session.
-		var chatMessages []api.ChatMessage
-		client := api.NewClient(rootBaseURL)
+		chat := &chatSession{
+			client:        newClient(),
+			id:            bench.NewRunID(),
+			branch:        defaultBranch,
+			showReasoning: chatShowReasoning,
+			hideReasoning: chatHideReasoning,
+			showStats:     chatStats,
+			rolePrefixes:  chatRolePrefixes,
+			contextBudget: chatContextBudget,
+			contextWindow: chatContextWindow,
+			stallAfter:    chatStallAfter,
+			options:       requestOptions,
+		}
 		reader := bufio.NewReader(os.Stdin)
+		chat.reader = reader
 
-		// The main chat loop.
-		for {
-			fmt.Print(text.FgBlue.Sprint("You: "))
+		// Load the knowledge base, if any, once for the whole session.
+		if chatKB != "" {
+			path, err := indexPath(chatKB)
+			if err != nil {
+				return err
+			}
+			if chat.kb, err = rag.Load(path); err != nil {
+				return fmt.Errorf("failed to load knowledge base %q: %w", chatKB, err)
+			}
+		}
 
-			// Read user input with context-awareness. This call will unblock and
-			// return an error if the command's context is canceled (e.g., by Ctrl+C).
-			input, err := readStringContext(cmd.Context(), reader)
+		// With a schema, every response is constrained to and validated against it.
+		if chatSchemaFile != "" {
+			schema, format, err := loadSchema(chatSchemaFile)
 			if err != nil {
-				// Ignore context cancellation errors.
-				if errors.Is(err, context.Canceled) {
-					return nil
-				}
-				return fmt.Errorf("failed to read input: %w", err)
+				return err
 			}
+			chat.schema, chat.options.ResponseFormat = schema, format
+			chat.schemaRetries = chatSchemaRetries
+		}
 
-			// Parse the raw input into a role and message content.
-			role, message := parseInput(input)
-			if message == "" {
-				continue // Ignore empty inputs.
+		// The enabled tools are offered to the model, which may call them.
+		for _, name := range chatTools {
+			if chat.tools == nil {
+				chat.tools = map[string]chatTool{}
 			}
+			chat.tools[name] = availableTools[name]
+			chat.options.Tools = append(chat.options.Tools, availableTools[name].definition)
+		}
 
-			// Add the user's input to the chat history.
-			chatMessages = append(chatMessages, api.ChatMessage{Role: role, Content: message})
+		// Load the pricing table, if any, to estimate the cost of every response.
+		if chatPricingFile != "" {
+			prices, err := pricing.Load(chatPricingFile)
+			if err != nil {
+				return err
+			}
+			if _, ok := prices[rootModel]; !ok {
+				logger.Warn("model is not in the pricing table, costs will not be shown", "model", rootModel)
+			}
+			chat.prices = prices
+		}
 
-			// Begin the streaming API call.
-			eventStream, err := client.ChatCompletionStream(cmd.Context(), rootModel, chatMessages)
+		// Every session gets its own transcript file in the transcript directory.
+		if chatTranscriptDir != "" {
+			path := filepath.Join(chatTranscriptDir, transcript.FileName(time.Now()))
+			writer, err := transcript.Open(path)
 			if err != nil {
-				// End if the context was canceled, otherwise log the error and continue chat.
-				if errors.Is(err, context.Canceled) {
-					return nil
-				}
-				fmt.Println("Failed to stream response:", err)
-				// Don't consider this message since the call failed.
-				chatMessages = chatMessages[:len(chatMessages)-1]
-				continue
-			}
-
-			// Consume the response stream token-by-token.
-			fmt.Print(text.FgGreen.Sprint("Assistant: "))
-			var answer string
-			for {
-				event, ok, err := eventStream.NextContext(cmd.Context())
-				if err != nil {
-					return nil // Context canceled.
-				}
+				return err
+			}
+			defer func() { _ = writer.Close() }()
+			chat.transcript, chat.transcriptPath = writer, path
+		}
 
-				// Stream ended.
-				if !ok {
-					break
-				}
+		// The log file is shared by all sessions, which are told apart by their IDs.
+		if chatLogFile != "" {
+			writer, err := transcript.Open(chatLogFile)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = writer.Close() }()
+			chat.logFile = writer
+		}
 
-				if len(event.Choices) > 0 {
-					token := event.Choices[0].Delta.Content
-					answer += token
-					fmt.Print(token)
-				}
+		// The inputs are only remembered when typed, like in shells, not when piped.
+		if !chatNoHistory && isTerminal(os.Stdin) {
+			if path, err := historyPath(); err != nil {
+				logger.Warn("failed to load the input history", "error", err)
+			} else if chat.history, err = history.Load(path, historyLimit); err != nil {
+				logger.Warn("failed to load the input history", "error", err)
 			}
-			fmt.Println("") // Newline after the full response.
+		}
+
+		// Images given as flags are attached to the first message.
+		for _, path := range chatImages {
+			if err := chat.attachImage(path); err != nil {
+				return err
+			}
+		}
+
+		// Continue a saved session if asked to.
+		if chatResume != "" {
+			path, err := sessionPath(chatResume)
+			if err != nil {
+				return err
+			}
+			saved, err := session.Load(path)
+			if err != nil {
+				return fmt.Errorf("failed to resume session %q: %w", chatResume, err)
+			}
+			chat.restore(saved)
+			fmt.Printf("Resumed session %q (%d messages on branch %q).\n", chatResume, len(chat.messages), chat.branch)
+		}
+
+		// Continue the named session if it exists, or start it, and save it after every turn.
+		if chatSessionName != "" {
+			path, err := sessionPath(chatSessionName)
+			if err != nil {
+				return err
+			}
+			saved, err := session.Load(path)
+			switch {
+			case err == nil:
+				chat.restore(saved)
+				fmt.Printf("Continuing session %q (%d messages on branch %q).\n", chatSessionName, len(chat.messages), chat.branch)
+			case errors.Is(err, os.ErrNotExist):
+				fmt.Printf("Starting session %q.\n", chatSessionName)
+			default:
+				return fmt.Errorf("failed to load session %q: %w", chatSessionName, err)
+			}
+			chat.sessionPath = path
+		}
 
-			// Add the assistant's complete response to the chat history.
-			chatMessages = append(chatMessages, api.ChatMessage{Role: api.RoleAssistant, Content: answer})
+		// Start the conversation with the system prompt, if any. A continued one already has its own.
+		if chatSystem != "" || chatSystemFile != "" {
+			if err := chat.startWithSystemPrompt(chatSystem, chatSystemFile); err != nil {
+				return err
+			}
 		}
+
+		// Offer to recover a session that didn't end normally, then start checkpointing this one.
+		// A deliberately resumed session takes precedence over the recovery offer, and a named
+		// session needs neither, since it's saved after every turn anyway.
+		var err error
+		switch {
+		case chatSessionName != "" || !chatAutosave:
+		case chatResume != "":
+			if chat.autosavePath, err = newAutosavePath(); err != nil {
+				return err
+			}
+		case !isTerminal(os.Stdin):
+			// Piped input can't answer the recovery offer, its first line would be taken as the answer.
+			if chat.autosavePath, err = newAutosavePath(); err != nil {
+				return err
+			}
+		default:
+			if err := recoverAutosave(cmd.Context(), reader, chat); err != nil {
+				// Ignore context cancellation errors.
+				if errors.Is(err, context.Canceled) {
+					return nil
+				}
+				return err
+			}
+		}
+
+		err = runChatLoop(cmd.Context(), reader, chat)
+		// Reaching here means there was no crash, so the checkpoint is no longer needed.
+		// The session is saved instead, to be resumable with a plain `--resume`.
+		chat.saveAsLast()
+		chat.discardAutosave()
+		return err
 	},
 }
 
+// init registers the chat command with the root command and defines its local flags.
 func init() {
 	rootCmd.AddCommand(chatCmd)
+
+	chatCmd.Flags().StringVar(&chatKB, "kb",
+		"", "Name of the knowledge base index to retrieve context from.")
+
+	chatCmd.Flags().IntVar(&chatKBTopK, "kb-top-k",
+		4, "Number of knowledge base chunks to retrieve per question.")
+
+	chatCmd.Flags().StringArrayVar(&chatImages, "image",
+		nil, "Path of an image to attach to the first message. Can be repeated.")
+
+	chatCmd.Flags().StringVar(&chatTranscriptDir, "transcript-dir",
+		"", "Directory to log every session transcript to. [env: LLMB_TRANSCRIPT_DIR]")
+
+	chatCmd.Flags().StringVar(&chatLogFile, "log-file",
+		os.Getenv("LLMB_LOG_FILE"), "JSONL file to append every message of every session to, for auditing. [env: LLMB_LOG_FILE]")
+
+	chatCmd.Flags().BoolVar(&chatShowReasoning, "show-reasoning",
+		false, "Show the model's reasoning instead of collapsing it.")
+
+	chatCmd.Flags().BoolVar(&chatHideReasoning, "hide-reasoning",
+		false, "Hide the model's reasoning entirely, instead of collapsing it into a notice.")
+
+	chatCmd.Flags().BoolVar(&chatStats, "stats",
+		false, "Show the TTFT, tokens, token rate and total time of every response, in a footer.")
+
+	chatCmd.Flags().BoolVar(&chatAutosave, "autosave",
+		true, "Checkpoint the session after every turn, to recover it after a crash.")
+
+	chatCmd.Flags().StringVar(&chatPricingFile, "pricing",
+		os.Getenv("LLMB_PRICING_FILE"), "JSON file of per-model token prices, to show estimated costs. [env: LLMB_PRICING_FILE]")
+
+	chatCmd.Flags().StringSliceVar(&chatTools, "tools",
+		nil, "Tools that the model may call, which run locally once confirmed: "+strings.Join(availableToolNames(), ", ")+".")
+
+	chatCmd.Flags().StringVar(&chatSchemaFile, "schema",
+		"", "JSON schema file that every response must match.")
+
+	chatCmd.Flags().IntVar(&chatSchemaRetries, "schema-retries",
+		2, "Number of times the model may correct a response that does not match the schema.")
+
+	chatCmd.Flags().StringVar(&chatResume, "resume",
+		"", "Continue a saved session. Without a name, continues the last session.")
+	// Allows the flag to be used without a value.
+	chatCmd.Flags().Lookup("resume").NoOptDefVal = lastSessionName
+
+	chatCmd.Flags().StringVarP(&chatSystem, "system", "s",
+		"", "System prompt to start the conversation with.")
+
+	chatCmd.Flags().StringVar(&chatSystemFile, "system-file",
+		"", "File of the system prompt to start the conversation with.")
+
+	chatCmd.Flags().StringVar(&chatSessionName, "session",
+		"", "Name of a session to continue, or to start if it doesn't exist, which is saved after every turn.")
+
+	chatCmd.Flags().BoolVar(&chatRolePrefixes, "role-prefixes",
+		false, `Send inputs that start with "system:", "assistant:" or "user:" with that role. Escape a prefix with a backslash.`)
+
+	chatCmd.Flags().BoolVar(&chatNoHistory, "no-history",
+		false, "Don't remember the inputs of this session in the input history, nor load it.")
+
+	chatCmd.Flags().IntVar(&chatContextBudget, "context-budget",
+		0, "Maximum estimated tokens of history to send, dropping the oldest unpinned messages. 0 means no limit.")
+
+	chatCmd.Flags().IntVar(&chatContextWindow, "context-window",
+		0, "Context window of the model in tokens, to show its usage with /tokens. 0 means unknown.")
+
+	addParamFlags(chatCmd.Flags())
+	addDryRunFlag(chatCmd.Flags())
+	addTimeoutFlags(chatCmd.Flags())
+	addNotifyFlag(chatCmd.Flags(), "a response")
+
+	chatCmd.Flags().DurationVar(&chatStallAfter, "stall-after",
+		5*time.Second, "Show a waiting indicator when no token arrives for this long. 0 disables it.")
+}
+
+// errChatEnded is returned when the user ends the chat, with /exit.
+var errChatEnded = errors.New("the chat was ended")
+
+// runChatLoop runs the read-eval-print loop of the chat until the input ends, the user ends the chat,
+// or the context is canceled.
+func runChatLoop(ctx context.Context, reader *bufio.Reader, chat *chatSession) error {
+	// The main chat loop.
+	for {
+		fmt.Print(text.FgBlue.Sprint("You: "))
+
+		// Read user input with context-awareness. This call will unblock and
+		// return an error if the command's context is canceled (e.g., by Ctrl+C).
+		input, err := readStringContext(ctx, reader)
+		// The end of the input, like of a piped one, ends the chat after its last line, if any.
+		ended := errors.Is(err, io.EOF)
+		if err != nil && !ended {
+			// Ignore context cancellation errors.
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+
+		if err := chat.handleInput(ctx, reader, input); err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, errChatEnded) {
+				return nil
+			}
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+
+		if ended {
+			fmt.Println()
+			return nil
+		}
+	}
+}
+
+// handleInput handles a raw line of input, reading the rest of the message if it starts a multi-line one.
+// It returns an error only if reading fails, the user ends the chat or the context is canceled, and reports
+// other failures itself.
+func (s *chatSession) handleInput(ctx context.Context, reader *bufio.Reader, input string) error {
+	// Past inputs can be recalled from the history, like in shells, and are shown once recalled.
+	if s.history != nil {
+		recalled, ok, err := s.history.Expand(input)
+		if err != nil {
+			fmt.Println(err)
+			return nil
+		}
+		if ok {
+			fmt.Println(recalled)
+			input = recalled
+		}
+	}
+
+	// A message between triple quotes may span multiple lines.
+	multiline := isMultilineStart(input)
+	if multiline {
+		var err error
+		if input, err = readMultiline(ctx, reader, input); err != nil {
+			return err
+		}
+	}
+	s.remember(input)
+
+	if !multiline && isChatCommand(input) {
+		// Slash commands are handled locally and never sent to the model.
+		if err := s.runCommand(ctx, input); err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, errChatEnded) {
+				return err
+			}
+			fmt.Println(err)
+		}
+		return nil
+	}
+
+	return s.submit(ctx, input)
+}
+
+// submit sends the given raw input to the model, with the role of its prefix, if any.
+// It returns an error only if the context is canceled, and reports other failures itself.
+func (s *chatSession) submit(ctx context.Context, input string) error {
+	// Parse the raw input into a role and message content.
+	role, message := parseInput(input, s.rolePrefixes)
+	if message == "" {
+		return nil // Ignore empty inputs.
+	}
+
+	// Files mentioned as @path are included in the message.
+	for _, path := range inlineFilePaths(message) {
+		if err := s.attachFile(path); err != nil {
+			fmt.Println(err)
+			return nil
+		}
+	}
+
+	err := s.send(ctx, role, message)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return err
+	case err == nil:
+		notify("The response", nil)
+	case errors.Is(err, errResponseAborted):
+		// An aborted response is no failure, the user can simply ask again.
+		fmt.Println(text.Faint.Sprint("Response aborted."))
+	case errors.Is(err, api.ErrDryRun):
+		// The request was printed instead, and the message is left out of the history.
+	default:
+		logger.Error("failed to stream response", "error", err)
+		notify("The response", err)
+	}
+	return nil
 }
 
 // readStringContext reads a line of text from a Reader but aborts early
@@ -139,29 +470,35 @@ func readStringContext(ctx context.Context, reader *bufio.Reader) (string, error
 
 // parseInput sanitizes raw user input and parses it to determine the message
 // content and the intended role (system, user, or assistant).
-// If no role prefix (e.g., "system:") is found, it defaults to the "user" role.
-func parseInput(input string) (role, message string) {
+// If role prefixes are disabled, or no role prefix (e.g., "system:") is found,
+// it defaults to the "user" role. A backslash before a role prefix escapes it.
+func parseInput(input string, rolePrefixes bool) (role, message string) {
 	message = strings.TrimSpace(input)
 	if message == "" {
 		return "", ""
 	}
-
-	const (
-		systemPrefix    = api.RoleSystem + ":"
-		assistantPrefix = api.RoleAssistant + ":"
-		userPrefix      = api.RoleUser + ":"
-	)
-
-	if strings.HasPrefix(strings.ToLower(message), systemPrefix) {
-		return api.RoleSystem, strings.TrimSpace(message[len(systemPrefix):])
+	if !rolePrefixes {
+		return api.RoleUser, message
 	}
-	if strings.HasPrefix(strings.ToLower(message), assistantPrefix) {
-		return api.RoleAssistant, strings.TrimSpace(message[len(assistantPrefix):])
+
+	// An escaped prefix is sent as is, without the backslash.
+	if escaped, ok := strings.CutPrefix(message, `\`); ok && rolePrefix(escaped) != "" {
+		return api.RoleUser, escaped
 	}
-	if strings.HasPrefix(strings.ToLower(message), userPrefix) {
-		return api.RoleUser, strings.TrimSpace(message[len(userPrefix):])
+	if role := rolePrefix(message); role != "" {
+		return role, strings.TrimSpace(message[len(role)+1:])
 	}
 
 	// Default to the user role if no prefix is provided.
 	return api.RoleUser, message
 }
+
+// rolePrefix returns the role whose prefix, like "system:", starts the message, regardless of case, if any.
+func rolePrefix(message string) string {
+	for _, role := range []string{api.RoleSystem, api.RoleAssistant, api.RoleUser} {
+		if strings.HasPrefix(strings.ToLower(message), role+":") {
+			return role
+		}
+	}
+	return ""
+}
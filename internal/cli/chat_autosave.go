@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shivanshkc/llmb/pkg/session"
+)
+
+// recoverAutosave looks for a checkpoint left behind by a chat that did not end
+// normally, offers to resume it, and then enables checkpointing for the given session.
+//
+// Only the latest leftover checkpoint is offered. It is removed whether or not
+// it's resumed, so the offer is never repeated; a resumed conversation lives on
+// in the new session's checkpoint.
+func recoverAutosave(ctx context.Context, reader *bufio.Reader, s *chatSession) error {
+	dir, err := autosaveDir()
+	if err != nil {
+		return err
+	}
+
+	// Checkpoint names sort chronologically.
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list autosaved sessions: %w", err)
+	}
+	sort.Strings(paths)
+
+	if s.autosavePath, err = newAutosavePath(); err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+	latest := paths[len(paths)-1]
+
+	saved, err := session.Load(latest)
+	if err != nil {
+		logger.Warn("ignoring unreadable autosaved session", "path", latest, "error", err)
+		return nil
+	}
+
+	fmt.Printf("Found a conversation from %s (%d messages) that did not end normally. Resume it? [y/N]: ",
+		saved.UpdatedAt.Local().Format(time.DateTime), len(saved.Messages()))
+
+	answer, err := readStringContext(ctx, reader)
+	// The end of input means no.
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if strings.EqualFold(strings.TrimSpace(answer), "y") {
+		s.restore(saved)
+		// Save under the new name before removing the old one, so there's always a copy on disk.
+		s.checkpoint()
+		fmt.Printf("Resumed %d messages on branch %q.\n", len(s.messages), s.branch)
+	}
+
+	if err := os.Remove(latest); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove the autosaved session: %w", err)
+	}
+
+	return nil
+}
+
+// newAutosavePath returns the path of the checkpoint file for a session starting now.
+func newAutosavePath() (string, error) {
+	dir, err := autosaveDir()
+	if err != nil {
+		return "", err
+	}
+
+	// Named by the start time, so the checkpoints sort chronologically.
+	return filepath.Join(dir, time.Now().Format("2006-01-02T15-04-05.000")+".json"), nil
+}
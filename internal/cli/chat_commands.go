@@ -0,0 +1,550 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/api/tokenizer"
+	"github.com/shivanshkc/llmb/pkg/session"
+	"github.com/shivanshkc/llmb/pkg/transcript"
+)
+
+// chatCommand is a slash command available in the chat REPL, like `/attach`.
+type chatCommand struct {
+	usage       string
+	description string
+	run         func(ctx context.Context, s *chatSession, args string) error
+}
+
+// chatCommands holds all the chat slash commands by name.
+// It is populated in init because the `/help` command refers to it.
+var chatCommands map[string]chatCommand
+
+func init() {
+	chatCommands = map[string]chatCommand{
+		"help": {
+			usage:       "/help",
+			description: "Show the available commands.",
+			run:         runHelpCommand,
+		},
+		"attach": {
+			usage:       "/attach <image-path>",
+			description: "Attach an image to the next message.",
+			run:         runAttachCommand,
+		},
+		"editor": {
+			usage:       "/editor",
+			description: "Compose a message in $EDITOR, and send it once the editor is closed.",
+			run:         runEditorCommand,
+		},
+		"exit": {
+			usage:       "/exit",
+			description: "End the chat.",
+			run:         runExitCommand,
+		},
+		"export": {
+			usage:       "/export <file>",
+			description: "Export the conversation as Markdown, or as JSON for .json files.",
+			run:         runExportCommand,
+		},
+		"file": {
+			usage:       "/file <path>",
+			description: "Include a text file in the next message, in a fenced block.",
+			run:         runFileCommand,
+		},
+		"fork": {
+			usage:       "/fork <name>",
+			description: "Copy the conversation into a new branch and switch to it.",
+			run:         runForkCommand,
+		},
+		"history": {
+			usage:       "/history [n]",
+			description: "List the last n inputs, 20 by default. Recall one with !<number>, or the last with !!.",
+			run:         runHistoryCommand,
+		},
+		"pin": {
+			usage:       "/pin [n|last]",
+			description: "Keep message n in the context window, or list the messages and pins.",
+			run:         runPinCommand,
+		},
+		"reasoning": {
+			usage:       "/reasoning [on|off|hide]",
+			description: "Show the last response's reasoning, or show, collapse or hide reasoning.",
+			run:         runReasoningCommand,
+		},
+		"save": {
+			usage:       "/save <name>",
+			description: "Save the session, to continue it later with --resume <name>.",
+			run:         runSaveCommand,
+		},
+		"search": {
+			usage:       "/search <term>",
+			description: "Search the messages of this session and the logged transcripts.",
+			run:         runSearchCommand,
+		},
+		"system": {
+			usage:       "/system [text|--edit]",
+			description: "Replace the system prompt, edit it in $EDITOR, or show it.",
+			run:         runSystemCommand,
+		},
+		"tokens": {
+			usage:       "/tokens",
+			description: "Show the tokens of every message, and the usage of the context window.",
+			run:         runTokensCommand,
+		},
+		"undo": {
+			usage:       "/undo",
+			description: "Remove the last message and the responses to it from the conversation.",
+			run:         runUndoCommand,
+		},
+		"unpin": {
+			usage:       "/unpin <n|all>",
+			description: "Let a pinned message, or all of them, be trimmed from the context window.",
+			run:         runUnpinCommand,
+		},
+		"switch": {
+			usage:       "/switch [name]",
+			description: "Switch to another branch, or list the branches.",
+			run:         runSwitchCommand,
+		},
+	}
+}
+
+// isChatCommand reports whether the given raw input is a slash command.
+func isChatCommand(input string) bool {
+	return strings.HasPrefix(strings.TrimSpace(input), "/")
+}
+
+// runCommand parses and executes the given slash command input.
+func (s *chatSession) runCommand(ctx context.Context, input string) error {
+	name, args, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(input), "/"), " ")
+
+	command, ok := chatCommands[name]
+	if !ok {
+		return fmt.Errorf("unknown command: /%s, use /help to see the available commands", name)
+	}
+
+	return command.run(ctx, s, strings.TrimSpace(args))
+}
+
+// runHelpCommand lists all the available slash commands.
+func runHelpCommand(_ context.Context, _ *chatSession, _ string) error {
+	names := make([]string, 0, len(chatCommands))
+	for name := range chatCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("  %-28s %s\n", chatCommands[name].usage, chatCommands[name].description)
+	}
+	return nil
+}
+
+// runAttachCommand attaches the image at the given path to the next message.
+func runAttachCommand(_ context.Context, s *chatSession, args string) error {
+	if args == "" {
+		return fmt.Errorf("usage: %s", chatCommands["attach"].usage)
+	}
+
+	if err := s.attachImage(args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Attached %s, it will be sent with the next message.\n", args)
+	return nil
+}
+
+// runExitCommand ends the chat.
+func runExitCommand(_ context.Context, _ *chatSession, _ string) error {
+	return errChatEnded
+}
+
+// runExportCommand exports the conversation of the current branch to the given file,
+// in the format implied by its extension.
+func runExportCommand(_ context.Context, s *chatSession, args string) error {
+	if args == "" {
+		return fmt.Errorf("usage: %s", chatCommands["export"].usage)
+	}
+
+	format := session.FormatForPath(args)
+	if err := exportSession(s.snapshot(), args, format); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d messages to %s, as %s.\n", len(s.messages), args, format)
+	return nil
+}
+
+// runFileCommand includes the text file at the given path in the next message.
+func runFileCommand(_ context.Context, s *chatSession, args string) error {
+	if args == "" {
+		return fmt.Errorf("usage: %s", chatCommands["file"].usage)
+	}
+
+	if err := s.attachFile(args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Attached %s, it will be included in the next message.\n", args)
+	return nil
+}
+
+// runForkCommand copies the conversation into a new branch and switches to it.
+func runForkCommand(_ context.Context, s *chatSession, args string) error {
+	if args == "" {
+		return fmt.Errorf("usage: %s", chatCommands["fork"].usage)
+	}
+
+	previous := s.branch
+	if err := s.fork(args); err != nil {
+		return err
+	}
+	s.checkpoint()
+
+	fmt.Printf("Forked %q into %q, use /switch %s to go back.\n", previous, args, previous)
+	return nil
+}
+
+// runSwitchCommand switches to the given branch, or lists all branches if none is given.
+func runSwitchCommand(_ context.Context, s *chatSession, args string) error {
+	if args == "" {
+		for _, name := range s.branchNames() {
+			marker := " "
+			if name == s.branch {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\n", marker, name)
+		}
+		return nil
+	}
+
+	if err := s.switchBranch(args); err != nil {
+		return err
+	}
+	s.checkpoint()
+
+	fmt.Printf("Switched to %q (%d messages).\n", s.branch, len(s.messages))
+	return nil
+}
+
+// runHistoryCommand lists the last inputs of the history, with their numbers.
+func runHistoryCommand(_ context.Context, s *chatSession, args string) error {
+	if s.history == nil {
+		fmt.Println("The input history is disabled.")
+		return nil
+	}
+
+	count := 20
+	if args != "" {
+		var err error
+		if count, err = strconv.Atoi(args); err != nil || count <= 0 {
+			return fmt.Errorf("invalid number of inputs %q, usage: %s", args, chatCommands["history"].usage)
+		}
+	}
+
+	entries := s.history.Entries()
+	start := max(len(entries)-count, 0)
+	for i, entry := range entries[start:] {
+		fmt.Printf("%5d  %s\n", start+i+1, strings.ReplaceAll(entry, "\n", " ↵ "))
+	}
+	return nil
+}
+
+// runPinCommand pins the given message, or lists the messages with their numbers if none is given.
+func runPinCommand(_ context.Context, s *chatSession, args string) error {
+	if args == "" {
+		if len(s.messages) == 0 {
+			fmt.Println("There are no messages yet.")
+			return nil
+		}
+
+		for i, message := range s.messages {
+			marker := " "
+			if s.isPinned(i) {
+				marker = "*"
+			}
+			fmt.Printf("%s %3d %s: %s\n", marker, i+1, message.Role, messagePreview(message))
+		}
+		return nil
+	}
+
+	index, err := parseMessageNumber(s, args)
+	if err != nil {
+		return fmt.Errorf("%w, usage: %s", err, chatCommands["pin"].usage)
+	}
+	if err := s.pin(index); err != nil {
+		return err
+	}
+	s.checkpoint()
+
+	fmt.Printf("Pinned message %d, it will never be trimmed from the context.\n", index+1)
+	return nil
+}
+
+// runSystemCommand replaces the system prompt with the given text, or with the one edited in the
+// user's editor for --edit, or shows it if no text is given.
+func runSystemCommand(ctx context.Context, s *chatSession, args string) error {
+	prompt := args
+	switch args {
+	case "":
+		if current := s.systemPrompt(); current != "" {
+			fmt.Println(current)
+		} else {
+			fmt.Println("There is no system prompt.")
+		}
+		return nil
+	case "--edit":
+		edited, err := editMessage(ctx, s.systemPrompt())
+		if err != nil {
+			return err
+		}
+		prompt = strings.TrimSpace(edited)
+		if prompt == "" {
+			fmt.Println("The system prompt is empty, nothing was changed.")
+			return nil
+		}
+	}
+
+	s.setSystemPrompt(prompt)
+	s.checkpoint()
+
+	fmt.Println("Updated the system prompt, it applies from the next message.")
+	return nil
+}
+
+// runUndoCommand removes the last exchange from the conversation.
+func runUndoCommand(_ context.Context, s *chatSession, _ string) error {
+	removed, err := s.undo()
+	if err != nil {
+		return err
+	}
+	s.checkpoint()
+
+	fmt.Printf("Removed the last exchange (%d messages), it will not be sent again.\n", removed)
+	return nil
+}
+
+// runUnpinCommand unpins the given message, or all of them.
+func runUnpinCommand(_ context.Context, s *chatSession, args string) error {
+	if args == "all" {
+		delete(s.pins, s.branch)
+		s.checkpoint()
+		fmt.Println("Unpinned all messages.")
+		return nil
+	}
+
+	index, err := parseMessageNumber(s, args)
+	if err != nil {
+		return fmt.Errorf("%w, usage: %s", err, chatCommands["unpin"].usage)
+	}
+	if err := s.unpin(index); err != nil {
+		return err
+	}
+	s.checkpoint()
+
+	fmt.Printf("Unpinned message %d.\n", index+1)
+	return nil
+}
+
+// parseMessageNumber parses the 1-based number of a message of the current branch, or "last",
+// and returns its index.
+func parseMessageNumber(s *chatSession, args string) (int, error) {
+	if args == "last" {
+		if len(s.messages) == 0 {
+			return 0, errors.New("there are no messages yet")
+		}
+		return len(s.messages) - 1, nil
+	}
+
+	number, err := strconv.Atoi(args)
+	if err != nil {
+		return 0, fmt.Errorf("invalid message number %q", args)
+	}
+	return number - 1, nil
+}
+
+// runReasoningCommand shows the reasoning behind the last response, or turns
+// the display of reasoning on or off.
+func runReasoningCommand(_ context.Context, s *chatSession, args string) error {
+	switch args {
+	case "":
+		if s.lastReasoning == "" {
+			fmt.Println("The last response had no reasoning.")
+			return nil
+		}
+		fmt.Println(text.Faint.Sprint(strings.TrimSpace(s.lastReasoning)))
+	case "on":
+		s.showReasoning, s.hideReasoning = true, false
+		fmt.Println("Reasoning will be shown.")
+	case "off":
+		s.showReasoning, s.hideReasoning = false, false
+		fmt.Println("Reasoning will be collapsed.")
+	case "hide":
+		s.showReasoning, s.hideReasoning = false, true
+		fmt.Println("Reasoning will be hidden.")
+	default:
+		return fmt.Errorf("usage: %s", chatCommands["reasoning"].usage)
+	}
+	return nil
+}
+
+// runSearchCommand searches all branches of the current session, and all the
+// stored transcripts in the transcript directory, for messages matching the given term.
+func runSearchCommand(_ context.Context, s *chatSession, args string) error {
+	if args == "" {
+		return fmt.Errorf("usage: %s", chatCommands["search"].usage)
+	}
+
+	// Characters of context to show on either side of a match.
+	const radius = 60
+	// Cap the output so a common term doesn't flood the terminal.
+	const maxHits = 20
+
+	var hits []transcript.Hit
+	var labels []string
+
+	// Search the stored transcripts first, so the output is roughly chronological.
+	if chatTranscriptDir != "" {
+		paths, err := filepath.Glob(filepath.Join(chatTranscriptDir, "*.jsonl"))
+		if err != nil {
+			return fmt.Errorf("failed to list transcripts: %w", err)
+		}
+		sort.Strings(paths) // Transcript names sort chronologically.
+
+		for _, path := range paths {
+			// The current session's transcript is searched in memory instead.
+			if path == s.transcriptPath {
+				continue
+			}
+
+			entries, err := transcript.Read(path)
+			if err != nil {
+				fmt.Printf("Skipping %s: %v\n", path, err)
+				continue
+			}
+
+			for _, hit := range transcript.Search(entries, args, radius) {
+				hits = append(hits, hit)
+				labels = append(labels, hit.Time.Local().Format(time.DateTime))
+			}
+		}
+	}
+
+	// Search all branches of the current session.
+	for _, branch := range s.branchNames() {
+		messages := s.branches[branch]
+		if branch == s.branch {
+			messages = s.messages
+		}
+
+		entries := make([]transcript.Entry, len(messages))
+		for i, message := range messages {
+			entries[i] = transcript.Entry{Message: message}
+		}
+
+		for _, hit := range transcript.Search(entries, args, radius) {
+			hits = append(hits, hit)
+			labels = append(labels, "this session, "+branch)
+		}
+	}
+
+	if len(hits) == 0 {
+		fmt.Println("No matches found.")
+		return nil
+	}
+
+	// Show only the latest hits.
+	if len(hits) > maxHits {
+		fmt.Printf("Showing the latest %d of %d matches.\n", maxHits, len(hits))
+		hits, labels = hits[len(hits)-maxHits:], labels[len(labels)-maxHits:]
+	}
+
+	for i, hit := range hits {
+		fmt.Printf("%s %s: %s%s%s\n",
+			text.Faint.Sprintf("[%s]", labels[i]),
+			hit.Message.Role,
+			hit.Snippet[:hit.MatchStart],
+			text.FgYellow.Sprint(hit.Snippet[hit.MatchStart:hit.MatchEnd]),
+			hit.Snippet[hit.MatchEnd:],
+		)
+	}
+	return nil
+}
+
+// runSaveCommand saves the session under the given name.
+func runSaveCommand(_ context.Context, s *chatSession, args string) error {
+	if err := validateName("session", args); err != nil {
+		return fmt.Errorf("%w, usage: %s", err, chatCommands["save"].usage)
+	}
+
+	if err := s.save(args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved the session as %q, continue it later with: llmb chat --resume %s\n", args, args)
+	return nil
+}
+
+// runTokensCommand shows the estimated number of tokens of every message of the history, marking those
+// left out to fit the context budget, and the total against the context window of the model, if it's known.
+func runTokensCommand(_ context.Context, s *chatSession, _ string) error {
+	if len(s.messages) == 0 {
+		fmt.Println("There are no messages yet.")
+		return nil
+	}
+
+	budget := s.contextBudget
+	if budget == 0 {
+		budget = math.MaxInt // No budget keeps all the messages.
+	}
+	messages := session.ChatMessages(s.messages)
+	kept := messages.KeptInTokens(rootModel, budget, s.pins[s.branch])
+
+	var sent session.ChatMessages
+	overhead := tokenizer.CountTokens(rootModel, nil)
+	for i, message := range messages {
+		marker := " "
+		if kept[i] {
+			sent = append(sent, message)
+		} else {
+			marker = "-"
+		}
+		tokens := tokenizer.CountTokens(rootModel, messages[i:i+1]) - overhead
+		fmt.Printf("%s %3d %-10s %6d  %s\n", marker, i+1, message.Role+":", tokens, messagePreview(message))
+	}
+
+	total := sent.Tokens(rootModel)
+	if len(sent) < len(messages) {
+		fmt.Printf("Messages marked with - are left out to fit the context budget of %d tokens.\n", s.contextBudget)
+	}
+	if s.contextWindow == 0 {
+		fmt.Printf("Total: ~%d tokens of history sent with the next message. The context window of the model is unknown, "+
+			"set it with --context-window.\n", total)
+		return nil
+	}
+	fmt.Printf("Total: ~%d tokens of history sent with the next message, %.1f%% of the context window of %d tokens.\n",
+		total, 100*float64(total)/float64(s.contextWindow), s.contextWindow)
+	return nil
+}
+
+// messagePreview returns the start of the content of the message on a single line, to list it.
+func messagePreview(message api.ChatMessage) string {
+	// Characters of the content to show.
+	const previewLength = 60
+	preview := strings.Join(strings.Fields(message.Content), " ")
+	if len(preview) > previewLength {
+		preview = preview[:previewLength] + "..."
+	}
+	return preview
+}
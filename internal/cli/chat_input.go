@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// multilineDelimiter starts and ends a message that spans multiple lines, like pasted code.
+const multilineDelimiter = `"""`
+
+// isMultilineStart reports whether the given raw input starts a multi-line message.
+func isMultilineStart(input string) bool {
+	return strings.HasPrefix(strings.TrimSpace(input), multilineDelimiter)
+}
+
+// readMultiline reads a multi-line message, whose first line is given, up to the line that ends with
+// the delimiter, and returns it without the delimiters. The message may end on its first line, or at
+// the end of the input.
+func readMultiline(ctx context.Context, reader *bufio.Reader, first string) (string, error) {
+	line := strings.TrimPrefix(strings.TrimLeft(first, " \t"), multilineDelimiter)
+
+	var lines []string
+	for {
+		trimmed := strings.TrimRight(line, " \t\r\n")
+		if strings.HasSuffix(trimmed, multilineDelimiter) {
+			lines = append(lines, strings.TrimSuffix(trimmed, multilineDelimiter))
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, strings.TrimRight(line, "\r\n"))
+
+		fmt.Print(text.Faint.Sprint("... "))
+		var err error
+		line, err = readStringContext(ctx, reader)
+		if errors.Is(err, io.EOF) {
+			lines = append(lines, line)
+			return strings.Join(lines, "\n"), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// confirm asks the user the given yes or no question, and reports whether they answered yes.
+// The end of the input means no.
+func confirm(ctx context.Context, reader *bufio.Reader, question string) (bool, error) {
+	fmt.Print(question)
+	answer, err := readStringContext(ctx, reader)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	return strings.EqualFold(strings.TrimSpace(answer), "y"), nil
+}
+
+// isTerminal reports whether the given file is a terminal, rather than, for example, a pipe or a regular file.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runEditorCommand opens the user's editor to compose a message, and sends it once the editor is closed.
+func runEditorCommand(ctx context.Context, s *chatSession, _ string) error {
+	message, err := editMessage(ctx, "")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(message) == "" {
+		fmt.Println("The message is empty, nothing was sent.")
+		return nil
+	}
+
+	fmt.Println(message)
+	return s.submit(ctx, message)
+}
+
+// editMessage opens the editor named by $VISUAL or $EDITOR, or else vi, on a temporary file with the
+// given initial content, and returns the content of the file once the editor is closed.
+func editMessage(ctx context.Context, initial string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	file, err := os.CreateTemp("", "llmb-message-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create message file: %w", err)
+	}
+	path := file.Name()
+	defer func() { _ = os.Remove(path) }()
+	_, err = file.WriteString(initial)
+	_ = file.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to write message file: %w", err)
+	}
+
+	// The editor may come with arguments, like "code --wait".
+	fields := strings.Fields(editor)
+	cmd := exec.CommandContext(ctx, fields[0], append(fields[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("failed to run editor %q: %w", editor, err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read message file: %w", err)
+	}
+	return strings.TrimRight(string(content), "\n"), nil
+}
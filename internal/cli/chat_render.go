@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/bench"
+	"github.com/shivanshkc/llmb/pkg/highlight"
+	"github.com/shivanshkc/llmb/pkg/reasoning"
+)
+
+// responseRenderer prints a streamed assistant response to standard output,
+// keeping the model's reasoning apart from its answer.
+//
+// Reasoning arrives either as separate reasoning deltas, or inline within
+// `<think>…</think>` tags. Either way, it is rendered dimmed if shown, and
+// collapsed into a short notice otherwise.
+type responseRenderer struct {
+	showReasoning bool
+	// quiet renders only the answer, without the reasoning or the notice that replaces it.
+	quiet bool
+	// out is where the answer is rendered. It defaults to standard output.
+	out io.Writer
+	// highlight colors the code blocks of the answer by their language, line by line.
+	highlight bool
+	// highlighter renders the answer when it is highlighted.
+	highlighter *highlight.Writer
+
+	splitter           reasoning.Splitter
+	answer, thoughts   strings.Builder
+	inReasoningSection bool
+}
+
+// write renders the given delta of the response.
+func (r *responseRenderer) write(delta api.ChatCompletionDelta) {
+	inlineReasoning, answer := r.splitter.Write(delta.Content)
+	r.writeReasoning(delta.ReasoningContent + inlineReasoning)
+	// A refusal takes the place of the answer.
+	r.writeAnswer(answer + delta.Refusal)
+}
+
+// finish renders whatever is left of the response and returns the complete
+// answer and reasoning. The answer is what belongs in the chat history.
+func (r *responseRenderer) finish() (answer, reasoning string) {
+	flushedReasoning, flushedAnswer := r.splitter.Flush()
+	r.writeReasoning(flushedReasoning)
+	r.writeAnswer(flushedAnswer)
+	r.endReasoningSection()
+	if r.highlighter != nil {
+		_ = r.highlighter.Flush()
+	}
+
+	fmt.Fprintln(r.output()) // Newline after the full response.
+	return r.answer.String(), r.thoughts.String()
+}
+
+// writeReasoning renders the given reasoning text.
+func (r *responseRenderer) writeReasoning(token string) {
+	if token == "" {
+		return
+	}
+
+	// Leading whitespace is noise at the start of the section.
+	if !r.inReasoningSection {
+		if token = strings.TrimLeft(token, " \n"); token == "" {
+			return
+		}
+		r.inReasoningSection = true
+		if !r.showReasoning && !r.quiet {
+			fmt.Print(text.Faint.Sprint("Thinking..."))
+		}
+	}
+
+	r.thoughts.WriteString(token)
+	if r.showReasoning && !r.quiet {
+		fmt.Print(text.Faint.Sprint(token))
+	}
+}
+
+// writeAnswer renders the given answer text.
+func (r *responseRenderer) writeAnswer(token string) {
+	if token == "" {
+		return
+	}
+
+	// Models usually separate the reasoning from the answer with blank lines,
+	// which are noise once the reasoning is rendered separately.
+	if r.answer.Len() == 0 {
+		if token = strings.TrimLeft(token, " \n"); token == "" {
+			return
+		}
+	}
+
+	r.endReasoningSection()
+	r.answer.WriteString(token)
+	if r.highlight {
+		if r.highlighter == nil {
+			r.highlighter = highlight.NewWriter(r.output())
+		}
+		_, _ = io.WriteString(r.highlighter, token)
+		return
+	}
+	fmt.Fprint(r.output(), token)
+}
+
+// endReasoningSection closes the reasoning section, if one is open.
+func (r *responseRenderer) endReasoningSection() {
+	if !r.inReasoningSection {
+		return
+	}
+	r.inReasoningSection = false
+
+	if r.quiet {
+		return
+	}
+	if r.showReasoning {
+		fmt.Print("\n\n")
+		return
+	}
+	fmt.Println(text.Faint.Sprint(" done. Use /reasoning to show it."))
+}
+
+// output returns where the answer is rendered.
+func (r *responseRenderer) output() io.Writer {
+	if r.out == nil {
+		return os.Stdout
+	}
+	return r.out
+}
+
+// finishNotice returns a notice about how the response ended, if it didn't end naturally, or an empty string.
+func finishNotice(result api.StreamResult) string {
+	switch {
+	case result.Refusal != "":
+		return "The model refused to respond."
+	case result.FinishReason == api.FinishReasonLength:
+		return "The response was cut off at the maximum number of tokens, or at the end of the context window."
+	case result.FinishReason == api.FinishReasonContentFilter:
+		return "The response was cut off by the content filter of the server."
+	default:
+		return ""
+	}
+}
+
+// statsFooter formats the timing metrics of a response as a one-line footer, like
+// "TTFT 312.45ms · 142 tokens · 45.30 tok/s · 3.14s". Estimated token counts are marked with "~".
+func statsFooter(stats bench.StreamStats) string {
+	tokens := strconv.Itoa(stats.Tokens)
+	if stats.Estimated {
+		tokens = "~" + tokens
+	}
+	return fmt.Sprintf("TTFT %s · %s tokens · %.2f tok/s · %s",
+		formatDuration(stats.TTFT), tokens, stats.TokensPerSecond, formatDuration(stats.Total))
+}
@@ -0,0 +1,784 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/bench"
+	"github.com/shivanshkc/llmb/pkg/history"
+	"github.com/shivanshkc/llmb/pkg/jsonschema"
+	"github.com/shivanshkc/llmb/pkg/pricing"
+	"github.com/shivanshkc/llmb/pkg/rag"
+	"github.com/shivanshkc/llmb/pkg/reasoning"
+	"github.com/shivanshkc/llmb/pkg/session"
+	"github.com/shivanshkc/llmb/pkg/streams"
+	"github.com/shivanshkc/llmb/pkg/transcript"
+)
+
+// chatSession holds the state of an interactive chat session.
+type chatSession struct {
+	client *api.Client
+	// reader reads the input of the user, like the answers to confirmations.
+	reader *bufio.Reader
+	// kb is the optional knowledge base to retrieve context from.
+	kb *rag.Index
+
+	// messages holds the full conversation history for the current branch.
+	messages []api.ChatMessage
+	// branch is the name of the current conversation branch.
+	branch string
+	// branches holds the histories of all the other conversation branches by name.
+	branches map[string][]api.ChatMessage
+	// pins holds the indices of the pinned messages of every branch, including the current one, by name.
+	pins map[string][]int
+	// contextBudget is the number of tokens of history sent with every message, or zero for no limit.
+	contextBudget int
+	// contextWindow is the number of tokens of the context window of the model, or zero if it's unknown.
+	contextWindow int
+	// leftOut is the number of messages that were left out to fit the context budget the last time,
+	// so that the user is told only when more are left out.
+	leftOut int
+	// attachments are the content parts to be sent along with the next message.
+	attachments []api.ContentPart
+	// files are the fenced blocks of the files to be included in the next message, by path, in order.
+	files []fileAttachment
+
+	// stallAfter is how long to wait for a token before showing that the server stalls. Zero disables it.
+	stallAfter time.Duration
+
+	// options are the request parameters sent with every message.
+	options api.ChatOptions
+	// tools holds the tools that the model may call, by name.
+	tools map[string]chatTool
+	// schema, if set, is the JSON schema that every response must match.
+	schema *jsonschema.Schema
+	// schemaRetries is the number of times the model may correct a response that doesn't match the schema.
+	schemaRetries int
+
+	// showReasoning controls whether the model's reasoning is displayed or collapsed,
+	// and hideReasoning hides it entirely, without the notice that replaces it.
+	showReasoning bool
+	hideReasoning bool
+	// showStats controls whether the timing metrics of every response are displayed.
+	showStats bool
+	// rolePrefixes controls whether inputs that start with a role prefix, like "system:", are sent with that role.
+	rolePrefixes bool
+	// lastReasoning is the reasoning behind the last response, kept for display on demand.
+	lastReasoning string
+
+	// prices is the optional pricing table used to estimate the cost of every response.
+	prices pricing.Table
+	// sessionCost is the cumulative estimated cost of the session.
+	sessionCost float64
+
+	// transcript is the optional writer that logs every message of the session.
+	transcript *transcript.Writer
+	// transcriptPath is the path of the file the transcript writer writes to.
+	transcriptPath string
+	// logFile is the optional writer that logs every message of the session to a transcript shared by sessions.
+	logFile *transcript.Writer
+	// id identifies the session in the transcripts.
+	id string
+
+	// history holds the inputs of past and current sessions, to recall them. It is nil if disabled.
+	history *history.History
+
+	// autosavePath is the path of the file the session is checkpointed to, if autosave is enabled.
+	autosavePath string
+	// sessionPath is the path of the file of the named session, which is saved along with every checkpoint, if any.
+	sessionPath string
+}
+
+// send adds the given message to the history, streams the model's response to
+// standard output and adds the complete response to the history as well. If the
+// model calls tools, their results are sent back to it, until it answers.
+//
+// If the API call fails, the message is removed from the history, along with the
+// tool calls made for it, so that the user can simply try again. A response that the
+// user aborts is kept as far as it went, unless nothing arrived.
+func (s *chatSession) send(ctx context.Context, role, message string) error {
+	// Add the user's input to the chat history, along with any attachments and files.
+	sentAt := time.Now()
+	content := message
+	for _, file := range s.files {
+		content += "\n\n" + file.block
+	}
+	start := len(s.messages)
+	s.messages = append(s.messages, api.ChatMessage{Role: role, Content: content, Parts: s.attachments})
+	attachments, files := s.attachments, s.files
+	s.attachments, s.files = nil, nil
+
+	// discard removes the message since the call failed, restoring its attachments and files.
+	discard := func() {
+		s.messages = s.messages[:start]
+		s.attachments, s.files = attachments, files
+		s.checkpoint()
+	}
+
+	// Checkpoint right away so the question survives a crash during the response.
+	s.checkpoint()
+
+	// requestMessages returns the messages to send. These differ from the history if it's
+	// trimmed to fit the context budget, or if there's a knowledge base, which only
+	// augments the message, not the results of the tools called for it.
+	requestMessages := func() ([]api.ChatMessage, error) {
+		messages := s.contextMessages()
+		if s.kb != nil && role == api.RoleUser && len(s.messages) == start+1 {
+			return augmentWithKB(ctx, s.client, s.kb, messages)
+		}
+		return messages, nil
+	}
+
+	respond := s.respond
+	if s.schema != nil {
+		respond = s.respondStructured
+	}
+
+	for {
+		messages, err := requestMessages()
+		if err != nil {
+			discard()
+			return err
+		}
+		reply, err := respond(ctx, messages)
+		// If the history outgrew the context window, it is trimmed from now on, and sent again.
+		if budget, ok := s.overflowBudget(err, messages); ok {
+			s.contextBudget = budget
+			fmt.Println(text.FgYellow.Sprintf("The conversation exceeds the context window of the model, "+
+				"so its context budget is set to %d tokens.", budget))
+			if messages, err = requestMessages(); err == nil {
+				reply, err = respond(ctx, messages)
+			}
+		}
+		// An aborted response is kept in the history as far as it went, unless it's empty.
+		if errors.Is(err, errResponseAborted) && reply.Content != "" {
+			s.messages = append(s.messages, reply)
+			s.logTurn(sentAt, s.messages[start:]...)
+			s.checkpoint()
+			return err
+		}
+		if err != nil {
+			discard()
+			return err
+		}
+
+		// Add the assistant's complete response to the chat history.
+		s.messages = append(s.messages, reply)
+		if len(reply.ToolCalls) == 0 {
+			break
+		}
+
+		// The results of the tools called by the model are sent back to it.
+		results, err := s.runToolCalls(ctx, reply.ToolCalls)
+		if err != nil {
+			discard()
+			return err
+		}
+		s.messages = append(s.messages, results...)
+		s.checkpoint()
+	}
+
+	s.logTurn(sentAt, s.messages[start:]...)
+	s.checkpoint()
+	return nil
+}
+
+// respond streams the model's response to the given messages to standard output and returns it,
+// with the answer and the tool calls of the model.
+func (s *chatSession) respond(ctx context.Context, messages []api.ChatMessage) (api.ChatMessage, error) {
+	// The timeouts abort the response if it takes too long, and the watcher shows when
+	// the server stalls, and lets the user abort the response.
+	ctx, timeouts := newResponseTimeouts(ctx)
+	defer timeouts.stop()
+	watcher := newStallWatcher(ctx, s.stallAfter)
+	defer watcher.close()
+
+	// Begin the streaming API call.
+	timer := bench.NewStreamTimer(time.Now())
+	eventStream, err := watcher.open(func(ctx context.Context) (*streams.Stream[api.ChatCompletionEvent], error) {
+		return s.client.ChatCompletionStream(ctx, rootModel, messages, s.options)
+	})
+	if err != nil {
+		return api.ChatMessage{}, timeouts.wrap(err)
+	}
+	eventStream = timeouts.watch(eventStream)
+
+	// Consume the response stream token-by-token.
+	fmt.Print(text.FgGreen.Sprint("Assistant: "))
+	renderer := &responseRenderer{showReasoning: s.showReasoning, quiet: s.hideReasoning, highlight: isTerminal(os.Stdout)}
+	var result api.StreamResult
+	var toolCalls []api.ToolCall
+	for {
+		event, ok, err := watcher.next(eventStream)
+		if errors.Is(err, errResponseAborted) {
+			// The partial answer is returned, for the user to keep it.
+			answer, thoughts := renderer.finish()
+			s.lastReasoning = thoughts
+			return api.ChatMessage{Role: api.RoleAssistant, Content: answer}, err
+		}
+		// The context was canceled, or the response timed out. The partial response still ends its line.
+		if err != nil {
+			renderer.finish()
+			return api.ChatMessage{}, timeouts.wrap(err)
+		}
+
+		// Stream ended.
+		if !ok {
+			break
+		}
+
+		if len(event.Choices) > 0 {
+			renderer.write(event.Choices[0].Delta)
+			toolCalls = api.MergeToolCalls(toolCalls, event.Choices[0].Delta.ToolCalls)
+		}
+		result.Add(event)
+		timer.Add(event)
+	}
+	answer, thoughts := renderer.finish()
+	s.lastReasoning = thoughts
+	if notice := finishNotice(result); notice != "" {
+		fmt.Println(text.FgYellow.Sprint(notice))
+	}
+	if s.showStats {
+		fmt.Println(text.Faint.Sprint(statsFooter(timer.Stop(time.Now()))))
+	}
+	s.reportCost(messages, answer+thoughts, result.Usage)
+	s.warnRateLimit()
+
+	return api.ChatMessage{Role: api.RoleAssistant, Content: answer, ToolCalls: toolCalls}, nil
+}
+
+// respondStructured obtains the model's response to the given messages, and
+// validates it against the session's schema. If the validation fails, the model
+// is asked to correct its response, up to the configured number of retries.
+//
+// Responses are not streamed, so that only valid JSON is ever printed.
+func (s *chatSession) respondStructured(ctx context.Context, messages []api.ChatMessage) (api.ChatMessage, error) {
+	response, err := requestStructured(ctx, s.client, messages, s.options, s.schema, s.schemaRetries, os.Stdout,
+		func(sent []api.ChatMessage, content string, result api.StreamResult) {
+			s.lastReasoning, _ = reasoning.Split(content)
+			s.reportCost(sent, content, result.Usage)
+			s.warnRateLimit()
+		})
+	if err != nil {
+		return api.ChatMessage{}, err
+	}
+
+	fmt.Println(text.FgGreen.Sprint("Assistant: ") + response.answer)
+	if s.showStats {
+		fmt.Println(text.Faint.Sprint(statsFooter(response.stats)))
+	}
+	return api.ChatMessage{Role: api.RoleAssistant, Content: response.answer}, nil
+}
+
+// unwrapCodeBlock returns the content of the given text if it is a single
+// fenced Markdown code block, and the trimmed text otherwise.
+func unwrapCodeBlock(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") || len(text) < 6 {
+		return text
+	}
+
+	// Drop the opening fence along with its language tag, and the closing fence.
+	_, body, found := strings.Cut(text[:len(text)-3], "\n")
+	if !found {
+		return text
+	}
+	return strings.TrimSpace(body)
+}
+
+// startWithSystemPrompt starts the conversation with the given system prompt, or with the content of
+// the given file. A conversation that already has messages, like a resumed one, is left as it is.
+func (s *chatSession) startWithSystemPrompt(prompt, path string) error {
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read system prompt file: %w", err)
+		}
+		prompt = strings.TrimSpace(string(content))
+	}
+	if prompt == "" {
+		return nil
+	}
+
+	if len(s.messages) > 0 {
+		if s.messages[0].Role != api.RoleSystem || s.messages[0].Content != prompt {
+			fmt.Println(text.FgYellow.Sprint("The conversation already has messages, so the system prompt was not added."))
+		}
+		return nil
+	}
+
+	s.messages = append(s.messages, api.ChatMessage{Role: api.RoleSystem, Content: prompt})
+	return nil
+}
+
+// systemPrompt returns the system prompt of the current branch, which is its first message if that is
+// a system message, or an empty string if there is none.
+func (s *chatSession) systemPrompt() string {
+	if len(s.messages) == 0 || s.messages[0].Role != api.RoleSystem {
+		return ""
+	}
+	return s.messages[0].Content
+}
+
+// setSystemPrompt replaces the system prompt of the current branch, or inserts it before all other
+// messages if there is none. The pins are shifted along with the messages they refer to.
+func (s *chatSession) setSystemPrompt(prompt string) {
+	if len(s.messages) > 0 && s.messages[0].Role == api.RoleSystem {
+		// The history may be shared with a forked branch, so it is never modified in place.
+		s.messages = slices.Clone(s.messages)
+		s.messages[0].Content = prompt
+		return
+	}
+
+	s.messages = slices.Insert(slices.Clone(s.messages), 0, api.ChatMessage{Role: api.RoleSystem, Content: prompt})
+	if pinned := s.pins[s.branch]; len(pinned) > 0 {
+		shifted := make([]int, len(pinned))
+		for i, index := range pinned {
+			shifted[i] = index + 1
+		}
+		s.pins[s.branch] = shifted
+	}
+}
+
+// snapshot returns the persistable state of the session.
+func (s *chatSession) snapshot() *session.Session {
+	branches := make(map[string][]api.ChatMessage, len(s.branches)+1)
+	for name, messages := range s.branches {
+		branches[name] = messages
+	}
+	branches[s.branch] = s.messages
+
+	pins := make(map[string][]int, len(s.pins))
+	for name, indices := range s.pins {
+		if len(indices) > 0 {
+			pins[name] = indices
+		}
+	}
+
+	return &session.Session{Model: rootModel, Branch: s.branch, Branches: branches, Pins: pins, UpdatedAt: time.Now()}
+}
+
+// restore replaces the conversation state of the session with the given one.
+func (s *chatSession) restore(saved *session.Session) {
+	s.branch = saved.Branch
+	s.messages = saved.Messages()
+	s.branches = make(map[string][]api.ChatMessage, len(saved.Branches))
+	for name, messages := range saved.Branches {
+		if name != saved.Branch {
+			s.branches[name] = messages
+		}
+	}
+	s.pins = make(map[string][]int, len(saved.Pins))
+	for name, indices := range saved.Pins {
+		s.pins[name] = indices
+	}
+}
+
+// checkpoint saves the session to the autosave file, if autosave is enabled, and to the file
+// of the named session, if any. Failing to save is reported but does not interrupt the chat.
+func (s *chatSession) checkpoint() {
+	if s.autosavePath != "" {
+		if err := s.snapshot().Save(s.autosavePath); err != nil {
+			logger.Warn("failed to autosave the session", "error", err)
+		}
+	}
+
+	if s.sessionPath != "" {
+		if err := s.snapshot().Save(s.sessionPath); err != nil {
+			logger.Warn("failed to save the session", "error", err)
+		}
+	}
+}
+
+// save saves the session under the given name, to be resumed later with `--resume <name>`.
+func (s *chatSession) save(name string) error {
+	path, err := sessionPath(name)
+	if err != nil {
+		return err
+	}
+	return s.snapshot().Save(path)
+}
+
+// saveAsLast saves the session under the name resumed by a plain `--resume`.
+// Empty sessions are not saved, so as not to overwrite a previous one.
+func (s *chatSession) saveAsLast() {
+	if len(s.messages) == 0 && len(s.branches) == 0 {
+		return
+	}
+
+	if err := s.save(lastSessionName); err != nil {
+		logger.Warn("failed to save the session", "error", err)
+	}
+}
+
+// discardAutosave removes the autosave file. It must be called only when the
+// session ends normally, so that the file survives crashes for recovery.
+func (s *chatSession) discardAutosave() {
+	if s.autosavePath == "" {
+		return
+	}
+
+	if err := os.Remove(s.autosavePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger.Warn("failed to remove the autosave file", "error", err)
+	}
+}
+
+// remember adds the input to the history, if it's enabled. Failing to do so is reported but does not interrupt the chat.
+func (s *chatSession) remember(input string) {
+	if s.history == nil {
+		return
+	}
+	if err := s.history.Add(strings.TrimRight(input, "\r\n")); err != nil {
+		logger.Warn("failed to save the input history", "error", err)
+	}
+}
+
+// logTurn appends the given messages of a completed turn to the transcript and to the log file, if any.
+// The first message is timestamped with the given time, and the rest with the current time.
+//
+// Failing to log is reported but does not interrupt the chat.
+func (s *chatSession) logTurn(sentAt time.Time, messages ...api.ChatMessage) {
+	if s.transcript == nil && s.logFile == nil {
+		return
+	}
+
+	parameters := s.parameters()
+	entries := make([]transcript.Entry, len(messages))
+	for i, message := range messages {
+		entries[i] = transcript.Entry{Time: time.Now(), Session: s.id, Model: rootModel, Parameters: parameters,
+			Message: message}
+	}
+	entries[0].Time = sentAt
+
+	for _, writer := range []*transcript.Writer{s.transcript, s.logFile} {
+		if writer == nil {
+			continue
+		}
+		if err := writer.Write(entries...); err != nil {
+			logger.Warn("failed to log the transcript", "error", err)
+		}
+	}
+}
+
+// reportCost prints the cost of the given response to the given messages, along with
+// the cumulative cost of the session. The token counts are taken from the usage
+// reported by the server, if any, and estimated otherwise.
+//
+// Nothing is printed if there's no pricing table or the model is not in it.
+func (s *chatSession) reportCost(messages []api.ChatMessage, response string, usage *api.Usage) {
+	inputTokens, outputTokens, approx := estimateMessageTokens(messages), estimateTokens(response), "~"
+	if usage != nil {
+		inputTokens, outputTokens, approx = usage.PromptTokens, usage.CompletionTokens, ""
+	}
+
+	cost, ok := s.prices.Cost(rootModel, inputTokens, outputTokens)
+	if !ok {
+		return
+	}
+
+	s.sessionCost += cost
+	fmt.Println(text.Faint.Sprintf("Cost: %[1]s$%.6[2]f (%[1]s%[3]d input, %[1]s%[4]d output tokens), session: ~$%.6[5]f",
+		approx, cost, inputTokens, outputTokens, s.sessionCost))
+}
+
+// rateLimitWarningFraction is the fraction of a rate limit below which the remaining quota is warned about.
+const rateLimitWarningFraction = 0.1
+
+// warnRateLimit prints a warning if the rate limits reported by the API are running low.
+func (s *chatSession) warnRateLimit() {
+	info, ok := s.client.RateLimitInfo()
+	if !ok {
+		return
+	}
+
+	for _, limit := range []struct {
+		kind  string
+		state *api.RateLimit
+	}{{"requests", info.Requests}, {"tokens", info.Tokens}} {
+		if limit.state == nil || limit.state.RemainingFraction() >= rateLimitWarningFraction {
+			continue
+		}
+
+		warning := fmt.Sprintf("Rate limit is low: %d of %d %s left", limit.state.Remaining, limit.state.Limit, limit.kind)
+		if limit.state.Reset > 0 {
+			warning += fmt.Sprintf(", resets in %s", limit.state.Reset)
+		}
+		fmt.Println(text.FgYellow.Sprint(warning + "."))
+	}
+}
+
+// parameters returns the request-affecting parameters of the chat session, for recording alongside
+// the messages: all the options sent with the requests, named like in the request body, and the
+// knowledge base, if any.
+func (s *chatSession) parameters() map[string]any {
+	parameters := map[string]any{}
+	// Numbers are kept as they are, like large seeds.
+	encoded, err := json.Marshal(s.options)
+	if err == nil {
+		decoder := json.NewDecoder(bytes.NewReader(encoded))
+		decoder.UseNumber()
+		err = decoder.Decode(&parameters)
+	}
+	if err != nil {
+		logger.Warn("failed to record the request parameters", "error", err)
+	}
+	maps.Copy(parameters, s.options.Extra)
+
+	if chatKB != "" {
+		parameters["kb"] = chatKB
+		parameters["kb_top_k"] = chatKBTopK
+	}
+	return parameters
+}
+
+// fork snapshots the current history into a new branch with the given name and switches to it.
+// The original branch is kept as it is, and can be switched back to later.
+func (s *chatSession) fork(name string) error {
+	if _, ok := s.branches[name]; name == s.branch || ok {
+		return fmt.Errorf("branch %q already exists", name)
+	}
+
+	if s.branches == nil {
+		s.branches = map[string][]api.ChatMessage{}
+	}
+
+	// The new branch gets its own copy so that the two histories can diverge.
+	s.branches[s.branch] = s.messages
+	s.messages = slices.Clone(s.messages)
+	// The pins are copied along with the messages they refer to.
+	if pinned := s.pins[s.branch]; len(pinned) > 0 {
+		s.pins[name] = slices.Clone(pinned)
+	}
+	s.branch = name
+	return nil
+}
+
+// switchBranch makes the branch with the given name the current one.
+func (s *chatSession) switchBranch(name string) error {
+	if name == s.branch {
+		return nil
+	}
+
+	messages, ok := s.branches[name]
+	if !ok {
+		return fmt.Errorf("branch %q does not exist", name)
+	}
+
+	s.branches[s.branch] = s.messages
+	delete(s.branches, name)
+	s.messages = messages
+	s.branch = name
+	return nil
+}
+
+// undo removes the last exchange, which is the last user message along with the responses to it, from the
+// current branch, and returns the number of messages removed. The pins of the removed messages are dropped.
+func (s *chatSession) undo() (int, error) {
+	start := -1
+	for i, message := range slices.Backward(s.messages) {
+		if message.Role == api.RoleUser {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return 0, errors.New("there is nothing to undo")
+	}
+
+	removed := len(s.messages) - start
+	s.messages = slices.Clip(s.messages[:start])
+	if pinned := s.pins[s.branch]; len(pinned) > 0 {
+		s.pins[s.branch] = slices.DeleteFunc(slices.Clone(pinned), func(index int) bool { return index >= start })
+	}
+	s.lastReasoning = ""
+	return removed, nil
+}
+
+// pin marks the message at the given index of the current branch as never trimmed from the context.
+func (s *chatSession) pin(index int) error {
+	if index < 0 || index >= len(s.messages) {
+		return fmt.Errorf("message %d does not exist", index+1)
+	}
+
+	if s.pins == nil {
+		s.pins = map[string][]int{}
+	}
+	if !slices.Contains(s.pins[s.branch], index) {
+		s.pins[s.branch] = append(s.pins[s.branch], index)
+		slices.Sort(s.pins[s.branch])
+	}
+	return nil
+}
+
+// unpin lets the message at the given index of the current branch be trimmed from the context again.
+func (s *chatSession) unpin(index int) error {
+	pinned := s.pins[s.branch]
+	position := slices.Index(pinned, index)
+	if position < 0 {
+		return fmt.Errorf("message %d is not pinned", index+1)
+	}
+
+	s.pins[s.branch] = slices.Delete(slices.Clone(pinned), position, position+1)
+	return nil
+}
+
+// isPinned reports whether the message at the given index of the current branch is pinned.
+func (s *chatSession) isPinned(index int) bool {
+	return slices.Contains(s.pins[s.branch], index)
+}
+
+// contextMessages returns the messages of the history to send to the model. If they don't fit the
+// context budget, the oldest turns are dropped, except for the system prompt and the pinned messages,
+// and the user is told whenever more messages are dropped than before.
+func (s *chatSession) contextMessages() []api.ChatMessage {
+	if s.contextBudget == 0 {
+		return s.messages
+	}
+
+	truncated := session.ChatMessages(s.messages).TruncateToTokens(rootModel, s.contextBudget, s.pins[s.branch])
+	dropped := len(s.messages) - len(truncated)
+	if dropped > 0 {
+		logger.Debug("trimmed the history to fit the context budget", "dropped", dropped, "budget", s.contextBudget)
+	}
+	if dropped > s.leftOut {
+		fmt.Println(text.FgYellow.Sprintf("The oldest %d messages are left out of the context, to fit the budget "+
+			"of %d tokens. Pin the ones to keep with /pin.", dropped, s.contextBudget))
+	}
+	s.leftOut = dropped
+	return truncated
+}
+
+// contextLengthPattern finds the context window in the errors of servers that reject
+// requests that are too long, like "This model's maximum context length is 4096 tokens".
+var contextLengthPattern = regexp.MustCompile(`(?i)maximum context length is (\d+) tokens`)
+
+// overflowBudget tells whether the error is the rejection of the given messages for exceeding the context
+// window of the model, and if so, returns a context budget that fits it, leaving room for the response.
+// The size of the window is remembered if the server tells it.
+// If the server doesn't tell the size of the window, the budget is a fraction of the rejected messages.
+func (s *chatSession) overflowBudget(err error, messages []api.ChatMessage) (int, bool) {
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+
+	sent := session.ChatMessages(messages).Tokens(rootModel)
+	var budget int
+	if match := contextLengthPattern.FindStringSubmatch(apiErr.Message + apiErr.Body); match != nil {
+		window, _ := strconv.Atoi(match[1])
+		s.contextWindow = window
+		reserve := window / 4
+		if s.options.MaxTokens > 0 {
+			reserve = s.options.MaxTokens
+		}
+		budget = window - reserve
+	} else if apiErr.Code == "context_length_exceeded" {
+		budget = sent * 3 / 4
+	} else {
+		return 0, false
+	}
+
+	// Trimming to the budget must make the messages shorter, or sending them again is pointless.
+	if budget <= 0 || budget >= sent {
+		return 0, false
+	}
+	return budget, true
+}
+
+// branchNames returns the names of all branches, including the current one, in sorted order.
+func (s *chatSession) branchNames() []string {
+	names := []string{s.branch}
+	for name := range s.branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// attachImage queues the image at the given path to be sent with the next message.
+func (s *chatSession) attachImage(path string) error {
+	url, err := imageDataURL(path)
+	if err != nil {
+		return err
+	}
+
+	s.attachments = append(s.attachments, api.NewImagePart(url))
+	return nil
+}
+
+// fileAttachment is a file to be included in a message.
+type fileAttachment struct {
+	path  string
+	block string
+}
+
+// attachFile queues the text file at the given path to be included in the next message, in a fenced block.
+// A file that is already queued is included only once.
+func (s *chatSession) attachFile(path string) error {
+	if slices.ContainsFunc(s.files, func(file fileAttachment) bool { return file.path == path }) {
+		return nil
+	}
+
+	block, err := fileBlock(path)
+	if err != nil {
+		return err
+	}
+
+	s.files = append(s.files, fileAttachment{path: path, block: block})
+	return nil
+}
+
+// augmentWithKB retrieves the knowledge base chunks most relevant to the last
+// message and returns a copy of the messages where the last message carries them as context.
+//
+// The augmented message is only sent to the API and never stored in the chat
+// history, so the retrieved context doesn't pile up over the session.
+func augmentWithKB(ctx context.Context, client *api.Client, kb *rag.Index, messages []api.ChatMessage,
+) ([]api.ChatMessage, error) {
+	last := messages[len(messages)-1]
+
+	// The question must be embedded with the same model as the index.
+	vectors, err := client.Embeddings(ctx, kb.EmbeddingModel, []string{last.Content})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed the question: %w", err)
+	}
+
+	matches, err := kb.Search(vectors[0], chatKBTopK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the knowledge base: %w", err)
+	}
+	if len(matches) == 0 {
+		return messages, nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString("Use the following context to answer the question, if it is relevant.\n\n")
+	for _, match := range matches {
+		fmt.Fprintf(&builder, "Source: %s\n%s\n\n", match.Source, match.Text)
+	}
+	builder.WriteString("Question: " + last.Content)
+
+	// Copy to avoid mutating the history's backing array.
+	augmented := make([]api.ChatMessage, len(messages))
+	copy(augmented, messages)
+	augmented[len(augmented)-1].Content = builder.String()
+
+	return augmented, nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/transcript"
+)
+
+// TestChatSession_LogTurn verifies that the transcript records the request parameters set by the flags.
+func TestChatSession_LogTurn(t *testing.T) {
+	parseFlags(t, chatCmd, "--seed", "7", "--temperature", "0.3", "--param", "min_p=0.05")
+	setupRequestOptions(chatCmd.Flags())
+	t.Cleanup(func() { requestOptions = api.ChatOptions{} })
+
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	writer, err := transcript.Open(path)
+	require.NoError(t, err)
+
+	s := &chatSession{id: "session", transcript: writer, options: requestOptions}
+	s.logTurn(time.Now(), api.ChatMessage{Role: api.RoleUser, Content: "Hi"},
+		api.ChatMessage{Role: api.RoleAssistant, Content: "Hello"})
+	require.NoError(t, writer.Close())
+
+	entries, err := transcript.Read(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, 7.0, entry.Parameters["seed"])
+		assert.Equal(t, 0.3, entry.Parameters["temperature"])
+		assert.Equal(t, 0.05, entry.Parameters["min_p"])
+		assert.NotContains(t, entry.Parameters, "top_p", "unset parameters must be left out")
+	}
+}
+
+// TestChatSession_Fork verifies that a branch cannot be forked with the name of an existing one,
+// even if the branch has no messages yet.
+func TestChatSession_Fork(t *testing.T) {
+	s := &chatSession{branch: "main"}
+	require.NoError(t, s.fork("a"))
+	require.NoError(t, s.switchBranch("main"))
+
+	assert.ErrorContains(t, s.fork("a"), `branch "a" already exists`)
+	assert.Equal(t, "main", s.branch)
+}
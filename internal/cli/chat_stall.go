@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/streams"
+)
+
+// errResponseAborted is returned when the user aborts a response.
+var errResponseAborted = errors.New("the response was aborted")
+
+// spinnerFrames are the frames of the spinner shown while the server stalls.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is the time between two frames of the spinner.
+const spinnerInterval = 100 * time.Millisecond
+
+// ANSI escape sequences used to draw the spinner.
+const (
+	saveCursor       = "\0337"
+	restoreCursor    = "\0338"
+	clearToEndOfLine = "\033[K"
+)
+
+// stallWatcher obtains a streamed response, and shows a spinner whenever no token has
+// arrived for a while. Until the response is complete, Ctrl+C aborts only the response,
+// instead of ending the chat.
+type stallWatcher struct {
+	// after is how long to wait for a token before showing the spinner. Zero disables the spinner.
+	after time.Duration
+
+	// parent is the context of the chat, and ctx the context of the response, canceled by aborting it.
+	parent, ctx context.Context
+	cancel      context.CancelFunc
+
+	// lastToken is the time the last event, or the request, was received or sent.
+	lastToken time.Time
+	// frame is the current frame of the spinner.
+	frame int
+	// spinning is true while the spinner is shown.
+	spinning bool
+	// removeHandler removes the interrupt handler that aborts the response.
+	removeHandler func()
+}
+
+// newStallWatcher returns a stall watcher for a response within the given context, which
+// shows the spinner after the given duration without tokens.
+func newStallWatcher(ctx context.Context, after time.Duration) *stallWatcher {
+	responseCtx, cancel := context.WithCancel(ctx)
+	return &stallWatcher{after: after, parent: ctx, ctx: responseCtx, cancel: cancel, lastToken: time.Now(),
+		removeHandler: onInterrupt(cancel)}
+}
+
+// open sends the request for the streamed response with the given function, waiting for
+// the response to start. If the user aborts the response, it returns errResponseAborted.
+func (w *stallWatcher) open(request func(ctx context.Context) (*streams.Stream[api.ChatCompletionEvent], error),
+) (*streams.Stream[api.ChatCompletionEvent], error) {
+	if w.after == 0 {
+		return request(w.ctx)
+	}
+
+	type result struct {
+		stream *streams.Stream[api.ChatCompletionEvent]
+		err    error
+	}
+	// Buffered, so that the request never blocks once it completes.
+	resultChan := make(chan result, 1)
+	go func() {
+		stream, err := request(w.ctx)
+		resultChan <- result{stream: stream, err: err}
+	}()
+
+	for {
+		timer := time.NewTimer(w.timeout())
+		select {
+		case res := <-resultChan:
+			timer.Stop()
+			w.received()
+			return res.stream, w.wrap(res.err)
+		case <-timer.C:
+			w.spin()
+		}
+	}
+}
+
+// next returns the next event of the stream, like its NextContext method. If the
+// user aborts the response while waiting, it returns errResponseAborted.
+func (w *stallWatcher) next(stream *streams.Stream[api.ChatCompletionEvent]) (api.ChatCompletionEvent, bool, error) {
+	if w.after == 0 {
+		return stream.NextContext(w.ctx)
+	}
+
+	for {
+		event, ok, err := stream.NextTimeout(w.ctx, w.timeout())
+		if errors.Is(err, streams.ErrTimeout) {
+			w.spin()
+			continue
+		}
+
+		w.received()
+		return event, ok, w.wrap(err)
+	}
+}
+
+// close releases the resources of the watcher, once the response is complete.
+func (w *stallWatcher) close() {
+	w.stopSpinning()
+	w.removeHandler()
+	w.cancel()
+}
+
+// timeout returns how long to wait before the spinner must be drawn next.
+func (w *stallWatcher) timeout() time.Duration {
+	if w.spinning {
+		return spinnerInterval
+	}
+	return max(w.after-time.Since(w.lastToken), 0)
+}
+
+// received records that the server responded, and erases the spinner.
+func (w *stallWatcher) received() {
+	w.stopSpinning()
+	w.lastToken = time.Now()
+}
+
+// wrap replaces the error caused by aborting the response with errResponseAborted.
+func (w *stallWatcher) wrap(err error) error {
+	if err != nil && w.ctx.Err() != nil && w.parent.Err() == nil {
+		return errResponseAborted
+	}
+	return err
+}
+
+// spin draws the next frame of the spinner, with the time since the last token.
+func (w *stallWatcher) spin() {
+	w.spinning = true
+
+	waited := time.Since(w.lastToken).Truncate(time.Second)
+	status := fmt.Sprintf("%s waiting for server… (%s), press Ctrl+C to abort this response",
+		spinnerFrames[w.frame%len(spinnerFrames)], waited)
+	w.frame++
+
+	// The spinner is drawn after the partial response, and erased by restoring the cursor position.
+	fmt.Print(clearToEndOfLine + saveCursor + text.Faint.Sprint(status) + restoreCursor)
+}
+
+// stopSpinning erases the spinner, if it is shown.
+func (w *stallWatcher) stopSpinning() {
+	if !w.spinning {
+		return
+	}
+	w.spinning = false
+	fmt.Print(clearToEndOfLine)
+}
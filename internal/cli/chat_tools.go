@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+// maxToolOutput is the maximum size of the output of a tool that is sent to the model.
+const maxToolOutput = 16 << 10
+
+// chatTool is a tool that the model may call in the chat, which runs locally.
+type chatTool struct {
+	definition api.Tool
+	// run runs the tool with the arguments of a call, as a JSON object, and returns the result for the model.
+	run func(ctx context.Context, s *chatSession, arguments string) (string, error)
+}
+
+// availableTools holds the tools that can be enabled with --tools, by name.
+var availableTools = map[string]chatTool{
+	"shell": {
+		definition: api.NewFunctionTool("shell",
+			"Run a shell command on the user's machine, and get its output and exit code. "+
+				"The user confirms every command before it runs.",
+			json.RawMessage(`{"type": "object", "properties": {"command": {"type": "string", `+
+				`"description": "The command, run with sh -c."}}, "required": ["command"]}`)),
+		run: runShellTool,
+	},
+}
+
+// availableToolNames returns the names of the tools that can be enabled, in sorted order.
+func availableToolNames() []string {
+	names := make([]string, 0, len(availableTools))
+	for name := range availableTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runToolCalls runs the tool calls of the model, and returns the messages with their results, in order.
+// A call that fails gets the error as its result, for the model to react to it.
+func (s *chatSession) runToolCalls(ctx context.Context, calls []api.ToolCall) ([]api.ChatMessage, error) {
+	results := make([]api.ChatMessage, 0, len(calls))
+	for _, call := range calls {
+		result, err := s.runToolCall(ctx, call)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result = "Error: " + err.Error()
+		}
+		results = append(results, api.ChatMessage{Role: api.RoleTool, Content: result, ToolCallID: call.ID})
+	}
+	return results, nil
+}
+
+// runToolCall runs the tool call of the model, if the tool is enabled.
+func (s *chatSession) runToolCall(ctx context.Context, call api.ToolCall) (string, error) {
+	tool, ok := s.tools[call.Function.Name]
+	if !ok {
+		return "", fmt.Errorf("tool %q is not available", call.Function.Name)
+	}
+	return tool.run(ctx, s, call.Function.Arguments)
+}
+
+// runShellTool runs the command of the call with sh, once the user confirms it, and returns its exit code and
+// output. The output is shown as the command runs. Ctrl+C stops the command, rather than the chat.
+func runShellTool(ctx context.Context, s *chatSession, arguments string) (string, error) {
+	var args struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil || strings.TrimSpace(args.Command) == "" {
+		return "", fmt.Errorf("invalid arguments %s, must be an object with the command", arguments)
+	}
+
+	fmt.Println(text.FgYellow.Sprint("The model wants to run a command:"))
+	fmt.Println("  " + args.Command)
+	// Piped input is not the user's answer, so commands are only run if confirmed on a terminal.
+	if !isTerminal(os.Stdin) {
+		return "The command was declined, as there is no terminal to confirm it on.", nil
+	}
+	confirmed, err := confirm(ctx, s.reader, "Run it? [y/N]: ")
+	if err != nil {
+		return "", err
+	}
+	if !confirmed {
+		return "The user declined to run the command.", nil
+	}
+
+	commandCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer onInterrupt(cancel)()
+
+	var output bytes.Buffer
+	writer := io.MultiWriter(os.Stdout, &output)
+	cmd := exec.CommandContext(commandCtx, "sh", "-c", args.Command)
+	cmd.Stdout, cmd.Stderr = writer, writer
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return "", ctx.Err()
+	case commandCtx.Err() != nil:
+		fmt.Println(text.Faint.Sprint("Command stopped."))
+		return "The user stopped the command. Output:\n" + truncateToolOutput(output.String()), nil
+	case errors.As(err, &exitErr):
+		return fmt.Sprintf("Exit code: %d. Output:\n%s", exitErr.ExitCode(), truncateToolOutput(output.String())), nil
+	case err != nil:
+		return "", fmt.Errorf("failed to run command: %w", err)
+	default:
+		return "Exit code: 0. Output:\n" + truncateToolOutput(output.String()), nil
+	}
+}
+
+// truncateToolOutput truncates the output of a tool to the maximum size sent to the model.
+func truncateToolOutput(output string) string {
+	if len(output) <= maxToolOutput {
+		return output
+	}
+	return output[:maxToolOutput] + fmt.Sprintf("\n[Truncated, %d more bytes]", len(output)-maxToolOutput)
+}
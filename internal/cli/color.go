@@ -0,0 +1,18 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// rootNoColor disables the colors of all output, like the chat prompts, the bench tables and the highlighted code.
+var rootNoColor bool
+
+// setupColors disables the colors of all output if the flag says so, or the environment does, with NO_COLOR
+// set to any value, as per https://no-color.org, or with a dumb terminal that can't render them.
+func setupColors() {
+	if rootNoColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		text.DisableColors()
+	}
+}
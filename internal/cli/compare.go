@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+var compareSystem string
+
+// compareCmd represents the `compare` command, an interactive chat with several models at once.
+var compareCmd = &cobra.Command{
+	Use:   "compare <model> <model>...",
+	Short: "Chat with several models at once, to compare their answers.",
+	Long: `Starts an interactive chat where every message is sent to all the given models at the same time,
+for a qualitative comparison. The answers are streamed in sections labeled with their model, one after
+the other, while the models further down are already responding. Every model keeps its own history of
+the conversation, with its own answers. The reasoning of reasoning models is left out.`,
+	Example: "  llmb compare llama3.1 qwen2.5 -s \"Answer in one paragraph.\"",
+	Args:    cobra.MinimumNArgs(2),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateRootFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, client, reader := cmd.Context(), newClient(), bufio.NewReader(os.Stdin)
+
+		histories := make([][]api.ChatMessage, len(args))
+		if compareSystem != "" {
+			for i := range histories {
+				histories[i] = []api.ChatMessage{{Role: api.RoleSystem, Content: compareSystem}}
+			}
+		}
+
+		for {
+			fmt.Print(text.FgBlue.Sprint("You: "))
+			input, err := readStringContext(ctx, reader)
+			// The end of the input, like of a piped one, ends the chat after its last line, if any.
+			ended := errors.Is(err, io.EOF)
+			if err == nil && isMultilineStart(input) {
+				input, err = readMultiline(ctx, reader, input)
+			}
+			if err != nil && !ended {
+				// Ignore context cancellation errors.
+				if errors.Is(err, context.Canceled) {
+					return nil
+				}
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+
+			if message := strings.TrimSpace(input); message != "" {
+				if err := compareResponses(ctx, client, args, histories, message); err != nil {
+					if errors.Is(err, context.Canceled) {
+						return nil
+					}
+					return err
+				}
+			}
+
+			if ended {
+				fmt.Println()
+				return nil
+			}
+		}
+	},
+}
+
+// init registers the compare command with the root command and defines its local flags.
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringVarP(&compareSystem, "system", "s",
+		"", "System prompt to start the conversation of every model with.")
+
+	addParamFlags(compareCmd.Flags())
+}
+
+// compareResponses sends the message to all the models at once, each with its own history, and shows their
+// responses one after the other. The responses are added to the histories, and the message is removed from
+// the histories of the models that failed to respond. Failures are reported, unless the context is canceled.
+func compareResponses(ctx context.Context, client *api.Client, models []string, histories [][]api.ChatMessage,
+	message string,
+) error {
+	responses := make([]*pendingResponse, len(models))
+	for i, model := range models {
+		histories[i] = append(histories[i], api.ChatMessage{Role: api.RoleUser, Content: message})
+		responses[i] = startResponse(ctx, client, model, histories[i])
+	}
+
+	for i, model := range models {
+		if i > 0 {
+			fmt.Println() // A blank line between the sections.
+		}
+		fmt.Print(text.FgGreen.Sprint(model + ": "))
+
+		answer, err := responses[i].render(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Println(text.FgRed.Sprint("Error: " + err.Error()))
+			histories[i] = histories[i][:len(histories[i])-1]
+			continue
+		}
+		histories[i] = append(histories[i], api.ChatMessage{Role: api.RoleAssistant, Content: answer})
+	}
+	return nil
+}
+
+// pendingResponse is a streamed response whose events are collected in the background,
+// so that it can be rendered after the ones before it, without holding up the model.
+type pendingResponse struct {
+	mu     sync.Mutex
+	events []api.ChatCompletionEvent
+	done   bool
+	err    error
+
+	// updated is signaled whenever events arrive or the response ends.
+	updated chan struct{}
+}
+
+// startResponse requests the response of the model to the messages, and collects its events in the background.
+func startResponse(ctx context.Context, client *api.Client, model string, messages []api.ChatMessage,
+) *pendingResponse {
+	r := &pendingResponse{updated: make(chan struct{}, 1)}
+	go func() {
+		err := r.collect(ctx, client, model, messages)
+		r.mu.Lock()
+		r.done, r.err = true, err
+		r.mu.Unlock()
+		r.signal()
+	}()
+	return r
+}
+
+// collect requests the response and collects its events until it ends.
+func (r *pendingResponse) collect(ctx context.Context, client *api.Client, model string, messages []api.ChatMessage,
+) error {
+	eventStream, err := client.ChatCompletionStream(ctx, model, messages, requestOptions)
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, ok, err := eventStream.NextContext(ctx)
+		if err != nil || !ok {
+			return err
+		}
+		r.mu.Lock()
+		r.events = append(r.events, event)
+		r.mu.Unlock()
+		r.signal()
+	}
+}
+
+// signal tells the renderer that the response was updated, unless it was told already.
+func (r *pendingResponse) signal() {
+	select {
+	case r.updated <- struct{}{}:
+	default:
+	}
+}
+
+// render renders the events collected so far, and then the rest as they arrive, and returns the answer.
+func (r *pendingResponse) render(ctx context.Context) (string, error) {
+	renderer := &responseRenderer{quiet: true, highlight: isTerminal(os.Stdout)}
+	var result api.StreamResult
+	for rendered := 0; ; {
+		r.mu.Lock()
+		events, done, err := r.events[rendered:], r.done, r.err
+		r.mu.Unlock()
+
+		for _, event := range events {
+			if len(event.Choices) > 0 {
+				renderer.write(event.Choices[0].Delta)
+			}
+			result.Add(event)
+		}
+		rendered += len(events)
+
+		if done {
+			answer, _ := renderer.finish()
+			if err != nil {
+				return "", err
+			}
+			if notice := finishNotice(result); notice != "" {
+				fmt.Println(text.FgYellow.Sprint(notice))
+			}
+			return answer, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-r.updated:
+		}
+	}
+}
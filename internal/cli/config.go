@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/config"
+	"github.com/shivanshkc/llmb/pkg/httpx"
+)
+
+// The names of the retry backoff strategies.
+const (
+	retryBackoffConstant    = "constant"
+	retryBackoffExponential = "exponential"
+)
+
+// retryBackoffs maps the names of the retry backoff strategies to them.
+var retryBackoffs = map[string]httpx.Backoff{
+	retryBackoffConstant:    httpx.ConstantBackoff,
+	retryBackoffExponential: httpx.ExponentialBackoff,
+}
+
+var (
+	// rootConfigFile and rootProfile select the configuration file and the profile in it.
+	rootConfigFile string
+	rootProfile    string
+
+	// profile holds the settings of the selected profile, after it is applied by applyConfig.
+	profile config.Profile
+	// rootAPIKey is the API key sent with every request, if any.
+	rootAPIKey string
+	// rootHeaders are the extra headers sent with every request, as "Name: Value".
+	rootHeaders []string
+	// rootOrganization and rootProject are sent in the OpenAI-Organization and OpenAI-Project headers, if set.
+	rootOrganization string
+	rootProject      string
+	// rootUser is sent in the user field of every chat request, if set.
+	rootUser string
+)
+
+// applyConfig loads the configuration file and applies the selected profile.
+//
+// Every setting is resolved with the precedence: flag > environment variable > profile.
+// So, the profile only provides a value for a flag if neither the flag nor its
+// environment variable is set.
+func applyConfig(cmd *cobra.Command) error {
+	// The environment variable selects the profile if the flag doesn't.
+	flags := cmd.Flags()
+	if err := resolveFlag(flags, "profile", "LLMB_PROFILE", ""); err != nil {
+		return err
+	}
+
+	path := rootConfigFile
+	if path == "" {
+		var err error
+		if path, err = configPath(); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.Load(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && rootConfigFile == "" && rootProfile == "":
+		// Not having a config file is fine, unless it is asked for.
+		cfg = &config.Config{}
+	case err != nil:
+		return err
+	}
+
+	if profile, err = cfg.Profile(rootProfile); err != nil {
+		return err
+	}
+	profileAPIKey, err := profile.ResolveAPIKey()
+	if err != nil {
+		return fmt.Errorf("invalid API key in profile: %w", err)
+	}
+
+	if err := resolveFlag(flags, "api-key", "LLMB_API_KEY", profileAPIKey); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "base-url", "LLMB_BASE_URL", profile.BaseURL); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "organization", "LLMB_ORGANIZATION", profile.Organization); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "project", "LLMB_PROJECT", profile.Project); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "user", "LLMB_USER", profile.User); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "provider", "LLMB_PROVIDER", profile.Provider); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "insecure", "LLMB_INSECURE", strconv.FormatBool(profile.Insecure)); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "cacert", "LLMB_CACERT", profile.CACert); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "cert", "LLMB_CERT", profile.Cert); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "key", "LLMB_KEY", profile.Key); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "proxy", "LLMB_PROXY", profile.Proxy); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "retries", "LLMB_RETRIES", profileRetries()); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "retry-delay", "LLMB_RETRY_DELAY", durationValue(profile.Retry.Delay)); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "retry-max-elapsed", "LLMB_RETRY_MAX_ELAPSED", durationValue(profile.Retry.MaxElapsed)); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "retry-backoff", "LLMB_RETRY_BACKOFF", profile.Retry.Backoff); err != nil {
+		return err
+	}
+	if err := resolveFlag(flags, "request-timeout", "LLMB_REQUEST_TIMEOUT", durationValue(profile.Timeout)); err != nil {
+		return err
+	}
+	// Only chat has a transcript directory.
+	if flags.Lookup("transcript-dir") != nil {
+		if err := resolveFlag(flags, "transcript-dir", "LLMB_TRANSCRIPT_DIR", profile.TranscriptDir); err != nil {
+			return err
+		}
+	}
+	return resolveFlag(flags, "model", "LLMB_MODEL", profile.Model)
+}
+
+// resolveFlag sets the named flag from the environment variable, or else from the config value,
+// unless the flag was set on the command line. Empty values are ignored.
+func resolveFlag(flags *pflag.FlagSet, name, envVar, configValue string) error {
+	flag := flags.Lookup(name)
+	if flag.Changed {
+		return nil
+	}
+
+	value := os.Getenv(envVar)
+	if value == "" {
+		value = configValue
+	}
+	if value == "" {
+		return nil
+	}
+
+	if err := flag.Value.Set(value); err != nil {
+		return fmt.Errorf("invalid value %q for flag --%s: %w", value, name, err)
+	}
+	return nil
+}
+
+// profileRetries returns the number of retries set by the profile, or an empty string if it sets none.
+func profileRetries() string {
+	if profile.Retry.MaxAttempts == 0 {
+		return ""
+	}
+	return strconv.Itoa(profile.Retry.MaxAttempts - 1)
+}
+
+// durationValue formats a duration as a flag value, with zero as an empty string, meaning unset.
+func durationValue(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// parseHeaders parses the values of `--header` flags, in the "Name: Value" format.
+func parseHeaders(values []string) (map[string]string, error) {
+	headers := make(map[string]string, len(values))
+	for _, value := range values {
+		name, headerValue, ok := strings.Cut(value, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header %q, must be Name: Value", value)
+		}
+		headers[name] = strings.TrimSpace(headerValue)
+	}
+	return headers, nil
+}
+
+// newClient returns an API client configured with the root flags and the selected profile.
+func newClient() *api.Client {
+	return newClientAt(rootBaseURL)
+}
+
+// newClientAt is like newClient, but for an API at a different base URL.
+func newClientAt(baseURL string) *api.Client {
+	options := []api.ClientOption{api.WithLogger(logger)}
+	if rootAPIKey != "" {
+		options = append(options, api.WithAPIKey(rootAPIKey))
+	}
+	if rootOrganization != "" {
+		options = append(options, api.WithOrganization(rootOrganization))
+	}
+	if rootProject != "" {
+		options = append(options, api.WithProject(rootProject))
+	}
+	if len(profile.Headers) > 0 {
+		options = append(options, api.WithHeaders(profile.Headers))
+	}
+	if len(rootHeaders) > 0 {
+		// The flags are validated already, and override the headers of the profile.
+		headers, _ := parseHeaders(rootHeaders)
+		options = append(options, api.WithHeaders(headers))
+	}
+	options = append(options,
+		api.WithRetry(rootRetries+1, rootRetryDelay),
+		api.WithRetryMaxElapsed(rootRetryMaxElapsed),
+		api.WithRetryBackoff(retryBackoffs[rootRetryBackoff]),
+		api.WithTimeout(rootRequestTimeout))
+
+	// A provider plugin replaces the network, the recorder wraps whatever is used,
+	// and the cache wraps the recorder, so that cached responses are not recorded.
+	// The player replaces all of them.
+	transport := networkTransport
+	if rootProvider != "" {
+		transport = providerTransport
+	}
+	if recorder != nil {
+		recorder.Transport = transport
+		transport = recorder
+	}
+	if responseCache != nil {
+		responseCache.Transport = transport
+		transport = responseCache
+	}
+	if player != nil {
+		transport = player
+	}
+	if transport != nil {
+		options = append(options, api.WithTransport(transport))
+	}
+	if rootDebugHTTP {
+		options = append(options, api.WithDebugHTTP(os.Stderr))
+	}
+	if dryRun {
+		options = append(options, api.WithDryRun(os.Stdout))
+	}
+
+	return api.NewClient(baseURL, options...)
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyConfig_TranscriptDir verifies that the transcript directory is resolved
+// in the order: flag, then environment variable, then profile.
+func TestApplyConfig_TranscriptDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "default_profile: local\nprofiles:\n  local:\n    transcript_dir: /profile/dir\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	tests := []struct {
+		name string
+		args []string
+		env  string
+		want string
+	}{
+		{name: "Profile", want: "/profile/dir"},
+		{name: "Environment", env: "/env/dir", want: "/env/dir"},
+		{name: "Flag", args: []string{"--transcript-dir", "/flag/dir"}, env: "/env/dir", want: "/flag/dir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousConfigFile, previousProfile := rootConfigFile, profile
+			t.Cleanup(func() {
+				rootConfigFile, profile, chatTranscriptDir = previousConfigFile, previousProfile, ""
+			})
+
+			t.Setenv("LLMB_TRANSCRIPT_DIR", tt.env)
+			parseFlags(t, chatCmd, append([]string{"--config", path}, tt.args...)...)
+
+			require.NoError(t, applyConfig(chatCmd))
+			assert.Equal(t, tt.want, chatTranscriptDir)
+		})
+	}
+}
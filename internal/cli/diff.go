@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/diff"
+)
+
+// The formats of the `diff` command.
+const (
+	diffFormatUnified    = "unified"
+	diffFormatSideBySide = "side-by-side"
+)
+
+var (
+	diffFile     string
+	diffSystem   string
+	diffModelB   string
+	diffBaseURLB string
+	diffFormat   string
+	diffContext  int
+	diffWidth    int
+)
+
+// diffCmd represents the `diff` command, which compares the responses of two models to the same prompt.
+//
+// The first model is the one given by the root flags, and the second one is given by the local flags.
+var diffCmd = &cobra.Command{
+	Use:   "diff [prompt]",
+	Short: "Compare the responses of two models to the same prompt.",
+	Long: `Sends the same prompt, with the same parameters, to two models or endpoints, and prints a
+diff of their responses. The first model is given by --model and --base-url, and the second one
+by --model-b and --base-url-b, which default to the first. This is useful to validate a quantized
+or fine-tuned model against the original.
+
+The prompt is the given text, the content of the file given with --file, or stdin if neither is given.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateDiffFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prompt, err := diffPrompt(args)
+		if err != nil {
+			return err
+		}
+
+		var messages []api.ChatMessage
+		if diffSystem != "" {
+			messages = append(messages, api.ChatMessage{Role: api.RoleSystem, Content: diffSystem})
+		}
+		messages = append(messages, api.ChatMessage{Role: api.RoleUser, Content: prompt})
+
+		modelB, baseURLB := rootModel, rootBaseURL
+		if diffModelB != "" {
+			modelB = diffModelB
+		}
+		if diffBaseURLB != "" {
+			baseURLB = diffBaseURLB
+		}
+
+		// Both models are queried at the same time.
+		var answerA, answerB string
+		var errA, errB error
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			answerA, _, _, errA = completeMessages(cmd.Context(), newClient(), rootModel, messages, requestOptions)
+		}()
+		go func() {
+			defer wg.Done()
+			answerB, _, _, errB = completeMessages(cmd.Context(), newClientAt(baseURLB), modelB, messages, requestOptions)
+		}()
+		wg.Wait()
+
+		if errA != nil {
+			return fmt.Errorf("failed to get the response of %s: %w", rootModel, errA)
+		}
+		if errB != nil {
+			return fmt.Errorf("failed to get the response of %s: %w", modelB, errB)
+		}
+
+		if answerA == answerB {
+			fmt.Println("The responses are identical.")
+			return nil
+		}
+
+		nameA, nameB := diffNames(rootModel, rootBaseURL, modelB, baseURLB)
+		lines := diff.Lines(diff.Split(answerA), diff.Split(answerB))
+
+		if diffFormat == diffFormatSideBySide {
+			printSideBySide(lines, nameA, nameB, diffWidth)
+		} else {
+			printUnified(diff.Unified(lines, nameA, nameB, diffContext))
+		}
+		return nil
+	},
+}
+
+// init registers the diff command with the root command and defines its local flags.
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&diffFile, "file", "f",
+		"", "File containing the prompt.")
+
+	diffCmd.Flags().StringVarP(&diffSystem, "system", "s",
+		"", "System prompt sent to both models.")
+
+	diffCmd.Flags().StringVar(&diffModelB, "model-b",
+		"", "Name of the second model. Defaults to the first model.")
+
+	diffCmd.Flags().StringVar(&diffBaseURLB, "base-url-b",
+		"", "Base URL of the API of the second model. Defaults to the base URL of the first model.")
+
+	diffCmd.Flags().StringVar(&diffFormat, "format",
+		diffFormatUnified, "Format of the diff: unified or side-by-side.")
+
+	diffCmd.Flags().IntVarP(&diffContext, "context", "c",
+		3, "Number of unchanged lines shown around every change in the unified format.")
+
+	diffCmd.Flags().IntVar(&diffWidth, "width",
+		120, "Total width of the side-by-side format, in columns.")
+
+	addParamFlags(diffCmd.Flags())
+}
+
+// diffPrompt returns the prompt from the arguments, the --file flag, or stdin, in that order.
+func diffPrompt(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+	if diffFile != "" {
+		content, err := os.ReadFile(diffFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt file: %w", err)
+		}
+		return string(content), nil
+	}
+
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return string(content), nil
+}
+
+// diffNames returns the labels of both sides of the diff. Models are labeled by name,
+// or by base URL if the names are the same.
+func diffNames(modelA, baseURLA, modelB, baseURLB string) (string, string) {
+	switch {
+	case modelA == modelB:
+		return baseURLA, baseURLB
+	case baseURLA == baseURLB:
+		return modelA, modelB
+	default:
+		return modelA + " (" + baseURLA + ")", modelB + " (" + baseURLB + ")"
+	}
+}
+
+// printUnified prints the unified diff with colored deletions, insertions, and hunk headers.
+func printUnified(unified string) {
+	for _, line := range diff.Split(unified) {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			fmt.Println(text.Bold.Sprint(line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Println(text.FgCyan.Sprint(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Println(text.FgRed.Sprint(line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Println(text.FgGreen.Sprint(line))
+		default:
+			fmt.Println(line)
+		}
+	}
+}
+
+// printSideBySide prints the diff in two columns of the given total width, wrapping long lines.
+// The gutter between the columns marks changed lines with "|", deleted lines with "<",
+// and inserted lines with ">".
+func printSideBySide(lines []diff.Line, nameA, nameB string, width int) {
+	const gutter = 3
+	columnWidth := (width - gutter) / 2
+
+	printRow := func(left, right, mark string, leftColors, rightColors text.Colors) {
+		leftLines := strings.Split(text.WrapSoft(left, columnWidth), "\n")
+		rightLines := strings.Split(text.WrapSoft(right, columnWidth), "\n")
+		for i := range max(len(leftLines), len(rightLines)) {
+			var l, r string
+			if i < len(leftLines) {
+				l = leftLines[i]
+			}
+			if i < len(rightLines) {
+				r = rightLines[i]
+			}
+			// The mark is only shown on the first line of a wrapped row.
+			if i > 0 && mark != "|" {
+				mark = " "
+			}
+			fmt.Println(leftColors.Sprint(text.Pad(l, columnWidth, ' ')) + " " + mark + " " + rightColors.Sprint(r))
+		}
+	}
+
+	printRow(nameA, nameB, " ", text.Colors{text.Bold}, text.Colors{text.Bold})
+	fmt.Println(strings.Repeat("─", width))
+	for _, row := range diff.SideBySide(lines) {
+		switch {
+		case row.Kind == diff.Equal:
+			printRow(row.Left, row.Right, " ", nil, nil)
+		case !row.HasRight:
+			printRow(row.Left, "", "<", text.Colors{text.FgRed}, nil)
+		case !row.HasLeft:
+			printRow("", row.Right, ">", nil, text.Colors{text.FgGreen})
+		default:
+			printRow(row.Left, row.Right, "|", text.Colors{text.FgRed}, text.Colors{text.FgGreen})
+		}
+	}
+}
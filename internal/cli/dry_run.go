@@ -0,0 +1,14 @@
+package cli
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// dryRun makes the commands print their requests instead of sending them.
+var dryRun bool
+
+// addDryRunFlag defines the dry run flag on a command that sends chat requests.
+func addDryRunFlag(flags *pflag.FlagSet) {
+	flags.BoolVar(&dryRun, "dry-run",
+		false, "Print every request, with its URL, headers and body, instead of sending it. Credentials are masked.")
+}
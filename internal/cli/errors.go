@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+// Exit codes of the application, so that scripts can tell the kinds of failures apart.
+const (
+	exitOK           = 0
+	exitFailure      = 1
+	exitUsage        = 2
+	exitConnection   = 3
+	exitAPI          = 4
+	exitSLOViolation = 5
+	exitCanceled     = 130
+)
+
+var (
+	// rootJSONErrors makes errors print as JSON objects instead of text.
+	rootJSONErrors bool
+
+	// commandStarted is set once the flags and arguments of the command are parsed.
+	// Errors before that are usage errors.
+	commandStarted bool
+)
+
+// usageError marks an error caused by invalid flags or arguments.
+type usageError struct{ err error }
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// asUsageError marks the given error, if any, as a usage error.
+// It is meant to wrap the validation functions in the PreRunE hooks.
+func asUsageError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &usageError{err: err}
+}
+
+// sloViolationError marks a run that completed, but didn't meet the objectives set for it.
+type sloViolationError struct{ err error }
+
+func (e *sloViolationError) Error() string { return e.err.Error() }
+func (e *sloViolationError) Unwrap() error { return e.err }
+
+// errorKind classifies an error into its name and exit code.
+func errorKind(err error) (string, int) {
+	var usageErr *usageError
+	var sloErr *sloViolationError
+	var apiErr *api.APIError
+	var urlErr *url.Error
+	var opErr *net.OpError
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled", exitCanceled
+	case errors.As(err, &usageErr) || !commandStarted:
+		return "usage_error", exitUsage
+	case errors.As(err, &sloErr):
+		return "slo_violation", exitSLOViolation
+	case errors.As(err, &apiErr):
+		return "api_error", exitAPI
+	case errors.As(err, &urlErr) || errors.As(err, &opErr):
+		return "connection_error", exitConnection
+	default:
+		return "error", exitFailure
+	}
+}
+
+// reportError prints the error of the given command to stderr and returns the exit code for it.
+//
+// Errors are printed as text, followed by the usage of the command for usage errors,
+// or as a JSON object if --json-errors is set.
+func reportError(cmd *cobra.Command, err error) int {
+	kind, code := errorKind(err)
+
+	if rootJSONErrors {
+		output := map[string]any{"error": kind, "exit_code": code, "message": err.Error()}
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) {
+			output["status"] = apiErr.StatusCode
+			if apiErr.Code != "" {
+				output["code"] = apiErr.Code
+			}
+			if apiErr.Type != "" {
+				output["type"] = apiErr.Type
+			}
+			if apiErr.RequestID != "" {
+				output["request_id"] = apiErr.RequestID
+			}
+		}
+		// Encoding a map of basic types cannot fail.
+		_ = json.NewEncoder(os.Stderr).Encode(output)
+		return code
+	}
+
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	if code == exitUsage {
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprint(os.Stderr, cmd.UsageString())
+	}
+	return code
+}
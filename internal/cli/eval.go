@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/eval"
+)
+
+var (
+	evalRepeat       int
+	evalThreshold    float64
+	evalJudgeModel   string
+	evalJudgeBaseURL string
+)
+
+// evalCmd represents the `eval` command, which runs a suite of prompts with expected
+// answers against the model, as a lightweight regression test of the model's behavior.
+var evalCmd = &cobra.Command{
+	Use:   "eval <suite-file>",
+	Short: "Evaluate the model against a suite of prompts with expected answers.",
+	Long: `Runs every case of a YAML suite file against the model, grades the responses, and reports
+the pass rate of every case. Responses are graded with exact answers, substrings, regular
+expressions, or a rubric for a judge model. The command fails if the overall pass rate is
+below the threshold.`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateEvalFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		suite, err := eval.LoadSuite(args[0])
+		if err != nil {
+			return err
+		}
+
+		// The flag overrides the threshold of the suite, which defaults to all runs passing.
+		threshold := 1.0
+		if cmd.Flags().Changed("threshold") {
+			threshold = evalThreshold
+		} else if suite.Threshold != nil {
+			threshold = *suite.Threshold
+		}
+
+		client := newClient()
+		complete := func(ctx context.Context, c eval.Case) (string, error) {
+			var messages []api.ChatMessage
+			if c.System != "" {
+				messages = append(messages, api.ChatMessage{Role: api.RoleSystem, Content: c.System})
+			}
+			messages = append(messages, api.ChatMessage{Role: api.RoleUser, Content: c.Prompt})
+
+			answer, _, _, err := completeMessages(ctx, client, rootModel, messages, requestOptions)
+			return answer, err
+		}
+
+		// The judge is the model under test itself, unless another one is given.
+		judgeModel, judgeClient := rootModel, client
+		if evalJudgeModel != "" {
+			judgeModel = evalJudgeModel
+		}
+		if evalJudgeBaseURL != "" {
+			judgeClient = newClientAt(evalJudgeBaseURL)
+		}
+		judge := func(ctx context.Context, prompt string) (string, error) {
+			messages := []api.ChatMessage{{Role: api.RoleUser, Content: prompt}}
+			answer, _, _, err := completeMessages(ctx, judgeClient, judgeModel, messages, api.ChatOptions{})
+			return answer, err
+		}
+
+		emit := func(result eval.CaseResult) {
+			status := text.FgGreen.Sprint("PASS")
+			if result.Passed < result.Runs {
+				status = text.FgYellow.Sprint("FAIL")
+			}
+			fmt.Printf("[%s] %s: %d/%d passed\n", status, result.Name, result.Passed, result.Runs)
+			for _, failure := range result.Failures {
+				fmt.Println(text.Faint.Sprint("    " + failure))
+			}
+		}
+
+		report, err := eval.Run(cmd.Context(), suite, evalRepeat, complete, judge, emit)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		passRate := report.PassRate()
+		fmt.Printf("\nPass rate: %.1f%% (threshold: %.1f%%)\n", passRate*100, threshold*100)
+		if passRate < threshold {
+			return &sloViolationError{err: fmt.Errorf("pass rate %.1f%% is below the threshold of %.1f%%",
+				passRate*100, threshold*100)}
+		}
+		return nil
+	},
+}
+
+// init registers the eval command with the root command and defines its local flags.
+func init() {
+	rootCmd.AddCommand(evalCmd)
+
+	evalCmd.Flags().IntVarP(&evalRepeat, "repeat", "r",
+		1, "Number of times every case is run.")
+
+	evalCmd.Flags().Float64Var(&evalThreshold, "threshold",
+		1, "Minimum overall pass rate, between 0 and 1. Overrides the threshold of the suite file.")
+
+	evalCmd.Flags().StringVar(&evalJudgeModel, "judge-model",
+		"", "Model that grades the responses against rubrics. Defaults to the model under test.")
+
+	evalCmd.Flags().StringVar(&evalJudgeBaseURL, "judge-base-url",
+		"", "Base URL of the API of the judge model. Defaults to the base URL of the model under test.")
+
+	addParamFlags(evalCmd.Flags())
+	addCacheFlags(evalCmd.Flags())
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// Formats of the generated documentation.
+const (
+	docsFormatMan      = "man"
+	docsFormatMarkdown = "markdown"
+)
+
+var (
+	genDocsFormat    string
+	genDocsOutputDir string
+)
+
+// genDocsCmd represents the hidden `gen-docs` command, which generates the reference
+// documentation of all commands, for packagers to ship along with the binary.
+var genDocsCmd = &cobra.Command{
+	Use:     "gen-docs",
+	Short:   "Generate man pages or markdown reference docs for all commands.",
+	Long:    "Generates one man page or markdown file per command into the output directory.",
+	Hidden:  true,
+	Args:    cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateGenDocsFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := os.MkdirAll(genDocsOutputDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		// The generation date is left out, so that the docs are reproducible.
+		rootCmd.DisableAutoGenTag = true
+
+		var err error
+		switch genDocsFormat {
+		case docsFormatMan:
+			header := &doc.GenManHeader{Title: "LLMB", Section: "1", Source: "llmb"}
+			err = doc.GenManTree(rootCmd, header, genDocsOutputDir)
+		case docsFormatMarkdown:
+			err = doc.GenMarkdownTree(rootCmd, genDocsOutputDir)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to generate %s docs: %w", genDocsFormat, err)
+		}
+
+		fmt.Printf("Generated %s docs in %s.\n", genDocsFormat, genDocsOutputDir)
+		return nil
+	},
+}
+
+// init registers the gen-docs command with the root command and defines its local flags.
+func init() {
+	rootCmd.AddCommand(genDocsCmd)
+
+	genDocsCmd.Flags().StringVar(&genDocsFormat, "format",
+		docsFormatMan, "Format of the docs, either man or markdown.")
+
+	genDocsCmd.Flags().StringVarP(&genDocsOutputDir, "output-dir", "o",
+		"docs", "Directory to write the docs to.")
+}
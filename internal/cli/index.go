@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/rag"
+)
+
+var (
+	indexName           string
+	indexEmbeddingModel string
+	indexChunkSize      int
+	indexChunkOverlap   int
+)
+
+// indexCmd is the parent command for managing local knowledge base indexes.
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage local knowledge base indexes.",
+	Long:  "Manage the local knowledge base indexes that can be used as context in chat using the --kb flag.",
+}
+
+// indexAddCmd chunks and embeds all text files of a directory into a named index.
+//
+// The index is stored on disk under the data directory, and the embedding model
+// is recorded in the index itself so that queries are always embedded consistently.
+var indexAddCmd = &cobra.Command{
+	Use:     "add <dir>",
+	Short:   "Add all text files of a directory to an index.",
+	Long:    "Chunks and embeds all text files of a directory (recursively) and stores them in the named index.",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateIndexAddFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := indexPath(indexName)
+		if err != nil {
+			return err
+		}
+
+		// Extend the existing index if there is one.
+		index, err := rag.Load(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			index = &rag.Index{EmbeddingModel: indexEmbeddingModel}
+		case err != nil:
+			return fmt.Errorf("failed to load index: %w", err)
+		case index.EmbeddingModel != indexEmbeddingModel:
+			return fmt.Errorf("index %q uses embedding model %q, cannot add with %q",
+				indexName, index.EmbeddingModel, indexEmbeddingModel)
+		}
+
+		client := newClient()
+		embed := func(ctx context.Context, texts []string) ([][]float64, error) {
+			return client.Embeddings(ctx, indexEmbeddingModel, texts)
+		}
+
+		var fileCount int
+		err = filepath.WalkDir(args[0], func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Skip hidden files and directories like .git, but not the root itself.
+			if filePath != args[0] && strings.HasPrefix(entry.Name(), ".") {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if entry.IsDir() {
+				return nil
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			if !rag.IsText(content) {
+				return nil // Skip binary files.
+			}
+
+			err = index.Add(cmd.Context(), filePath, string(content), indexChunkSize, indexChunkOverlap, embed)
+			if err != nil {
+				return err
+			}
+
+			fileCount++
+			fmt.Printf("Indexed %s\n", filePath)
+			return nil
+		})
+		if err != nil {
+			// Ignore context cancellation errors.
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return fmt.Errorf("failed to index directory: %w", err)
+		}
+
+		if err := index.Save(path); err != nil {
+			return fmt.Errorf("failed to save index: %w", err)
+		}
+
+		fmt.Printf("Indexed %d files into %q (%d chunks in total).\n", fileCount, indexName, len(index.Chunks))
+		return nil
+	},
+}
+
+// init registers the index commands and defines their local flags.
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	indexCmd.AddCommand(indexAddCmd)
+
+	indexAddCmd.Flags().StringVar(&indexName, "name",
+		"default", "Name of the index to add the files to.")
+
+	indexAddCmd.Flags().StringVar(&indexEmbeddingModel, "embedding-model",
+		"text-embedding-3-small", "Name of the model to use for embeddings.")
+
+	indexAddCmd.Flags().IntVar(&indexChunkSize, "chunk-size",
+		1000, "Size of each chunk in characters.")
+
+	indexAddCmd.Flags().IntVar(&indexChunkOverlap, "chunk-overlap",
+		200, "Number of characters shared by consecutive chunks.")
+}
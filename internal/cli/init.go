@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/config"
+	"github.com/shivanshkc/llmb/pkg/plugin"
+)
+
+// initName is the name of the profile that the `init` command sets up.
+var initName string
+
+// initCmd represents the `init` command, which sets up a profile in the configuration file interactively.
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up a profile in the config file, interactively.",
+	Long: `Asks for the base URL, the API key and the default model of a provider, checks them with a test
+request, and saves them as a profile in the configuration file. The file is created if needed, and the
+rest of it, including its comments, is kept. The API key may be given as env:NAME or file:PATH, to read
+it from there when the profile is used, instead of saving it in the file.
+
+The profile becomes the default one if there is no default yet, or else if you say so.`,
+	Example: "  llmb init\n  llmb init --name openai",
+	Args:    cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateInitFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, reader := cmd.Context(), bufio.NewReader(os.Stdin)
+
+		path := rootConfigFile
+		if path == "" {
+			var err error
+			if path, err = configPath(); err != nil {
+				return err
+			}
+		}
+
+		cfg, err := config.Load(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			cfg = &config.Config{}
+		case err != nil:
+			return err
+		}
+
+		// An existing profile is updated, so its answers are the defaults.
+		existing, exists := cfg.Profiles[initName]
+		if exists {
+			fmt.Printf("Updating the profile %q in %s.\n", initName, path)
+		} else {
+			fmt.Printf("Creating the profile %q in %s.\n", initName, path)
+		}
+
+		newProfile := existing
+		if newProfile.BaseURL, err = readAnswer(ctx, reader, "Base URL", cmp.Or(existing.BaseURL, rootBaseURL)); err != nil {
+			return err
+		}
+
+		// The current API key is kept on an empty answer, but not shown. A key typed in is echoed,
+		// so the references, which also keep it out of the file, are suggested first.
+		fmt.Println("The API key is best given as env:NAME or file:PATH, to read it from there when needed.")
+		fmt.Println("A key typed in here is shown on the screen and saved in the file.")
+		keyQuestion := "API key as env:NAME, file:PATH, or the key itself (empty for none)"
+		if existing.APIKey != "" {
+			keyQuestion = "API key as env:NAME, file:PATH, or the key itself (empty to keep the current one)"
+		}
+		apiKey, err := readAnswer(ctx, reader, keyQuestion, "")
+		if err != nil {
+			return err
+		}
+		newProfile.APIKey = cmp.Or(apiKey, existing.APIKey)
+
+		if newProfile.Model, err = readAnswer(ctx, reader, "Default model", cmp.Or(existing.Model, rootModel)); err != nil {
+			return err
+		}
+
+		fmt.Print("Sending a test request... ")
+		if err := testProfile(ctx, newProfile); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Println(text.FgRed.Sprint("failed: " + err.Error()))
+
+			save, err := confirm(ctx, reader, "Save the profile anyway? [y/N]: ")
+			if err != nil {
+				return err
+			}
+			if !save {
+				return errors.New("the profile was not saved")
+			}
+		} else {
+			fmt.Println(text.FgGreen.Sprint("OK"))
+		}
+
+		makeDefault := cfg.DefaultProfile == "" || cfg.DefaultProfile == initName
+		if !makeDefault {
+			question := fmt.Sprintf("Make it the default profile, instead of %q? [y/N]: ", cfg.DefaultProfile)
+			if makeDefault, err = confirm(ctx, reader, question); err != nil {
+				return err
+			}
+		}
+
+		if err := config.SaveProfile(path, initName, newProfile, makeDefault); err != nil {
+			return err
+		}
+
+		fmt.Printf("Saved the profile %q to %s.\n", initName, path)
+		if !makeDefault {
+			fmt.Printf("Use it with --profile %s.\n", initName)
+		}
+		return nil
+	},
+}
+
+// init registers the init command with the root command and defines its local flags.
+func init() {
+	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().StringVar(&initName, "name",
+		"default", "Name of the profile to create or update.")
+}
+
+// readAnswer asks the user the given question and returns the answer, or the given default if the answer is
+// empty. The default, if any, is shown with the question. The end of the input gives an empty answer.
+func readAnswer(ctx context.Context, reader *bufio.Reader, question, fallback string) (string, error) {
+	if fallback != "" {
+		question += " [" + fallback + "]"
+	}
+	fmt.Print(question + ": ")
+
+	answer, err := readStringContext(ctx, reader)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return fallback, nil
+	}
+	return answer, nil
+}
+
+// testProfile checks that the profile works, by asking its default model for a short response.
+// The client is made of the settings of the profile alone, not of the flags or of the current profile.
+// The request is sent once, without retries, to report a failure right away.
+func testProfile(ctx context.Context, p config.Profile) error {
+	apiKey, err := p.ResolveAPIKey()
+	if err != nil {
+		return fmt.Errorf("invalid API key: %w", err)
+	}
+
+	options := []api.ClientOption{api.WithLogger(logger), api.WithRetry(1, 0), api.WithTimeout(p.Timeout)}
+	if apiKey != "" {
+		options = append(options, api.WithAPIKey(apiKey))
+	}
+	if p.Organization != "" {
+		options = append(options, api.WithOrganization(p.Organization))
+	}
+	if p.Project != "" {
+		options = append(options, api.WithProject(p.Project))
+	}
+	if len(p.Headers) > 0 {
+		options = append(options, api.WithHeaders(p.Headers))
+	}
+
+	var transport http.RoundTripper
+	if p.Provider != "" {
+		path, err := plugin.Find(p.Provider)
+		if err != nil {
+			return err
+		}
+		transport = &plugin.Transport{Path: path}
+	} else if transport, err = newNetworkTransport(p.Insecure, p.CACert, p.Cert, p.Key, p.Proxy); err != nil {
+		return err
+	}
+	if transport != nil {
+		options = append(options, api.WithTransport(transport))
+	}
+
+	messages := []api.ChatMessage{{Role: api.RoleUser, Content: "Say hi."}}
+	_, err = api.NewClient(p.BaseURL, options...).ChatCompletionText(ctx, p.Model, messages, api.ChatOptions{})
+	return err
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/config"
+)
+
+// TestTestProfile verifies that the test request of a profile is sent with its settings alone,
+// not with those of the flags or of the current profile, which are left untouched.
+func TestTestProfile(t *testing.T) {
+	var request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	rootAPIKey, profile.Headers = "sk-current", map[string]string{"X-Current": "current"}
+	t.Cleanup(func() { rootAPIKey, profile.Headers = "", nil })
+
+	err := testProfile(context.Background(), config.Profile{
+		BaseURL: server.URL,
+		Model:   "test-model",
+		APIKey:  "sk-new",
+		Headers: map[string]string{"X-Tenant": "new"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Bearer sk-new", request.Header.Get("Authorization"))
+	assert.Equal(t, "new", request.Header.Get("X-Tenant"))
+	assert.Empty(t, request.Header.Get("X-Current"))
+	assert.Equal(t, "sk-current", rootAPIKey)
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"log/slog"
+	"os"
+)
+
+var (
+	// rootVerbosity is the number of times the verbose flag is given.
+	rootVerbosity int
+	// rootDebugHTTP dumps the HTTP traffic with the API to stderr.
+	rootDebugHTTP bool
+)
+
+// logger reports diagnostics to stderr. Only warnings and errors are shown by default,
+// `-v` adds informational messages like retries, and `-vv` adds debug messages like timing phases.
+var logger = newLogger(0)
+
+// newLogger returns a logger for the given verbosity.
+func newLogger(verbosity int) *slog.Logger {
+	level := slog.LevelWarn
+	switch {
+	case verbosity == 1:
+		level = slog.LevelInfo
+	case verbosity >= 2:
+		level = slog.LevelDebug
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	// Timestamps are only useful when looking at timings, so they're left out of the default output.
+	if verbosity == 0 {
+		options.ReplaceAttr = func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		}
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stderr, options))
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/cassette"
+	"github.com/shivanshkc/llmb/pkg/mock"
+)
+
+var (
+	mockListen      string
+	mockTTFT        time.Duration
+	mockTokenRate   float64
+	mockTokens      int
+	mockJitter      float64
+	mockErrorRate   float64
+	mockErrorStatus int
+	mockSeed        int64
+	mockCassette    string
+)
+
+// mockCmd represents the `mock` command, which runs a synthetic OpenAI compatible API server.
+//
+// It makes the chat and bench commands, as well as any other client, testable offline,
+// with exactly known latencies, response lengths and failure rates.
+var mockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Run a synthetic Open AI compatible API server.",
+	Long: `Runs a server that serves /v1/chat/completions with synthetic responses, streamed or not,
+at a configurable time to first token, token rate, response length, jitter and error rate.
+It can also replay the responses of a cassette recorded with --record-cassette.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateMockFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// A cassette replaces the synthetic responses with recorded ones.
+		if mockCassette != "" {
+			loaded, err := cassette.Load(mockCassette)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Replaying %d interactions from %s.\n", len(loaded.Interactions), mockCassette)
+			return listenAndServe(cmd.Context(), mockListen, cassette.NewPlayer(loaded))
+		}
+
+		server := mock.New(mock.Config{
+			TTFT:        mockTTFT,
+			TokenRate:   mockTokenRate,
+			Tokens:      mockTokens,
+			Jitter:      mockJitter,
+			ErrorRate:   mockErrorRate,
+			ErrorStatus: mockErrorStatus,
+			Seed:        mockSeed,
+		})
+
+		fmt.Printf("Serving %d tokens per response at %g tokens/s, after a TTFT of %s.\n", mockTokens, mockTokenRate, mockTTFT)
+		return listenAndServe(cmd.Context(), mockListen, server)
+	},
+}
+
+// init registers the mock command with the root command and defines its local flags.
+func init() {
+	rootCmd.AddCommand(mockCmd)
+
+	mockCmd.Flags().StringVarP(&mockListen, "listen", "l",
+		"localhost:8080", "Address to listen on.")
+
+	mockCmd.Flags().DurationVar(&mockTTFT, "ttft",
+		200*time.Millisecond, "Time to first token.")
+
+	mockCmd.Flags().Float64Var(&mockTokenRate, "token-rate",
+		50, "Tokens produced per second after the first one. Zero means as fast as possible.")
+
+	mockCmd.Flags().IntVar(&mockTokens, "tokens",
+		100, "Number of tokens in every response.")
+
+	mockCmd.Flags().Float64Var(&mockJitter, "jitter",
+		0, "Random variation of every delay, as a fraction of it. For example, 0.1 for ±10%.")
+
+	mockCmd.Flags().Float64Var(&mockErrorRate, "error-rate",
+		0, "Fraction of requests to fail, between 0 and 1.")
+
+	mockCmd.Flags().IntVar(&mockErrorStatus, "error-status",
+		500, "Status code of the failed requests.")
+
+	mockCmd.Flags().Int64Var(&mockSeed, "seed",
+		0, "Seed for the randomness of jitter and errors, to make runs reproducible.")
+
+	mockCmd.Flags().StringVar(&mockCassette, "cassette",
+		"", "Replay the responses of this cassette file instead of synthetic ones.")
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// modelsCmd represents the `models` command, which lists the models available at the API.
+var modelsCmd = &cobra.Command{
+	Use:     "models",
+	Short:   "List the models available at the API.",
+	Long:    "Lists the IDs of the models available at the API, to be used with --model.",
+	Args:    cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateModelsFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		models, err := newClient().ListModels(cmd.Context())
+		if err != nil {
+			// Ignore context cancellation errors.
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return fmt.Errorf("failed to list models: %w", err)
+		}
+
+		if len(models) == 0 {
+			fmt.Println("The API has no models.")
+			return nil
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.SetStyle(table.StyleColoredDark)
+		t.AppendHeader(table.Row{"ID", "Owned By", "Created"})
+
+		for _, model := range models {
+			// Not every API tells when a model was created.
+			var created string
+			if model.Created > 0 {
+				created = time.Unix(model.Created, 0).Format(time.DateOnly)
+			}
+			t.AppendRow(table.Row{model.ID, model.OwnedBy, created})
+		}
+
+		t.Render()
+		return nil
+	},
+}
+
+// init registers the models command with the root command.
+func init() {
+	rootCmd.AddCommand(modelsCmd)
+}
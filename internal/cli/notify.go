@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+// The ways to notify the user.
+const (
+	notifyBell    = "bell"
+	notifyDesktop = "desktop"
+)
+
+// notifyMode is how the user is notified when a response or a benchmark completes, if at all.
+var notifyMode string
+
+// addNotifyFlag defines the notification flag on a command whose work may take long.
+func addNotifyFlag(flags *pflag.FlagSet, what string) {
+	flags.StringVar(&notifyMode, "notify",
+		"", fmt.Sprintf("Ring the terminal bell when %s completes, or send a desktop notification with --notify=desktop.", what))
+	flags.Lookup("notify").NoOptDefVal = notifyBell
+}
+
+// notify tells the user that the task with the given description, like "The response", completed or failed
+// with the given error, as asked with --notify. Nothing is told of canceled tasks, or of dry runs.
+// A desktop notification that can't be sent rings the bell instead.
+func notify(task string, err error) {
+	if notifyMode == "" || errors.Is(err, context.Canceled) || errors.Is(err, api.ErrDryRun) {
+		return
+	}
+
+	message := task + " completed."
+	if err != nil {
+		message = task + " failed: " + err.Error()
+	}
+
+	if notifyMode == notifyDesktop {
+		err := sendDesktopNotification("llmb", message)
+		if err == nil {
+			return
+		}
+		logger.Warn("failed to send a desktop notification", "error", err)
+	}
+	// The bell goes to stderr, so that it never mixes with the output.
+	fmt.Fprint(os.Stderr, "\a")
+}
+
+// sendDesktopNotification shows a notification with the given title and message on the desktop,
+// with notify-send on Linux and other Unix systems, or with osascript on macOS.
+func sendDesktopNotification(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		quote := func(s string) string { return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"` }
+		cmd = exec.Command("osascript", "-e", "display notification "+quote(message)+" with title "+quote(title))
+	case "windows":
+		return errors.New("desktop notifications are not supported on Windows")
+	default:
+		cmd = exec.Command("notify-send", title, message)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil && len(bytes.TrimSpace(output)) > 0 {
+		return fmt.Errorf("failed to run %s: %w: %s", cmd.Args[0], err, bytes.TrimSpace(output))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w", cmd.Args[0], err)
+	}
+	return nil
+}
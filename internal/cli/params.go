@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+var (
+	// paramTemperature, paramTopP, paramMaxTokens and the others hold the values of the
+	// request parameter flags, which are defined by the commands that send chat requests.
+	paramTemperature      float64
+	paramTopP             float64
+	paramMaxTokens        int
+	paramPresencePenalty  float64
+	paramFrequencyPenalty float64
+	paramStop             []string
+	paramSeed             int
+	// paramExtra holds the extra fields of the request body, as key=value pairs.
+	paramExtra []string
+	// paramIncludeUsage asks for the token usage at the end of every stream.
+	paramIncludeUsage bool
+
+	// requestOptions holds the request parameters set by the flags, to be sent with every chat request.
+	requestOptions api.ChatOptions
+)
+
+// addParamFlags defines the request parameter flags on a command that sends chat requests.
+func addParamFlags(flags *pflag.FlagSet) {
+	flags.Float64Var(&paramTemperature, "temperature",
+		0, "Sampling temperature, between 0 and 2. Lower is more deterministic. Unset, the server's default applies.")
+
+	flags.Float64Var(&paramTopP, "top-p",
+		0, "Nucleus sampling probability mass, between 0 and 1. Unset, the server's default applies.")
+
+	flags.IntVar(&paramMaxTokens, "max-tokens",
+		0, "Maximum number of tokens of every response. Zero means no limit.")
+
+	flags.Float64Var(&paramPresencePenalty, "presence-penalty",
+		0, "Penalty, between -2 and 2, for tokens that already appeared, encouraging new topics. Unset, the server's default applies.")
+
+	flags.Float64Var(&paramFrequencyPenalty, "frequency-penalty",
+		0, "Penalty, between -2 and 2, for tokens by how often they appeared, discouraging repetition. Unset, the server's default applies.")
+
+	flags.StringArrayVar(&paramStop, "stop",
+		nil, "Sequence at which the model stops generating. Can be repeated.")
+
+	flags.IntVar(&paramSeed, "seed",
+		0, "Seed for deterministic sampling, on a best-effort basis, so runs can be reproduced. Unset, sampling is random.")
+
+	flags.StringArrayVar(&paramExtra, "param",
+		nil, "Extra field of the request body as key=value, for parameters that only some servers support, "+
+			"like min_p=0.05. Values that are valid JSON are sent as such, and others as strings. Can be repeated.")
+
+	flags.BoolVar(&paramIncludeUsage, "include-usage",
+		true, "Ask the server to report the token usage of every response. Disable it for servers that reject it.")
+}
+
+// setupRequestOptions collects the request parameters set by the flags, or else by the profile.
+// Parameters that are set by neither are left out, so that the server's defaults apply.
+func setupRequestOptions(flags *pflag.FlagSet) {
+	defaults := profile.Params
+	requestOptions = api.ChatOptions{
+		MaxTokens:        paramMaxTokens,
+		Stop:             paramStop,
+		User:             rootUser,
+		Temperature:      defaults.Temperature,
+		TopP:             defaults.TopP,
+		PresencePenalty:  defaults.PresencePenalty,
+		FrequencyPenalty: defaults.FrequencyPenalty,
+		Seed:             defaults.Seed,
+	}
+	if requestOptions.MaxTokens == 0 {
+		requestOptions.MaxTokens = defaults.MaxTokens
+	}
+	if len(requestOptions.Stop) == 0 {
+		requestOptions.Stop = defaults.Stop
+	}
+	if flags.Changed("temperature") {
+		requestOptions.Temperature = &paramTemperature
+	}
+	if flags.Changed("top-p") {
+		requestOptions.TopP = &paramTopP
+	}
+	if flags.Changed("presence-penalty") {
+		requestOptions.PresencePenalty = &paramPresencePenalty
+	}
+	if flags.Changed("frequency-penalty") {
+		requestOptions.FrequencyPenalty = &paramFrequencyPenalty
+	}
+	if flags.Changed("seed") {
+		requestOptions.Seed = &paramSeed
+	}
+	// The extra fields of the flags are added to those of the profile, overriding them.
+	if len(defaults.Extra) > 0 || len(paramExtra) > 0 {
+		requestOptions.Extra = maps.Clone(defaults.Extra)
+		if requestOptions.Extra == nil {
+			requestOptions.Extra = map[string]any{}
+		}
+		params, _ := parseParams(paramExtra) // Validated already.
+		maps.Copy(requestOptions.Extra, params)
+	}
+	// The flag is only defined by some commands, and its default only applies to them.
+	if flags.Lookup("include-usage") != nil && paramIncludeUsage {
+		requestOptions.StreamOptions = &api.StreamOptions{IncludeUsage: true}
+	}
+}
+
+// parseParams parses the values of `--param` flags, in the key=value format. Values that are valid JSON,
+// like numbers, booleans or objects, are sent as JSON, and the others as strings.
+func parseParams(values []string) (map[string]any, error) {
+	params := make(map[string]any, len(values))
+	for _, value := range values {
+		key, raw, ok := strings.Cut(value, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid parameter %q, must be key=value", value)
+		}
+
+		// JSON values are sent as they are, which keeps the precision of numbers.
+		if json.Valid([]byte(raw)) {
+			params[key] = json.RawMessage(raw)
+		} else {
+			params[key] = raw
+		}
+	}
+	return params, nil
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shivanshkc/llmb/pkg/prompt"
+	"github.com/shivanshkc/llmb/pkg/session"
+)
+
+// dataDir returns the directory where llmb persists its data, like indexes.
+//
+// It follows the XDG Base Directory specification, falling back to
+// `~/.local/share/llmb` when `XDG_DATA_HOME` is not set.
+func dataDir() (string, error) {
+	if xdgDataHome := os.Getenv("XDG_DATA_HOME"); xdgDataHome != "" {
+		return filepath.Join(xdgDataHome, "llmb"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine the home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".local", "share", "llmb"), nil
+}
+
+// indexPath returns the file path of the knowledge base index with the given name.
+func indexPath(name string) (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "indexes", name+".json"), nil
+}
+
+// autosaveDir returns the directory where in-progress chat sessions are checkpointed.
+func autosaveDir() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "autosave"), nil
+}
+
+// sessionDir returns the directory where the chat sessions are saved.
+func sessionDir() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sessions"), nil
+}
+
+// sessionPath returns the file path of the saved chat session with the given name.
+func sessionPath(name string) (string, error) {
+	dir, err := sessionDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+session.Extension), nil
+}
+
+// historyPath returns the file path of the history of the chat inputs.
+func historyPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history"), nil
+}
+
+// promptDir returns the directory where the prompt templates are stored.
+func promptDir() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "prompts"), nil
+}
+
+// promptPath returns the file path of the prompt template with the given name.
+func promptPath(name string) (string, error) {
+	dir, err := promptDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+prompt.Extension), nil
+}
+
+// cacheDir returns the directory of the response cache.
+//
+// It follows the XDG Base Directory specification, falling back to
+// `~/.cache/llmb/responses` when `XDG_CACHE_HOME` is not set.
+func cacheDir() (string, error) {
+	if xdgCacheHome := os.Getenv("XDG_CACHE_HOME"); xdgCacheHome != "" {
+		return filepath.Join(xdgCacheHome, "llmb", "responses"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine the home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".cache", "llmb", "responses"), nil
+}
+
+// configPath returns the default path of the configuration file.
+//
+// It follows the XDG Base Directory specification, falling back to
+// `~/.config/llmb/config.yaml` when `XDG_CONFIG_HOME` is not set.
+func configPath() (string, error) {
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		return filepath.Join(xdgConfigHome, "llmb", "config.yaml"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine the home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".config", "llmb", "config.yaml"), nil
+}
@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/prompt"
+)
+
+var promptVars []string
+
+// promptCmd is the parent command for managing the library of prompt templates.
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Manage reusable prompt templates.",
+	Long: `Manage a library of named prompt templates. Templates use the Go template syntax for
+variables, like "Summarize this in {{.words}} words:\n{{.text}}", whose values are given
+with --var name=value, or --var name=@file to use the content of a file.`,
+}
+
+// promptAddCmd stores a template under a name.
+var promptAddCmd = &cobra.Command{
+	Use:   "add <name> [file]",
+	Short: "Add a prompt template from a file, or from stdin.",
+	Long:  "Stores the template in the file, or read from stdin, under the given name, replacing any existing one.",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var content []byte
+		var err error
+		if len(args) == 2 {
+			content, err = os.ReadFile(args[1])
+		} else {
+			content, err = io.ReadAll(os.Stdin)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+
+		tmpl, err := prompt.Parse(string(content))
+		if err != nil {
+			return err
+		}
+
+		path, err := namedPromptPath(args[0])
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Save(path); err != nil {
+			return err
+		}
+
+		fmt.Printf("Saved template %q with variables: %s\n", args[0], formatVariables(tmpl))
+		return nil
+	},
+}
+
+// promptListCmd lists the stored templates.
+var promptListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the prompt templates.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := promptDir()
+		if err != nil {
+			return err
+		}
+		names, err := prompt.List(dir)
+		if err != nil {
+			return err
+		}
+
+		if len(names) == 0 {
+			fmt.Println("No prompt templates. Add one with `llmb prompt add`.")
+			return nil
+		}
+		for _, name := range names {
+			tmpl, err := loadPrompt(name)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s (%s)\n", name, formatVariables(tmpl))
+		}
+		return nil
+	},
+}
+
+// promptShowCmd prints a stored template.
+var promptShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a prompt template.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmpl, err := loadPrompt(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Print(tmpl.Text)
+		if !strings.HasSuffix(tmpl.Text, "\n") {
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+// promptRemoveCmd deletes a stored template.
+var promptRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a prompt template.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := namedPromptPath(args[0])
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("prompt template %q does not exist", args[0])
+			}
+			return fmt.Errorf("failed to remove template: %w", err)
+		}
+		fmt.Printf("Removed template %q.\n", args[0])
+		return nil
+	},
+}
+
+// promptRenderCmd prints a stored template with its variables substituted.
+var promptRenderCmd = &cobra.Command{
+	Use:   "render <name>",
+	Short: "Print a prompt template with its variables substituted.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rendered, err := renderPrompt(args[0], promptVars)
+		if err != nil {
+			return err
+		}
+		fmt.Print(rendered)
+		return nil
+	},
+}
+
+// promptRunCmd sends a stored template, with its variables substituted, as a one-shot prompt.
+var promptRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "Send a prompt template with its variables substituted, and print the response.",
+	Long: `Renders the template like the render command, and sends it to the model as a single prompt,
+streaming the response to standard output, like the ask command.`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateRootFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rendered, err := renderPrompt(args[0], promptVars)
+		if err != nil {
+			return err
+		}
+		return ask(cmd.Context(), newClient(), rendered)
+	},
+}
+
+// init registers the prompt commands and defines their local flags.
+func init() {
+	rootCmd.AddCommand(promptCmd)
+	promptCmd.AddCommand(promptAddCmd, promptListCmd, promptShowCmd, promptRemoveCmd, promptRenderCmd, promptRunCmd)
+
+	for _, cmd := range []*cobra.Command{promptRenderCmd, promptRunCmd} {
+		cmd.Flags().StringArrayVar(&promptVars, "var",
+			nil, "Value of a template variable as name=value, or name=@file for the content of a file. Can be repeated.")
+	}
+
+	addParamFlags(promptRunCmd.Flags())
+	addCacheFlags(promptRunCmd.Flags())
+}
+
+// namedPromptPath validates the template name and returns the path of its file.
+func namedPromptPath(name string) (string, error) {
+	if err := validateName("prompt template", name); err != nil {
+		return "", err
+	}
+	return promptPath(name)
+}
+
+// loadPrompt loads the stored template with the given name.
+func loadPrompt(name string) (*prompt.Template, error) {
+	path, err := namedPromptPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := prompt.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("prompt template %q does not exist", name)
+	}
+	return tmpl, err
+}
+
+// renderPrompt renders the stored template with the given name, with the values of `--var` flags.
+func renderPrompt(name string, varFlags []string) (string, error) {
+	tmpl, err := loadPrompt(name)
+	if err != nil {
+		return "", err
+	}
+	vars, err := parseVars(varFlags)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Render(vars)
+}
+
+// parseVars parses the values of `--var` flags, in the name=value or name=@file format.
+func parseVars(varFlags []string) (map[string]string, error) {
+	vars := make(map[string]string, len(varFlags))
+	for _, flag := range varFlags {
+		name, value, ok := strings.Cut(flag, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid variable %q, must be name=value", flag)
+		}
+
+		if path, ok := strings.CutPrefix(value, "@"); ok {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the value of variable %q: %w", name, err)
+			}
+			value = string(content)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// formatVariables returns the variables of the template as a comma-separated list.
+func formatVariables(tmpl *prompt.Template) string {
+	variables := tmpl.Variables()
+	if len(variables) == 0 {
+		return "no variables"
+	}
+	return strings.Join(variables, ", ")
+}
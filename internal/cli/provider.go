@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"github.com/shivanshkc/llmb/pkg/plugin"
+)
+
+var (
+	// rootProvider is the name of the provider plugin to use instead of calling the API.
+	rootProvider string
+
+	// providerTransport executes requests with the provider plugin, when one is selected.
+	providerTransport *plugin.Transport
+)
+
+// setupProvider finds the executable of the selected provider plugin, if any.
+func setupProvider() error {
+	if rootProvider == "" {
+		return nil
+	}
+
+	path, err := plugin.Find(rootProvider)
+	if err != nil {
+		return err
+	}
+
+	providerTransport = &plugin.Transport{Path: path}
+	logger.Info("using provider plugin", "provider", rootProvider, "path", path)
+	return nil
+}
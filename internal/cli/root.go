@@ -5,11 +5,17 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/api"
 )
 
 var (
@@ -18,8 +24,32 @@ var (
 	// package (like `chat` and `bench`) to access these shared values directly and safely.
 	rootBaseURL string
 	rootModel   string
+
+	// rootRetries, rootRetryDelay, rootRetryMaxElapsed and rootRetryBackoff make up the retry policy of all API requests.
+	rootRetries         int
+	rootRetryDelay      time.Duration
+	rootRetryMaxElapsed time.Duration
+	rootRetryBackoff    string
+	// rootRequestTimeout limits the time of every attempt of an API request.
+	rootRequestTimeout time.Duration
 )
 
+// version is the version of llmb. It can be set at build time with
+// -ldflags "-X github.com/shivanshkc/llmb/internal/cli.version=v1.2.3".
+var version string
+
+// appVersion returns the version of llmb, falling back to the module version
+// recorded in the build information, like for `go install`.
+func appVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
 // rootCmd represents the base command when called without any subcommands.
 // It serves as the entry point and parent for all other commands.
 var rootCmd = &cobra.Command{
@@ -27,14 +57,39 @@ var rootCmd = &cobra.Command{
 	Short: "A tool to interact with and benchmark Open AI compatible REST APIs.",
 	Long: `A tool to interact with and benchmark Open AI compatible REST APIs.
 This CLI provides subcommands for interactive chat sessions and performance benchmarking.`,
+	// The configuration is applied before any subcommand validates its flags.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		commandStarted = true
+		setupColors()
+		logger = newLogger(rootVerbosity)
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+		if err := setupTransport(); err != nil {
+			return err
+		}
+		if err := setupProvider(); err != nil {
+			return err
+		}
+		setupRequestOptions(cmd.Flags())
+		if err := setupCache(cmd.Flags()); err != nil {
+			return err
+		}
+		return setupCassette()
+	},
+	PersistentPostRunE: func(cmd *cobra.Command, args []string) error { return saveCassette() },
+	// Errors are reported by Execute, according to their kind.
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 // Execute is the primary entry point for the CLI application, called by main.go.
+// It returns the exit code of the application.
 //
 // It sets up a single, root cancellable context and wires it up to respond
 // to OS interruption signals (like Ctrl+C or SIGTERM). This context is then passed down
 // to all cobra commands, enabling graceful shutdown across the entire application.
-func Execute() error {
+func Execute() int {
 	// Create a root context that can be canceled.
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel() // Ensure cancel is called on exit to clean up context resources.
@@ -47,14 +102,64 @@ func Execute() error {
 	// prevents resource leaks in more complex application lifecycles.
 	defer signal.Stop(signals)
 
-	// Launch a goroutine to cancel the context upon receiving a signal.
+	// Launch a goroutine to cancel the context upon receiving a signal,
+	// unless an interrupt handler takes care of it.
 	go func() {
-		<-signals
-		cancel()
+		for sig := range signals {
+			if sig == os.Interrupt && handleInterrupt() {
+				continue
+			}
+			cancel()
+			return
+		}
 	}()
 
 	// Execute the root command with the cancellable context.
-	return rootCmd.ExecuteContext(ctx)
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	// A dry run ends with its first request, which is printed instead of sent.
+	if err != nil && !errors.Is(err, api.ErrDryRun) {
+		return reportError(cmd, err)
+	}
+	// Commands end quietly when interrupted, but the exit code still tells.
+	if ctx.Err() != nil {
+		return exitCanceled
+	}
+	return exitOK
+}
+
+// interruptHandler, if set, handles the next Ctrl+C instead of the cancellation of the root context.
+var (
+	interruptHandler   func()
+	interruptHandlerMu sync.Mutex
+)
+
+// onInterrupt makes the next Ctrl+C call the given handler instead of ending the command,
+// like to abort only a single response. The returned function removes the handler.
+func onInterrupt(handler func()) (remove func()) {
+	interruptHandlerMu.Lock()
+	defer interruptHandlerMu.Unlock()
+	interruptHandler = handler
+
+	return func() {
+		interruptHandlerMu.Lock()
+		defer interruptHandlerMu.Unlock()
+		interruptHandler = nil
+	}
+}
+
+// handleInterrupt calls the interrupt handler, if any, and reports whether there was one.
+// A handler handles a single interrupt, so the next one ends the command again.
+func handleInterrupt() bool {
+	interruptHandlerMu.Lock()
+	handler := interruptHandler
+	interruptHandler = nil
+	interruptHandlerMu.Unlock()
+
+	if handler == nil {
+		return false
+	}
+	handler()
+	return true
 }
 
 // init configures the application's flags.
@@ -64,8 +169,80 @@ func Execute() error {
 // This avoids code duplication and provides a consistent user experience.
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&rootBaseURL, "base-url", "u",
-		"http://localhost:8080", "Base URL of the API.")
+		"http://localhost:8080", "Base URL of the API. [env: LLMB_BASE_URL]")
 
 	rootCmd.PersistentFlags().StringVarP(&rootModel, "model", "m",
-		"gpt-4.1", "Name of the model to use.")
+		"gpt-4.1", "Name of the model to use. [env: LLMB_MODEL]")
+
+	rootCmd.PersistentFlags().StringVar(&rootAPIKey, "api-key",
+		"", "API key sent as a bearer token with every request. [env: LLMB_API_KEY]")
+
+	rootCmd.PersistentFlags().StringVar(&rootOrganization, "organization",
+		"", "Organization ID sent in the OpenAI-Organization header, for accounting the usage to it. [env: LLMB_ORGANIZATION]")
+
+	rootCmd.PersistentFlags().StringVar(&rootProject, "project",
+		"", "Project ID sent in the OpenAI-Project header, for accounting the usage to it. [env: LLMB_PROJECT]")
+
+	rootCmd.PersistentFlags().StringVar(&rootUser, "user",
+		"", "End-user ID sent in the user field of chat requests, for abuse attribution. [env: LLMB_USER]")
+
+	rootCmd.PersistentFlags().StringArrayVarP(&rootHeaders, "header", "H",
+		nil, `Extra header sent with every request, as "Name: Value". Can be repeated.`)
+
+	rootCmd.PersistentFlags().IntVar(&rootRetries, "retries",
+		3, "Number of times a request is retried after a network error or a transient status, like 429 or 503. Use 0 to disable retries. [env: LLMB_RETRIES]")
+
+	rootCmd.PersistentFlags().DurationVar(&rootRetryDelay, "retry-delay",
+		500*time.Millisecond, "Delay between the retries of a request. [env: LLMB_RETRY_DELAY]")
+
+	rootCmd.PersistentFlags().DurationVar(&rootRetryMaxElapsed, "retry-max-elapsed",
+		0, "Maximum total time spent retrying a request. Zero means no limit. [env: LLMB_RETRY_MAX_ELAPSED]")
+
+	rootCmd.PersistentFlags().StringVar(&rootRetryBackoff, "retry-backoff",
+		retryBackoffConstant, "Strategy of the delays between retries, constant or exponential. [env: LLMB_RETRY_BACKOFF]")
+
+	rootCmd.PersistentFlags().DurationVar(&rootRequestTimeout, "request-timeout",
+		0, "Maximum time of every attempt of a request, including the streaming of the response. Zero means no limit. [env: LLMB_REQUEST_TIMEOUT]")
+
+	rootCmd.PersistentFlags().BoolVar(&rootInsecure, "insecure",
+		false, "Skip the verification of the server's TLS certificate. [env: LLMB_INSECURE]")
+
+	rootCmd.PersistentFlags().StringVar(&rootCACert, "cacert",
+		"", "PEM file of CA certificates to trust in addition to the system ones. [env: LLMB_CACERT]")
+
+	rootCmd.PersistentFlags().StringVar(&rootCert, "cert",
+		"", "PEM file of the client certificate, for servers that require mutual TLS. Requires --key. [env: LLMB_CERT]")
+
+	rootCmd.PersistentFlags().StringVar(&rootKey, "key",
+		"", "PEM file of the private key of the client certificate. [env: LLMB_KEY]")
+
+	rootCmd.PersistentFlags().StringVar(&rootProxy, "proxy",
+		"", "URL of an HTTP(S) or SOCKS5 proxy for calling the API, like socks5://localhost:1080. [env: LLMB_PROXY]")
+
+	rootCmd.PersistentFlags().CountVarP(&rootVerbosity, "verbose", "v",
+		"Log diagnostics to stderr. Use -v for retries and requests, -vv for timings and streams.")
+
+	rootCmd.PersistentFlags().BoolVar(&rootDebugHTTP, "debug-http",
+		false, "Dump the headers and bodies of all requests and responses to stderr, with credentials masked.")
+
+	rootCmd.PersistentFlags().StringVar(&rootConfigFile, "config",
+		os.Getenv("LLMB_CONFIG"), "Path of the config file. Defaults to ~/.config/llmb/config.yaml. [env: LLMB_CONFIG]")
+
+	rootCmd.PersistentFlags().StringVar(&rootProfile, "profile",
+		"", "Name of the config file profile to use. [env: LLMB_PROFILE]")
+
+	rootCmd.PersistentFlags().StringVar(&rootProvider, "provider",
+		"", "Name of a provider plugin (an llmb-provider-<name> executable in PATH) to use instead of the API. [env: LLMB_PROVIDER]")
+
+	rootCmd.PersistentFlags().BoolVar(&rootNoColor, "no-color",
+		false, "Disable the colors of all output, for logs and dumb terminals. [env: NO_COLOR]")
+
+	rootCmd.PersistentFlags().BoolVar(&rootJSONErrors, "json-errors",
+		false, "Print errors to stderr as JSON objects, for scripts.")
+
+	rootCmd.PersistentFlags().StringVar(&rootRecordCassette, "record-cassette",
+		"", "Record all API responses, with their timing, to this cassette file.")
+
+	rootCmd.PersistentFlags().StringVar(&rootReplayCassette, "replay-cassette",
+		"", "Replay API responses from this cassette file instead of calling the API.")
 }
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/batch"
+	"github.com/shivanshkc/llmb/pkg/reasoning"
+)
+
+var (
+	runOutputFile   string
+	runConcurrency  int
+	runRPS          float64
+	runAttempts     int
+	runAttemptDelay time.Duration
+	runQuiet        bool
+)
+
+// runCmd represents the `run` command, which processes a file of prompts in batch.
+//
+// Results are appended to the output file as they complete. Running the same command
+// again skips the prompts that already succeeded, so an interrupted run can be resumed.
+var runCmd = &cobra.Command{
+	Use:   "run <input-file>",
+	Short: "Run a batch of prompts from a JSONL or CSV file.",
+	Long: `Runs every prompt of the input file concurrently, and appends the prompt, response and usage
+of each one to the output file. Prompts that already succeeded in the output file are skipped,
+so an interrupted run can be resumed by running the same command again.
+
+JSONL input has one {"id": ..., "prompt": ..., "system": ...} object per line, where only the
+prompt is required. CSV input has a header row with a "prompt" column, and optional "id" and
+"system" columns.`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateRunFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		items, err := batch.ReadItems(args[0])
+		if err != nil {
+			return err
+		}
+
+		// Resume by skipping the items that already succeeded.
+		completed, err := batch.CompletedIDs(runOutputFile)
+		if err != nil {
+			return err
+		}
+		pending := make([]batch.Item, 0, len(items))
+		for _, item := range items {
+			if !completed[item.ID] {
+				pending = append(pending, item)
+			}
+		}
+		if skipped := len(items) - len(pending); skipped > 0 && !runQuiet {
+			fmt.Printf("Skipping %d prompts that already completed.\n", skipped)
+		}
+
+		output, err := os.OpenFile(runOutputFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open output file: %w", err)
+		}
+		defer func() { _ = output.Close() }()
+
+		client := newClient()
+		process := func(ctx context.Context, item batch.Item) (batch.Result, error) {
+			return runPrompt(ctx, client, item)
+		}
+
+		var done, failed int
+		encoder := json.NewEncoder(output)
+		emit := func(result batch.Result) {
+			done++
+			status := text.FgGreen.Sprint("ok")
+			if result.Error != "" {
+				failed++
+				status = text.FgYellow.Sprint("failed: " + result.Error)
+			}
+			if !runQuiet {
+				fmt.Printf("[%d/%d] %s: %s\n", done, len(pending), result.ID, status)
+			}
+
+			if err := encoder.Encode(result); err != nil {
+				logger.Error("failed to write the result", "id", result.ID, "error", err)
+			}
+		}
+
+		options := batch.Options{
+			Concurrency: runConcurrency,
+			RPS:         runRPS,
+			Attempts:    runAttempts,
+			RetryDelay:  runAttemptDelay,
+		}
+		err = batch.Run(cmd.Context(), pending, options, process, emit)
+		if errors.Is(err, context.Canceled) {
+			if !runQuiet {
+				fmt.Printf("Interrupted after %d prompts. Run the same command again to resume.\n", done)
+			}
+			return nil
+		}
+
+		if !runQuiet {
+			fmt.Printf("Completed %d prompts, %d failed. Results are in %s\n", done, failed, runOutputFile)
+		}
+		return err
+	},
+}
+
+// init registers the run command with the root command and defines its local flags.
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringVarP(&runOutputFile, "output", "o",
+		"results.jsonl", "JSONL file to append the results to, and to resume from.")
+
+	runCmd.Flags().IntVarP(&runConcurrency, "concurrency", "c",
+		4, "Number of prompts to run at a time.")
+
+	runCmd.Flags().Float64Var(&runRPS, "rps",
+		0, "Maximum number of requests to start per second. Zero means no limit.")
+
+	runCmd.Flags().IntVar(&runAttempts, "attempts",
+		3, "Maximum number of attempts per prompt.")
+
+	runCmd.Flags().DurationVar(&runAttemptDelay, "attempt-delay",
+		time.Second, "Delay between the attempts of a prompt.")
+
+	runCmd.Flags().BoolVarP(&runQuiet, "quiet", "q",
+		false, "Do not print the progress and the summary. Only the output file is written.")
+
+	addParamFlags(runCmd.Flags())
+	addCacheFlags(runCmd.Flags())
+}
+
+// runPrompt obtains the model's response to the given item.
+func runPrompt(ctx context.Context, client *api.Client, item batch.Item) (batch.Result, error) {
+	var messages []api.ChatMessage
+	if item.System != "" {
+		messages = append(messages, api.ChatMessage{Role: api.RoleSystem, Content: item.System})
+	}
+	messages = append(messages, api.ChatMessage{Role: api.RoleUser, Content: item.Prompt})
+
+	answer, thoughts, usage, err := completeMessages(ctx, client, rootModel, messages, requestOptions)
+	if err != nil {
+		return batch.Result{}, err
+	}
+
+	// The usage is estimated if the server doesn't report it.
+	result := batch.Result{Response: answer, Reasoning: thoughts}
+	if usage != nil {
+		result.Usage = &batch.Usage{PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens}
+	} else {
+		result.Usage = &batch.Usage{
+			PromptTokens:     estimateMessageTokens(messages),
+			CompletionTokens: estimateTokens(thoughts + answer),
+			Estimated:        true,
+		}
+	}
+	return result, nil
+}
+
+// completeMessages obtains the model's complete answer to the given messages, its reasoning, if any,
+// and its token usage, if the server reports it.
+func completeMessages(
+	ctx context.Context, client *api.Client, model string, messages []api.ChatMessage, options api.ChatOptions,
+) (answer, thoughts string, usage *api.Usage, err error) {
+	eventStream, err := client.ChatCompletionStream(ctx, model, messages, options)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	events, err := eventStream.Drain(ctx)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	var content, reasoningContent strings.Builder
+	for _, event := range events {
+		if len(event.Choices) > 0 {
+			content.WriteString(event.Choices[0].Delta.Content)
+			reasoningContent.WriteString(event.Choices[0].Delta.ReasoningContent)
+		}
+		if event.Usage != nil {
+			usage = event.Usage
+		}
+	}
+
+	// Reasoning may come as separate deltas, or inline within <think> tags.
+	thoughts, answer = reasoning.Split(content.String())
+	return strings.TrimSpace(answer), strings.TrimSpace(reasoningContent.String() + thoughts), usage, nil
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/batch"
+)
+
+// TestRunPrompt_Usage verifies that the usage reported by the server is recorded, and estimated otherwise.
+func TestRunPrompt_Usage(t *testing.T) {
+	tests := []struct {
+		name   string
+		events string
+		want   *batch.Usage
+	}{
+		{
+			name: "Reported",
+			events: "data: {\"choices\":[{\"delta\":{\"content\":\"Hello there\"}}]}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":34,\"total_tokens\":46}}\n\n",
+			want: &batch.Usage{PromptTokens: 12, CompletionTokens: 34},
+		},
+		{
+			name:   "Estimated",
+			events: "data: {\"choices\":[{\"delta\":{\"content\":\"Hello there\"}}]}\n\n",
+			want: &batch.Usage{
+				PromptTokens:     estimateMessageTokens([]api.ChatMessage{{Role: api.RoleUser, Content: "Hi"}}),
+				CompletionTokens: estimateTokens("Hello there"),
+				Estimated:        true,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tc.events+"data: [DONE]\n\n")
+			}))
+			defer server.Close()
+
+			result, err := runPrompt(context.Background(), api.NewClient(server.URL), batch.Item{Prompt: "Hi"})
+			require.NoError(t, err)
+			assert.Equal(t, "Hello there", result.Response)
+			assert.Equal(t, tc.want, result.Usage)
+		})
+	}
+}
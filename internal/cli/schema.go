@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/bench"
+	"github.com/shivanshkc/llmb/pkg/jsonschema"
+	"github.com/shivanshkc/llmb/pkg/reasoning"
+)
+
+// invalidSchemaNameChars matches the characters not allowed in a response format's schema name.
+var invalidSchemaNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// loadSchema reads the JSON schema file at the given path and returns it parsed
+// for local validation, and as a response format to send to the API.
+//
+// The schema is named after the file, since the API requires a name.
+func loadSchema(path string) (*jsonschema.Schema, *api.ResponseFormat, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	schema, err := jsonschema.Parse(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse schema file: %w", err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if name = invalidSchemaNameChars.ReplaceAllString(name, "_"); name == "" {
+		name = "response"
+	}
+
+	format := &api.ResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &api.JSONSchemaFormat{Name: name, Schema: json.RawMessage(content)},
+	}
+
+	return schema, format, nil
+}
+
+// structuredResponse is a response of the model that matches a schema.
+type structuredResponse struct {
+	// answer is the JSON of the response, without the reasoning or a wrapping code block.
+	answer string
+	// result and stats are those of the valid response, not of the ones it corrected.
+	result api.StreamResult
+	stats  bench.StreamStats
+}
+
+// requestStructured obtains the model's response to the given messages, and validates it against the
+// schema. If the validation fails, the model is asked to correct its response, up to the given number of
+// retries, with a notice written to the given writer. The observe function, if any, is given every response,
+// valid or not, along with the messages it answered.
+func requestStructured(ctx context.Context, client *api.Client, messages []api.ChatMessage, options api.ChatOptions,
+	schema *jsonschema.Schema, retries int, notices io.Writer,
+	observe func(sent []api.ChatMessage, content string, result api.StreamResult),
+) (structuredResponse, error) {
+	// The corrective exchanges are only sent to the API, never stored in the history.
+	messages = slices.Clip(messages)
+
+	// The timeouts apply to the whole response, including its corrections.
+	ctx, timeouts := newResponseTimeouts(ctx)
+	defer timeouts.stop()
+
+	for attempt := 0; ; attempt++ {
+		timer := bench.NewStreamTimer(time.Now())
+		eventStream, err := client.ChatCompletionStream(ctx, rootModel, messages, options)
+		if err != nil {
+			return structuredResponse{}, timeouts.wrap(err)
+		}
+
+		events, err := timeouts.watch(eventStream).Drain(ctx)
+		if err != nil {
+			return structuredResponse{}, timeouts.wrap(err) // Context canceled, or timed out.
+		}
+
+		var content strings.Builder
+		var result api.StreamResult
+		for _, event := range events {
+			if len(event.Choices) > 0 {
+				content.WriteString(event.Choices[0].Delta.Content)
+			}
+			result.Add(event)
+			timer.Add(event)
+		}
+		stats := timer.Stop(time.Now())
+		if observe != nil {
+			observe(messages, content.String(), result)
+		}
+
+		// Models tend to wrap JSON in a Markdown code block, even when asked not to.
+		_, answer := reasoning.Split(content.String())
+		answer = unwrapCodeBlock(answer)
+
+		err = schema.ValidateJSON([]byte(answer))
+		if err == nil {
+			return structuredResponse{answer: answer, result: result, stats: stats}, nil
+		}
+
+		if attempt == retries {
+			return structuredResponse{}, fmt.Errorf("response did not match the schema after %d attempts: %w",
+				attempt+1, err)
+		}
+
+		fmt.Fprintln(notices, text.Faint.Sprintf("Response did not match the schema (%v), retrying...", err))
+		messages = append(messages,
+			api.ChatMessage{Role: api.RoleAssistant, Content: answer},
+			api.ChatMessage{Role: api.RoleUser, Content: fmt.Sprintf("Your response is not valid: %v. "+
+				"Respond again with only the corrected JSON that matches the schema, and nothing else.", err)},
+		)
+	}
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/proxy"
+)
+
+var (
+	serveListen         string
+	serveRecordFile     string
+	serveUpstreams      []string
+	serveStrategy       string
+	serveHealthInterval time.Duration
+)
+
+// serveCmd represents the `serve` command, which runs a local reverse proxy in front of the API.
+//
+// Existing applications can be pointed at the proxy instead of the API to have every one of
+// their requests measured, without any change to the applications themselves.
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a proxy that records the latency and token usage of API traffic.",
+	Long: `Runs a local reverse proxy that forwards all requests to the API at --base-url,
+streaming the responses through unchanged, and records the latency, time to first token,
+and token usage of every request.
+
+With several --upstream flags, requests are spread over the upstreams according to the
+--strategy, and a request that fails with a connection error or a 5xx status is retried
+on the next upstream.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateServeFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		upstreams, err := parseUpstreams()
+		if err != nil {
+			return err
+		}
+		router, err := proxy.NewRouter(upstreams, proxy.Strategy(serveStrategy))
+		if err != nil {
+			return err
+		}
+
+		// Records are printed, and also appended to the record file if there's one.
+		var recordFile *os.File
+		if serveRecordFile != "" {
+			if recordFile, err = os.OpenFile(serveRecordFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644); err != nil {
+				return fmt.Errorf("failed to open record file: %w", err)
+			}
+			defer func() { _ = recordFile.Close() }()
+		}
+
+		// Requests are handled concurrently, so their records must be serialized.
+		var mu sync.Mutex
+		record := func(r proxy.Record) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			printRecord(r)
+			if recordFile == nil {
+				return
+			}
+			if err := json.NewEncoder(recordFile).Encode(r); err != nil {
+				logger.Warn("failed to write the record", "error", err)
+			}
+		}
+
+		if len(upstreams) == 1 {
+			fmt.Println("Proxying requests to", upstreams[0].URL)
+		} else {
+			fmt.Printf("Proxying requests to %d upstreams (%s).\n", len(upstreams), serveStrategy)
+			if serveHealthInterval > 0 {
+				go router.CheckHealth(cmd.Context(), http.DefaultClient, serveHealthInterval)
+			}
+		}
+		return listenAndServe(cmd.Context(), serveListen, proxy.NewWithRouter(router, record))
+	},
+}
+
+// init registers the serve command with the root command and defines its local flags.
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVarP(&serveListen, "listen", "l",
+		"localhost:8081", "Address to listen on.")
+
+	serveCmd.Flags().StringVar(&serveRecordFile, "record",
+		"", "JSONL file to append the record of every request to.")
+
+	serveCmd.Flags().StringArrayVar(&serveUpstreams, "upstream",
+		nil, "Base URL of an upstream API, optionally with a weight, as in http://host:8080,weight=3. "+
+			"Can be repeated. Defaults to the base URL.")
+
+	serveCmd.Flags().StringVar(&serveStrategy, "strategy",
+		string(proxy.RoundRobin), "Strategy to spread requests over the upstreams: "+strings.Join(proxy.Strategies(), ", ")+".")
+
+	serveCmd.Flags().DurationVar(&serveHealthInterval, "health-interval",
+		10*time.Second, "Interval of the health checks of the upstreams. Zero disables them.")
+}
+
+// parseUpstreams returns the upstreams of the --upstream flags, or the base URL if there are none.
+func parseUpstreams() ([]proxy.Upstream, error) {
+	if len(serveUpstreams) == 0 {
+		upstream, err := url.Parse(rootBaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base URL: %w", err)
+		}
+		return []proxy.Upstream{{URL: upstream}}, nil
+	}
+
+	upstreams := make([]proxy.Upstream, 0, len(serveUpstreams))
+	for _, value := range serveUpstreams {
+		rawURL, options, _ := strings.Cut(value, ",")
+		upstream, err := url.Parse(rawURL)
+		if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+			return nil, fmt.Errorf("invalid upstream URL: %q", rawURL)
+		}
+
+		var weight int
+		if options != "" {
+			weightValue, ok := strings.CutPrefix(options, "weight=")
+			if !ok {
+				return nil, fmt.Errorf("invalid upstream option: %q", options)
+			}
+			if weight, err = strconv.Atoi(weightValue); err != nil || weight <= 0 {
+				return nil, fmt.Errorf("invalid upstream weight: %q", weightValue)
+			}
+		}
+
+		upstreams = append(upstreams, proxy.Upstream{URL: upstream, Weight: weight})
+	}
+	return upstreams, nil
+}
+
+// listenAndServe serves HTTP on the given address until the context is canceled, then shuts down gracefully.
+func listenAndServe(ctx context.Context, address string, handler http.Handler) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	fmt.Printf("Listening on http://%s\n", listener.Addr())
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
+
+// printRecord prints a one-line summary of the given record.
+func printRecord(r proxy.Record) {
+	line := fmt.Sprintf("%s %s %s %d %.0fms", r.Time.Format(time.TimeOnly), r.Method, r.Path, r.Status, r.LatencyMS)
+	if r.Model != "" {
+		line += " model=" + r.Model
+	}
+	if r.Upstream != "" {
+		line += fmt.Sprintf(" upstream=%s attempts=%d", r.Upstream, r.Attempts)
+	}
+	if r.TTFTMS > 0 {
+		line += fmt.Sprintf(" ttft=%.0fms chunks=%d", r.TTFTMS, r.Chunks)
+	}
+	if r.PromptTokens > 0 || r.CompletionTokens > 0 {
+		line += fmt.Sprintf(" tokens=%d/%d", r.PromptTokens, r.CompletionTokens)
+	}
+	if r.Error != "" {
+		line += " error=" + r.Error
+	}
+
+	if r.Status != http.StatusOK {
+		line = text.FgYellow.Sprint(line)
+	}
+	fmt.Println(line)
+}
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/session"
+)
+
+var (
+	sessionsExportOutput string
+	sessionsExportFormat string
+	sessionsForkTurns    int
+)
+
+// sessionsCmd is the parent command for managing the saved chat sessions.
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage the saved chat sessions.",
+	Long:  "Manage the chat sessions saved with /save, --session or at the end of every chat, as last.",
+}
+
+// sessionsExportCmd exports the current branch of a saved session.
+var sessionsExportCmd = &cobra.Command{
+	Use:   "export <name>",
+	Short: "Export a saved session as Markdown or JSON.",
+	Long: `Exports the current branch of a saved session, either as a Markdown document for sharing,
+or as a chat completion request body in the OpenAI format, with the model and the messages, for replay.
+
+Without --format, the format is implied by the extension of the output file: JSON for .json files,
+and Markdown otherwise.`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateSessionsExportFlags(args)) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		saved, err := loadSession(args[0])
+		if err != nil {
+			return err
+		}
+
+		format := sessionsExportFormat
+		if !cmd.Flags().Changed("format") && sessionsExportOutput != "" {
+			format = session.FormatForPath(sessionsExportOutput)
+		}
+
+		if sessionsExportOutput == "" {
+			return saved.Export(os.Stdout, format)
+		}
+		return exportSession(saved, sessionsExportOutput, format)
+	},
+}
+
+// sessionsListCmd lists the saved sessions in a table.
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the saved sessions, most recently updated first.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := sessionDir()
+		if err != nil {
+			return err
+		}
+		names, err := session.List(dir)
+		if err != nil {
+			return err
+		}
+
+		if len(names) == 0 {
+			fmt.Println("No saved sessions. Save one with /save in a chat, or start one with `llmb chat --session`.")
+			return nil
+		}
+
+		sessions := make(map[string]*session.Session, len(names))
+		for _, name := range names {
+			saved, err := loadSession(name)
+			if err != nil {
+				// A broken file must not hide the other sessions.
+				logger.Warn("skipped a session that failed to load", "error", err)
+				continue
+			}
+			sessions[name] = saved
+		}
+		names = slices.DeleteFunc(names, func(name string) bool { return sessions[name] == nil })
+		sort.SliceStable(names, func(i, j int) bool {
+			return sessions[names[i]].UpdatedAt.After(sessions[names[j]].UpdatedAt)
+		})
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.SetStyle(table.StyleColoredDark)
+		t.AppendHeader(table.Row{"Name", "Model", "Turns", "Branches", "Last Updated"})
+		for _, name := range names {
+			saved := sessions[name]
+			t.AppendRow(table.Row{name, saved.Model, saved.Turns(), len(saved.Branches),
+				saved.UpdatedAt.Local().Format(time.DateTime)})
+		}
+		t.Render()
+		return nil
+	},
+}
+
+// sessionsShowCmd prints a saved session.
+var sessionsShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a saved session.",
+	Long:  "Prints a summary of a saved session, followed by the conversation of its current branch, as Markdown.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		saved, err := loadSession(args[0])
+		if err != nil {
+			return err
+		}
+
+		branches := make([]string, 0, len(saved.Branches))
+		for branch := range saved.Branches {
+			branches = append(branches, branch)
+		}
+		sort.Strings(branches)
+
+		fmt.Println(text.Faint.Sprintf("Model: %s", saved.Model))
+		fmt.Println(text.Faint.Sprintf("Branch: %s (branches: %s)", saved.Branch, strings.Join(branches, ", ")))
+		fmt.Println(text.Faint.Sprintf("Turns: %d", saved.Turns()))
+		fmt.Println(text.Faint.Sprintf("Last updated: %s", saved.UpdatedAt.Local().Format(time.DateTime)))
+		fmt.Println()
+		return saved.Export(os.Stdout, session.FormatMarkdown)
+	},
+}
+
+// sessionsDeleteCmd deletes saved sessions.
+var sessionsDeleteCmd = &cobra.Command{
+	Use:   "delete <name>...",
+	Short: "Delete saved sessions.",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, name := range args {
+			path, err := namedSessionPath(name)
+			if err != nil {
+				return err
+			}
+			if err := os.Remove(path); err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return fmt.Errorf("session %q does not exist", name)
+				}
+				return fmt.Errorf("failed to delete session: %w", err)
+			}
+			fmt.Printf("Deleted session %q.\n", name)
+		}
+		return nil
+	},
+}
+
+// sessionsRenameCmd renames a saved session.
+var sessionsRenameCmd = &cobra.Command{
+	Use:   "rename <name> <new-name>",
+	Short: "Rename a saved session.",
+	Long:  "Renames a saved session, which is then resumed with its new name. A session with the new name must not exist.",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldPath, err := namedSessionPath(args[0])
+		if err != nil {
+			return err
+		}
+		newPath, err := namedSessionPath(args[1])
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(oldPath); errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("session %q does not exist", args[0])
+		}
+		if _, err := os.Stat(newPath); err == nil {
+			return fmt.Errorf("session %q already exists", args[1])
+		}
+		if err := os.Rename(oldPath, newPath); err != nil {
+			return fmt.Errorf("failed to rename session: %w", err)
+		}
+
+		fmt.Printf("Renamed session %q to %q.\n", args[0], args[1])
+		return nil
+	},
+}
+
+// sessionsForkCmd copies the current branch of a saved session into a new session.
+var sessionsForkCmd = &cobra.Command{
+	Use:   "fork <name> <new-name>",
+	Short: "Copy a saved session into a new one, to take it in another direction.",
+	Long: `Copies the current branch of a saved session, with its pins, into a new session, which can then be
+continued with --resume <new-name> without changing the original. With --turns, only the first turns are
+copied, to take the conversation in another direction from an earlier point.
+
+Within a chat, /fork does the same with a new branch of the session.`,
+	Example: "  llmb sessions fork work work-alt --turns 3\n  llmb chat --resume work-alt",
+	Args:    cobra.ExactArgs(2),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateSessionsForkFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		saved, err := loadSession(args[0])
+		if err != nil {
+			return err
+		}
+		path, err := namedSessionPath(args[1])
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("session %q already exists", args[1])
+		}
+
+		if err := saved.Fork(sessionsForkTurns).Save(path); err != nil {
+			return err
+		}
+
+		fmt.Printf("Forked session %q into %q, continue it with: llmb chat --resume %s\n", args[0], args[1], args[1])
+		return nil
+	},
+}
+
+// init registers the sessions commands and defines their local flags.
+func init() {
+	rootCmd.AddCommand(sessionsCmd)
+	sessionsCmd.AddCommand(sessionsListCmd, sessionsShowCmd, sessionsDeleteCmd, sessionsRenameCmd,
+		sessionsForkCmd, sessionsExportCmd)
+
+	sessionsForkCmd.Flags().IntVar(&sessionsForkTurns, "turns",
+		0, "Number of turns to copy, from the start of the conversation. Zero copies all of them.")
+
+	sessionsExportCmd.Flags().StringVarP(&sessionsExportOutput, "output", "o",
+		"", "File to export the session to, instead of standard output. It is replaced if it exists.")
+
+	sessionsExportCmd.Flags().StringVar(&sessionsExportFormat, "format",
+		session.FormatMarkdown, "Format of the export, either markdown or json.")
+}
+
+// exportSession exports the current branch of the session to the file at the given path, in the given format.
+func exportSession(saved *session.Session, path, format string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to write export file: %w", closeErr)
+		}
+	}()
+
+	return saved.Export(file, format)
+}
+
+// namedSessionPath validates the session name and returns the path of its file.
+func namedSessionPath(name string) (string, error) {
+	if err := validateName("session", name); err != nil {
+		return "", err
+	}
+	return sessionPath(name)
+}
+
+// loadSession loads the saved session with the given name.
+func loadSession(name string) (*session.Session, error) {
+	path, err := namedSessionPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := session.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("session %q does not exist", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %q: %w", name, err)
+	}
+	return saved, nil
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/streams"
+)
+
+var (
+	// responseTimeout limits the total time of every response, and responseIdleTimeout the time without tokens.
+	responseTimeout     time.Duration
+	responseIdleTimeout time.Duration
+)
+
+// addTimeoutFlags defines the response timeout flags on a command that streams responses.
+func addTimeoutFlags(flags *pflag.FlagSet) {
+	flags.DurationVar(&responseTimeout, "timeout",
+		0, "Abort a response that hasn't finished within this time, including retries. Zero means no limit.")
+
+	flags.DurationVar(&responseIdleTimeout, "idle-timeout",
+		0, "Abort a response when no token arrives for this long, like from a stuck server. Zero means no limit.")
+}
+
+// responseTimeoutError is the error of a response that took too long, in total or without tokens.
+type responseTimeoutError struct {
+	idle  bool
+	after time.Duration
+}
+
+func (e *responseTimeoutError) Error() string {
+	if e.idle {
+		return fmt.Sprintf("response aborted, no token arrived for %s", e.after)
+	}
+	return fmt.Sprintf("response aborted, it did not finish within %s", e.after)
+}
+
+// responseTimeouts aborts a response that takes too long, by canceling its context, if the flags say so.
+type responseTimeouts struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	// timer aborts the response once the timeout has passed, and idleTimer once no token arrived for
+	// the idle timeout, if they are set.
+	timer, idleTimer *time.Timer
+}
+
+// newResponseTimeouts returns the timeouts of a response, and the context for it, derived from the given one.
+// The timeouts must be stopped once the response is complete.
+func newResponseTimeouts(ctx context.Context) (context.Context, *responseTimeouts) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	t := &responseTimeouts{ctx: ctx, cancel: cancel}
+
+	if responseTimeout > 0 {
+		t.timer = time.AfterFunc(responseTimeout, func() {
+			cancel(&responseTimeoutError{after: responseTimeout})
+		})
+	}
+	if responseIdleTimeout > 0 {
+		t.idleTimer = time.AfterFunc(responseIdleTimeout, func() {
+			cancel(&responseTimeoutError{idle: true, after: responseIdleTimeout})
+		})
+	}
+	return ctx, t
+}
+
+// watch returns the given stream, resetting the idle timeout whenever it yields an event.
+func (t *responseTimeouts) watch(stream *streams.Stream[api.ChatCompletionEvent],
+) *streams.Stream[api.ChatCompletionEvent] {
+	if t.idleTimer == nil {
+		return stream
+	}
+	return streams.Map(stream, func(event api.ChatCompletionEvent) api.ChatCompletionEvent {
+		t.idleTimer.Reset(responseIdleTimeout)
+		return event
+	})
+}
+
+// wrap replaces the error caused by a timeout with the timeout error, which tells what happened.
+func (t *responseTimeouts) wrap(err error) error {
+	var timeoutErr *responseTimeoutError
+	if err != nil && errors.As(context.Cause(t.ctx), &timeoutErr) {
+		return timeoutErr
+	}
+	return err
+}
+
+// stop releases the resources of the timeouts.
+func (t *responseTimeouts) stop() {
+	for _, timer := range []*time.Timer{t.timer, t.idleTimer} {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	t.cancel(nil)
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/api/tokenizer"
+)
+
+var (
+	tokensFiles     []string
+	tokensTokenizer string
+	tokensBreakdown bool
+)
+
+// tokensCmd represents the `tokens` command, which counts the tokens of text, files, or stdin.
+//
+// The counting is done locally, so the API is never called.
+var tokensCmd = &cobra.Command{
+	Use:   "tokens [text]",
+	Short: "Count the tokens of text, files, or stdin.",
+	Long: `Counts the tokens of the given text, of the files given with --file, or of stdin if neither is given.
+Counting is done locally with an approximation of the model's tokenizer, so counts are estimates.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateTokensFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tok := tokenizer.ForModel(rootModel)
+		if tokensTokenizer != "" {
+			tok, _ = tokenizer.Get(tokensTokenizer) // Validated already.
+		}
+
+		// Every source of text is counted separately.
+		type source struct{ name, content string }
+		var sources []source
+
+		if len(args) > 0 {
+			sources = append(sources, source{name: "text", content: strings.Join(args, " ")})
+		}
+		for _, path := range tokensFiles {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			sources = append(sources, source{name: path, content: string(content)})
+		}
+		if len(sources) == 0 {
+			content, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read stdin: %w", err)
+			}
+			sources = append(sources, source{name: "stdin", content: string(content)})
+		}
+
+		var total int
+		for _, src := range sources {
+			tokens := tok.Tokenize(src.content)
+			total += len(tokens)
+
+			if tokensBreakdown {
+				printTokens(tokens)
+			}
+			if len(sources) > 1 {
+				fmt.Printf("%s: %d tokens\n", src.name, len(tokens))
+			}
+		}
+
+		fmt.Printf("Total: ~%d tokens (%s tokenizer)\n", total, tok.Name())
+		return nil
+	},
+}
+
+// init registers the tokens command with the root command and defines its local flags.
+func init() {
+	rootCmd.AddCommand(tokensCmd)
+
+	tokensCmd.Flags().StringArrayVarP(&tokensFiles, "file", "f",
+		nil, "Path of a file to count the tokens of. Can be repeated.")
+
+	tokensCmd.Flags().StringVar(&tokensTokenizer, "tokenizer",
+		"", fmt.Sprintf("Tokenizer to use instead of the model's. One of: %s.", strings.Join(tokenizer.Names(), ", ")))
+
+	tokensCmd.Flags().BoolVar(&tokensBreakdown, "breakdown",
+		false, "Show the tokens, highlighted in alternating colors.")
+}
+
+// printTokens prints the given tokens in alternating colors, to show where each one starts and ends.
+func printTokens(tokens []string) {
+	colors := []text.Colors{{text.FgBlack, text.BgCyan}, {text.FgBlack, text.BgYellow}}
+
+	for i, token := range tokens {
+		// Line breaks are printed uncolored, so the background doesn't spill over the line.
+		lines := strings.Split(token, "\n")
+		for j, line := range lines {
+			if j > 0 {
+				fmt.Println()
+			}
+			if line != "" {
+				fmt.Print(colors[i%len(colors)].Sprint(line))
+			}
+		}
+	}
+	fmt.Println()
+}
+
+// estimateTokens approximates the number of tokens in the given text, with the tokenizer of the model.
+func estimateTokens(text string) int {
+	return tokenizer.Count(tokenizer.ForModel(rootModel), text)
+}
+
+// estimateMessageTokens approximates the number of prompt tokens of the given messages.
+func estimateMessageTokens(messages []api.ChatMessage) int {
+	return tokenizer.CountTokens(rootModel, messages)
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// transcribeCmd represents the `transcribe` command, which transcribes an audio file.
+var transcribeCmd = &cobra.Command{
+	Use:   "transcribe <audio-file>",
+	Short: "Transcribe an audio file.",
+	Long: `Uploads the audio file to the transcriptions API of a Whisper-compatible server, and prints its transcription.
+Select the transcription model with --model, like --model whisper-1.`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error { return asUsageError(validateRootFlags()) },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		transcription, err := newClient().Transcribe(cmd.Context(), rootModel, args[0])
+		if err != nil {
+			// Ignore context cancellation errors.
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return fmt.Errorf("failed to transcribe: %w", err)
+		}
+
+		fmt.Println(transcription)
+		return nil
+	},
+}
+
+// init registers the transcribe command with the root command.
+func init() {
+	rootCmd.AddCommand(transcribeCmd)
+}
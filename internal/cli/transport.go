@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+
+	"github.com/shivanshkc/llmb/pkg/httpx"
+)
+
+var (
+	// rootInsecure and rootCACert configure the verification of the server's TLS certificate.
+	rootInsecure bool
+	rootCACert   string
+	// rootCert and rootKey are the PEM files of the client certificate for mutual TLS, if any.
+	rootCert string
+	rootKey  string
+	// rootProxy is the URL of the proxy for calling the API, instead of the one of the environment, if any.
+	rootProxy string
+
+	// networkTransport is the transport for calling the API, when the default one doesn't do.
+	networkTransport http.RoundTripper
+)
+
+// setupTransport creates the network transport, if any of the root flags need one.
+//
+// The default transport already uses the proxy set by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables, which --proxy overrides.
+func setupTransport() error {
+	transport, err := newNetworkTransport(rootInsecure, rootCACert, rootCert, rootKey, rootProxy)
+	if err != nil {
+		return err
+	}
+	networkTransport = transport
+
+	if rootInsecure {
+		logger.Warn("TLS certificate verification is disabled")
+	}
+	return nil
+}
+
+// newNetworkTransport returns a transport with the given TLS settings and proxy, or nil if the default
+// transport does, as none is set.
+func newNetworkTransport(insecure bool, caCert, cert, key, proxy string) (http.RoundTripper, error) {
+	if !insecure && caCert == "" && cert == "" && key == "" && proxy == "" {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure || caCert != "" || cert != "" || key != "" {
+		config, err := httpx.NewTLSConfig(insecure, caCert)
+		if err != nil {
+			return nil, err
+		}
+		if cert != "" || key != "" {
+			if cert == "" || key == "" {
+				return nil, asUsageError(errors.New("--cert and --key must be given together"))
+			}
+			certificate, err := httpx.LoadClientCertificate(cert, key)
+			if err != nil {
+				return nil, err
+			}
+			config.Certificates = []tls.Certificate{certificate}
+		}
+		transport.TLSClientConfig = config
+	}
+	if proxy != "" {
+		proxyURL, err := httpx.ParseProxyURL(proxy)
+		if err != nil {
+			return nil, asUsageError(err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport, nil
+}
@@ -6,7 +6,20 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/shivanshkc/llmb/pkg/api/tokenizer"
+	"github.com/shivanshkc/llmb/pkg/bench"
+	"github.com/shivanshkc/llmb/pkg/proxy"
+	"github.com/shivanshkc/llmb/pkg/session"
 )
 
 // These validation functions are designed to be used with Cobra's `PreRunE`
@@ -32,6 +45,52 @@ func validateRootFlags() error {
 		return errors.New("model is required")
 	}
 
+	if _, err := parseHeaders(rootHeaders); err != nil {
+		return err
+	}
+
+	if rootRetries < 0 {
+		return errors.New("retries must not be negative")
+	}
+	if rootRetryDelay < 0 || rootRetryMaxElapsed < 0 {
+		return errors.New("retry delay and max elapsed must not be negative")
+	}
+	if _, ok := retryBackoffs[rootRetryBackoff]; !ok {
+		return fmt.Errorf("invalid retry backoff %q, must be %s or %s",
+			rootRetryBackoff, retryBackoffConstant, retryBackoffExponential)
+	}
+	if rootRequestTimeout < 0 {
+		return errors.New("request timeout must not be negative")
+	}
+
+	// The cache and timeout flags are only defined by some commands, but their defaults are valid.
+	if cacheTTL < 0 {
+		return errors.New("cache TTL must not be negative")
+	}
+	if responseTimeout < 0 || responseIdleTimeout < 0 {
+		return errors.New("response timeouts must not be negative")
+	}
+	if notifyMode != "" && notifyMode != notifyBell && notifyMode != notifyDesktop {
+		return fmt.Errorf("invalid notification %q, must be %s or %s", notifyMode, notifyBell, notifyDesktop)
+	}
+
+	// So are the request parameter flags.
+	if paramTemperature < 0 || paramTemperature > 2 {
+		return errors.New("temperature must be between 0 and 2")
+	}
+	if paramTopP < 0 || paramTopP > 1 {
+		return errors.New("top-p must be between 0 and 1")
+	}
+	if paramMaxTokens < 0 {
+		return errors.New("max tokens must not be negative")
+	}
+	if paramPresencePenalty < -2 || paramPresencePenalty > 2 || paramFrequencyPenalty < -2 || paramFrequencyPenalty > 2 {
+		return errors.New("presence and frequency penalties must be between -2 and 2")
+	}
+	if _, err := parseParams(paramExtra); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -40,7 +99,7 @@ func validateRootFlags() error {
 // This function composes validation by first calling `validateRootFlags`.
 // This is a clean, DRY (Don't Repeat Yourself) pattern that ensures shared flags
 // are always validated without duplicating logic.
-func validateBenchFlags() error {
+func validateBenchFlags(flags *pflag.FlagSet) error {
 	// First, validate the shared root flags.
 	if err := validateRootFlags(); err != nil {
 		return err
@@ -51,6 +110,10 @@ func validateBenchFlags() error {
 		return errors.New("a prompt is required for benchmarking")
 	}
 
+	if benchPromptTokens < 0 {
+		return errors.New("prompt tokens must not be negative")
+	}
+
 	if benchRequestCount <= 0 {
 		return errors.New("request count must be greater than 0")
 	}
@@ -59,11 +122,470 @@ func validateBenchFlags() error {
 		return errors.New("concurrency must be greater than 0")
 	}
 
+	if benchDuration < 0 {
+		return errors.New("duration must not be negative")
+	}
+
+	if benchDuration > 0 && flags.Changed("request-count") {
+		return errors.New("--duration and --request-count cannot be used together")
+	}
+
+	// The share of the requests that may fail is unknown without a number of requests.
+	if benchDuration > 0 && benchMaxErrorRate != "" {
+		return errors.New("--duration and --max-error-rate cannot be used together, use --max-errors instead")
+	}
+
+	if benchRPS < 0 || benchRPS > bench.MaxRate || math.IsNaN(benchRPS) {
+		return fmt.Errorf("rps must be between 0 and %d", bench.MaxRate)
+	}
+
+	if benchRPS > 0 && flags.Changed("concurrency") {
+		return errors.New("--rps and --concurrency cannot be used together")
+	}
+
+	if benchArrivals != bench.ArrivalsPoisson && benchArrivals != bench.ArrivalsUniform {
+		return fmt.Errorf("invalid arrivals %q, must be %s or %s", benchArrivals, bench.ArrivalsPoisson, bench.ArrivalsUniform)
+	}
+
+	if benchWarmup < 0 {
+		return errors.New("warmup must not be negative")
+	}
+
+	if benchMaxErrors < 0 {
+		return errors.New("max errors must not be negative")
+	}
+
+	if benchMaxErrorRate != "" {
+		if _, err := parseRate(benchMaxErrorRate); err != nil {
+			return fmt.Errorf("invalid max error rate: %w", err)
+		}
+	}
+
+	if benchOutput != benchOutputTable && benchOutput != bench.FormatJSON && benchOutput != bench.FormatYAML {
+		return fmt.Errorf("invalid output format %q, must be %s, %s or %s",
+			benchOutput, benchOutputTable, bench.FormatJSON, bench.FormatYAML)
+	}
+
+	if benchRawOut != "" && rawOutFormat(benchRawOut) == "" {
+		return fmt.Errorf("invalid raw output file %q, must end with .csv, .jsonl or .ndjson", benchRawOut)
+	}
+
+	if benchRawGaps && benchRawOut == "" {
+		return errors.New("--raw-gaps requires --raw-out")
+	}
+
 	return nil
 }
 
 // validateChatFlags checks the validity of all flags required by the `chat` command.
-func validateChatFlags() error {
-	// The `chat` command only uses the shared root flags.
-	return validateRootFlags()
+func validateChatFlags(args []string) error {
+	if err := validateRootFlags(); err != nil {
+		return err
+	}
+
+	// Allow `--resume <name>` in addition to `--resume=<name>`. Since the flag's
+	// value is optional, the parser considers the name a positional argument.
+	if chatResume == lastSessionName && len(args) == 1 {
+		chatResume = args[0]
+	} else if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %v", args)
+	}
+
+	if chatShowReasoning && chatHideReasoning {
+		return errors.New("--show-reasoning and --hide-reasoning cannot be used together")
+	}
+
+	if chatResume != "" {
+		if err := validateName("session", chatResume); err != nil {
+			return err
+		}
+	}
+
+	if chatSessionName != "" {
+		if chatResume != "" {
+			return errors.New("--session and --resume cannot be used together")
+		}
+		if err := validateName("session", chatSessionName); err != nil {
+			return err
+		}
+	}
+
+	if chatSystem != "" && chatSystemFile != "" {
+		return errors.New("--system and --system-file cannot be used together")
+	}
+
+	for _, name := range chatTools {
+		if _, ok := availableTools[name]; !ok {
+			return fmt.Errorf("unknown tool %q, must be one of: %s", name, strings.Join(availableToolNames(), ", "))
+		}
+	}
+	if len(chatTools) > 0 && chatSchemaFile != "" {
+		return errors.New("--tools and --schema cannot be used together")
+	}
+	// The calls are confirmed on the terminal, which piped input would answer instead of the user.
+	if len(chatTools) > 0 && !isTerminal(os.Stdin) {
+		return errors.New("--tools requires an interactive terminal to confirm the tool calls")
+	}
+
+	if chatSchemaRetries < 0 {
+		return errors.New("schema retries must not be negative")
+	}
+
+	if chatContextBudget < 0 {
+		return errors.New("context budget must not be negative")
+	}
+
+	if chatContextWindow < 0 {
+		return errors.New("context window must not be negative")
+	}
+
+	if chatStallAfter < 0 {
+		return errors.New("stall duration must not be negative")
+	}
+
+	// The knowledge base is optional.
+	if chatKB != "" {
+		if err := validateName("index", chatKB); err != nil {
+			return err
+		}
+		if chatKBTopK <= 0 {
+			return errors.New("knowledge base top-k must be greater than 0")
+		}
+	}
+
+	return nil
+}
+
+// validateIndexAddFlags checks the validity of all flags required by the `index add` command.
+func validateIndexAddFlags() error {
+	if err := validateRootFlags(); err != nil {
+		return err
+	}
+
+	if err := validateName("index", indexName); err != nil {
+		return err
+	}
+
+	if indexEmbeddingModel == "" {
+		return errors.New("embedding model is required")
+	}
+
+	if indexChunkSize <= 0 {
+		return errors.New("chunk size must be greater than 0")
+	}
+
+	if indexChunkOverlap < 0 || indexChunkOverlap >= indexChunkSize {
+		return errors.New("chunk overlap must be non-negative and less than the chunk size")
+	}
+
+	return nil
+}
+
+// validateServeFlags checks the validity of all flags required by the `serve` command.
+func validateServeFlags() error {
+	if err := validateRootFlags(); err != nil {
+		return err
+	}
+
+	if serveListen == "" {
+		return errors.New("listen address is required")
+	}
+
+	if _, err := parseUpstreams(); err != nil {
+		return err
+	}
+
+	if !slices.Contains(proxy.Strategies(), serveStrategy) {
+		return fmt.Errorf("invalid strategy %q, must be one of: %s", serveStrategy, strings.Join(proxy.Strategies(), ", "))
+	}
+
+	if serveHealthInterval < 0 {
+		return errors.New("health interval must not be negative")
+	}
+
+	return nil
+}
+
+// validateMockFlags checks the validity of all flags required by the `mock` command.
+// The mock server doesn't talk to an API, so the root flags are not validated.
+func validateMockFlags() error {
+	if mockListen == "" {
+		return errors.New("listen address is required")
+	}
+
+	if mockTTFT < 0 || mockTokenRate < 0 || mockTokens < 0 {
+		return errors.New("TTFT, token rate and tokens must not be negative")
+	}
+
+	if mockJitter < 0 || mockJitter > 1 {
+		return errors.New("jitter must be between 0 and 1")
+	}
+
+	if mockErrorRate < 0 || mockErrorRate > 1 {
+		return errors.New("error rate must be between 0 and 1")
+	}
+
+	if mockErrorStatus < 400 || mockErrorStatus > 599 {
+		return errors.New("error status must be a 4xx or 5xx status code")
+	}
+
+	return nil
+}
+
+// validateTokensFlags checks the validity of all flags required by the `tokens` command.
+// No API is called, so only the model is needed out of the root flags.
+func validateTokensFlags() error {
+	if rootModel == "" {
+		return errors.New("model is required")
+	}
+
+	if tokensTokenizer != "" {
+		if _, err := tokenizer.Get(tokensTokenizer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRunFlags checks the validity of all flags required by the `run` command.
+func validateRunFlags() error {
+	if err := validateRootFlags(); err != nil {
+		return err
+	}
+
+	if runOutputFile == "" {
+		return errors.New("output file is required")
+	}
+
+	if runConcurrency <= 0 {
+		return errors.New("concurrency must be greater than 0")
+	}
+
+	// A rate too high for the interval between two requests to be measured makes no sense, and is likely a typo.
+	if runRPS < 0 || math.IsNaN(runRPS) || math.IsInf(runRPS, 0) {
+		return errors.New("rps must be a finite number, not negative")
+	}
+	if runRPS > 0 && time.Duration(float64(time.Second)/runRPS) <= 0 {
+		return fmt.Errorf("rps must be at most %d", int(time.Second))
+	}
+
+	if runAttempts <= 0 {
+		return errors.New("attempts must be greater than 0")
+	}
+
+	if runAttemptDelay < 0 {
+		return errors.New("attempt delay must not be negative")
+	}
+
+	return nil
+}
+
+// validateEvalFlags checks the validity of all flags required by the `eval` command.
+func validateEvalFlags() error {
+	if err := validateRootFlags(); err != nil {
+		return err
+	}
+
+	if evalRepeat <= 0 {
+		return errors.New("repeat must be greater than 0")
+	}
+
+	if evalThreshold < 0 || evalThreshold > 1 {
+		return errors.New("threshold must be between 0 and 1")
+	}
+
+	if evalJudgeBaseURL != "" {
+		if _, err := url.Parse(evalJudgeBaseURL); err != nil {
+			return fmt.Errorf("invalid judge base URL: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateDiffFlags checks the validity of all flags required by the `diff` command.
+func validateDiffFlags() error {
+	if err := validateRootFlags(); err != nil {
+		return err
+	}
+
+	if diffModelB == "" && diffBaseURLB == "" {
+		return errors.New("at least one of --model-b and --base-url-b is required")
+	}
+
+	if diffBaseURLB != "" {
+		if _, err := url.Parse(diffBaseURLB); err != nil {
+			return fmt.Errorf("invalid second base URL: %w", err)
+		}
+	}
+
+	if diffFormat != diffFormatUnified && diffFormat != diffFormatSideBySide {
+		return fmt.Errorf("invalid format %q, must be %s or %s", diffFormat, diffFormatUnified, diffFormatSideBySide)
+	}
+
+	if diffContext < 0 {
+		return errors.New("context must not be negative")
+	}
+
+	if diffWidth < 20 {
+		return errors.New("width must be at least 20")
+	}
+
+	return nil
+}
+
+// validateAskFlags checks the validity of all flags required by the `ask` command.
+func validateAskFlags(args []string) error {
+	if err := validateRootFlags(); err != nil {
+		return err
+	}
+
+	if askWatchFile != "" && len(args) > 0 {
+		return errors.New("a prompt cannot be given along with --watch")
+	}
+
+	if askTemplate != "" {
+		if askWatchFile != "" || len(args) > 0 {
+			return errors.New("a prompt or --watch cannot be given along with --template")
+		}
+		if err := validateName("prompt template", askTemplate); err != nil {
+			return err
+		}
+	} else if len(promptVars) > 0 {
+		return errors.New("--var can only be used with --template")
+	}
+
+	// Without arguments, the prompt may be piped to standard input.
+	if askWatchFile == "" && askTemplate == "" && len(args) == 0 && isTerminal(os.Stdin) {
+		return errors.New("a prompt is required")
+	}
+
+	if askChoices < 1 {
+		return errors.New("choices must be at least 1")
+	}
+
+	if askSchemaRetries < 0 {
+		return errors.New("schema retries must not be negative")
+	}
+	if askSchemaFile != "" && askChoices > 1 {
+		return errors.New("--schema and --choices cannot be used together")
+	}
+
+	return nil
+}
+
+// validateModelsFlags checks the validity of all flags required by the `models` command.
+// No model is used, so only the base URL is needed out of the root flags.
+func validateModelsFlags() error {
+	if rootBaseURL == "" {
+		return errors.New("base URL is required")
+	}
+
+	if _, err := url.Parse(rootBaseURL); err != nil {
+		return fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	if _, err := parseHeaders(rootHeaders); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateBatchFlags checks the validity of all flags required by the `batch` commands.
+// Batches name their models in their requests, so only the base URL is needed out of the root flags.
+func validateBatchFlags() error {
+	if err := validateModelsFlags(); err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(batchEndpoint, "/") {
+		return fmt.Errorf("invalid endpoint %q, must be a path like /v1/chat/completions", batchEndpoint)
+	}
+
+	if batchListLimit <= 0 {
+		return errors.New("limit must be greater than 0")
+	}
+
+	return nil
+}
+
+// validateSessionsExportFlags checks the validity of all flags required by the `sessions export` command.
+// No API is called, so none of the root flags are needed.
+func validateSessionsExportFlags(args []string) error {
+	if err := validateName("session", args[0]); err != nil {
+		return err
+	}
+
+	if !slices.Contains(session.Formats(), sessionsExportFormat) {
+		return fmt.Errorf("invalid format %q, must be one of: %s", sessionsExportFormat, strings.Join(session.Formats(), ", "))
+	}
+
+	return nil
+}
+
+// validateSessionsForkFlags checks the validity of all flags required by the `sessions fork` command.
+func validateSessionsForkFlags() error {
+	if sessionsForkTurns < 0 {
+		return errors.New("turns must not be negative")
+	}
+
+	return nil
+}
+
+// validateGenDocsFlags checks the validity of all flags required by the `gen-docs` command.
+func validateGenDocsFlags() error {
+	if genDocsFormat != docsFormatMan && genDocsFormat != docsFormatMarkdown {
+		return fmt.Errorf("invalid format %q, must be %s or %s", genDocsFormat, docsFormatMan, docsFormatMarkdown)
+	}
+
+	if genDocsOutputDir == "" {
+		return errors.New("output directory is required")
+	}
+
+	return nil
+}
+
+// validateInitFlags checks the validity of all flags required by the `init` command.
+func validateInitFlags() error {
+	if err := validateRootFlags(); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(initName) == "" {
+		return errors.New("profile name is required")
+	}
+
+	return nil
+}
+
+// parseRate parses a rate between 0 and 1, given either as a fraction, like 0.05, or as a percentage, like 5%.
+func parseRate(value string) (float64, error) {
+	number, percent := strings.CutSuffix(value, "%")
+	rate, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number or a percentage", value)
+	}
+	if percent {
+		rate /= 100
+	}
+	if rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("%q is not between 0 and 100%%", value)
+	}
+	return rate, nil
+}
+
+// validateName checks that the given name of a stored item, like an index, can
+// be safely used as a file name. The kind of the item is used in error messages.
+func validateName(kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s name is required", kind)
+	}
+
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid %s name: %q", kind, name)
+	}
+
+	return nil
 }
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseFlags parses the given arguments into the flags of the command, including the persistent flags
+// of its parents, and restores the flags that they changed once the test ends, as the flags are package
+// variables.
+func parseFlags(t *testing.T, cmd *cobra.Command, args ...string) {
+	t.Helper()
+	flags := cmd.Flags()
+	t.Cleanup(func() {
+		flags.Visit(func(flag *pflag.Flag) {
+			// Setting a slice flag appends to it, so its default is put back whole.
+			if slice, ok := flag.Value.(pflag.SliceValue); ok {
+				_ = slice.Replace(nil)
+			} else {
+				_ = flag.Value.Set(flag.DefValue)
+			}
+			flag.Changed = false
+		})
+	})
+	require.NoError(t, cmd.ParseFlags(args))
+}
+
+// TestValidateBenchFlags verifies the combinations of the bench flags that are rejected.
+func TestValidateBenchFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "Duration", args: []string{"-p", "hi", "--duration", "1m"}},
+		{name: "Max Error Rate", args: []string{"-p", "hi", "--max-error-rate", "5%"}},
+		{name: "Duration with Max Errors", args: []string{"-p", "hi", "--duration", "1m", "--max-errors", "3"}},
+		{
+			name:    "Duration with Request Count",
+			args:    []string{"-p", "hi", "--duration", "1m", "-n", "10"},
+			wantErr: "--duration and --request-count cannot be used together",
+		},
+		{
+			name:    "Duration with Max Error Rate",
+			args:    []string{"-p", "hi", "--duration", "1m", "--max-error-rate", "5%"},
+			wantErr: "--duration and --max-error-rate cannot be used together",
+		},
+		{name: "Rate", args: []string{"-p", "hi", "--rps", "50"}},
+		{name: "Infinite Rate", args: []string{"-p", "hi", "--rps", "Inf"}, wantErr: "rps must be between 0 and 10000"},
+		{name: "Rate Above Maximum", args: []string{"-p", "hi", "--rps", "1e6"}, wantErr: "rps must be between 0 and 10000"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parseFlags(t, benchCmd, tc.args...)
+			err := validateBenchFlags(benchCmd.Flags())
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+// TestValidateChatFlags verifies the combinations of the chat flags that are rejected.
+func TestValidateChatFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "No Flags"},
+		{name: "Unknown Tool", args: []string{"--tools", "browser"}, wantErr: `unknown tool "browser"`},
+		{name: "Tools with Piped Input", args: []string{"--tools", "shell"}, wantErr: "requires an interactive terminal"},
+	}
+
+	// The input is piped, like in scripts.
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() { _ = writer.Close() }()
+	defer func() { _ = reader.Close() }()
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = stdin }()
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parseFlags(t, chatCmd, tc.args...)
+			err := validateChatFlags(nil)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+// TestValidateAskFlags verifies the combinations of the ask flags that are rejected.
+func TestValidateAskFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		prompt  []string
+		wantErr string
+	}{
+		{name: "Prompt", prompt: []string{"hi"}},
+		{name: "Template", args: []string{"--template", "summarize", "--var", "file=@notes.txt"}},
+		{
+			name:    "Template with a Prompt",
+			args:    []string{"--template", "summarize"},
+			prompt:  []string{"hi"},
+			wantErr: "cannot be given along with --template",
+		},
+		{
+			name:    "Template with Watch",
+			args:    []string{"--template", "summarize", "--watch", "prompt.txt"},
+			wantErr: "cannot be given along with --template",
+		},
+		{name: "Invalid Template", args: []string{"--template", "../secrets"}, wantErr: "invalid prompt template name"},
+		{name: "Var without Template", args: []string{"--var", "a=b"}, prompt: []string{"hi"}, wantErr: "--var"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parseFlags(t, askCmd, tc.args...)
+			err := validateAskFlags(tc.prompt)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+// TestValidateRunFlags verifies the values of the run flags that are rejected.
+func TestValidateRunFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "Rate", args: []string{"--rps", "2.5"}},
+		{name: "Negative Rate", args: []string{"--rps", "-1"}, wantErr: "rps must be a finite number"},
+		{name: "Infinite Rate", args: []string{"--rps", "Inf"}, wantErr: "rps must be a finite number"},
+		{name: "Unmeasurable Rate", args: []string{"--rps", "1e10"}, wantErr: "rps must be at most"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parseFlags(t, runCmd, tc.args...)
+			err := validateRunFlags()
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
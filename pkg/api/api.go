@@ -3,73 +3,728 @@ package api
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/shivanshkc/llmb/pkg/clock"
 	"github.com/shivanshkc/llmb/pkg/httpx"
 	"github.com/shivanshkc/llmb/pkg/streams"
 )
 
+// Default retry policy of a Client, used unless WithRetry is given.
+const (
+	DefaultMaxAttempts = 20
+	DefaultRetryDelay  = time.Millisecond * 50
+)
+
 // Client represents an LLM REST API client.
 type Client struct {
 	baseURL    string
 	httpClient *httpx.RetryClient
+
+	// apiKey, if set, is sent as a bearer token with every request.
+	apiKey string
+	// headers are the extra headers sent with every request.
+	headers http.Header
+
+	// maxAttempts and retryDelay control the retrying of failed requests.
+	maxAttempts int
+	retryDelay  time.Duration
+
+	// interceptors hook into every request, in order.
+	interceptors []Interceptor
+
+	// rateLimit holds the rate limits reported by the last response that reported any.
+	rateLimitMu sync.Mutex
+	rateLimit   *RateLimitInfo
+
+	// logger reports requests, retries and streams. It discards everything by default.
+	logger *slog.Logger
+
+	// clock timestamps stream events, and times requests and retries.
+	clock clock.Clock
+
+	// debugWriter, if set, receives the dumps of all requests and responses.
+	debugWriter io.Writer
+	// dryRunWriter, if set, receives the dumps of all requests instead of the server.
+	dryRunWriter io.Writer
+}
+
+// ClientOption configures optional behavior of a Client.
+type ClientOption func(*Client)
+
+// Interceptor hooks into the requests of a Client, for example to log them, to record metrics,
+// or to modify them. Any of its functions may be nil.
+type Interceptor struct {
+	// OnRequest is called before a request is sent, and may modify it, other than its body,
+	// like adding headers. Retries send the modified request. An error aborts the request.
+	OnRequest func(request *http.Request) error
+	// OnResponse is called with the response of a request, whatever its status code, before its body is read.
+	OnResponse func(response *http.Response)
+	// OnStreamEvent is called with every event of a chat completion stream, before the
+	// stream returns it, and may modify it. Events that failed to be read are skipped.
+	OnStreamEvent func(event *ChatCompletionEvent)
+}
+
+// WithInterceptor adds an interceptor to the client. Interceptors are called in the order they are added.
+func WithInterceptor(interceptor Interceptor) ClientOption {
+	return func(c *Client) { c.interceptors = append(c.interceptors, interceptor) }
+}
+
+// WithAPIKey makes the client authenticate every request with the given API key,
+// sent as a bearer token in the Authorization header.
+func WithAPIKey(apiKey string) ClientOption {
+	return func(c *Client) { c.apiKey = apiKey }
+}
+
+// WithHeaders makes the client send the given extra headers with every request.
+func WithHeaders(headers map[string]string) ClientOption {
+	return func(c *Client) {
+		for name, value := range headers {
+			c.headers.Set(name, value)
+		}
+	}
+}
+
+// WithOrganization makes the client send the given organization ID in the OpenAI-Organization header
+// with every request, so that its usage is accounted to that organization.
+func WithOrganization(organization string) ClientOption {
+	return func(c *Client) { c.headers.Set("OpenAI-Organization", organization) }
+}
+
+// WithProject makes the client send the given project ID in the OpenAI-Project header
+// with every request, so that its usage is accounted to that project.
+func WithProject(project string) ClientOption {
+	return func(c *Client) { c.headers.Set("OpenAI-Project", project) }
+}
+
+// WithRetry sets the maximum number of attempts for every request, and the delay
+// between consecutive attempts. A maxAttempts of 1 disables retries.
+func WithRetry(maxAttempts int, delay time.Duration) ClientOption {
+	return func(c *Client) { c.maxAttempts, c.retryDelay = maxAttempts, delay }
+}
+
+// WithRetryMaxElapsed limits the total time spent retrying a request. Once the next
+// attempt would start later than maxElapsed after the first one, the request fails.
+// A zero maxElapsed, the default, means no limit.
+func WithRetryMaxElapsed(maxElapsed time.Duration) ClientOption {
+	return func(c *Client) { c.httpClient.MaxElapsed = maxElapsed }
+}
+
+// WithRetryBackoff sets the strategy that computes the delays between the attempts of a request,
+// from the delay given to WithRetry. The default is httpx.ConstantBackoff.
+func WithRetryBackoff(backoff httpx.Backoff) ClientOption {
+	return func(c *Client) { c.httpClient.Backoff = backoff }
+}
+
+// WithTimeout limits the time of every attempt of a request, including the reading of the response,
+// which is the whole stream for streamed responses. A zero timeout, the default, means no limit.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) { c.httpClient.Timeout = timeout }
+}
+
+// WithTLSConfig makes the client connect with the given TLS configuration, for example, to trust
+// a custom CA, or to present a client certificate to servers that require mutual TLS. It replaces
+// the transport, so it must not be combined with WithTransport.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config
+		c.httpClient.Transport = transport
+	}
+}
+
+// WithClock makes the client use the given clock for timestamping stream events and timing requests
+// and retries, instead of the real clock. It is meant for deterministic tests.
+func WithClock(c clock.Clock) ClientOption {
+	return func(client *Client) { client.clock = c }
+}
+
+// APIError is returned when the API responds with a non-200 status code.
+//
+// Code, Type and Message are parsed from the standard OpenAI error body, like
+// {"error": {"message": "...", "type": "...", "code": "..."}}, and are empty if
+// the body is not in that format.
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable error code, like "rate_limit_exceeded" or "invalid_api_key".
+	Code string
+	// Type is the category of the error, like "invalid_request_error".
+	Type string
+	// Message is the human-readable description of the error.
+	Message string
+	// Body is the raw response body, which usually describes the error.
+	Body string
+	// RequestID is the ID that the server assigned to the request, if any, to correlate it with the server logs.
+	RequestID string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	var message string
+	switch {
+	case e.Message == "":
+		message = fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, e.Body)
+	case e.Code != "":
+		message = fmt.Sprintf("API error (status %d, code %s): %s", e.StatusCode, e.Code, e.Message)
+	default:
+		message = fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
+	}
+
+	if e.RequestID != "" {
+		message += fmt.Sprintf(" (request ID: %s)", e.RequestID)
+	}
+	return message
+}
+
+// requestIDHeaders are the response headers that carry the ID of a request, in the order of
+// preference. Besides the common one, some providers, like Anthropic and Azure, use their own.
+var requestIDHeaders = []string{"X-Request-Id", "Request-Id", "Apim-Request-Id", "X-Amzn-Requestid"}
+
+// RequestID returns the ID that the server assigned to a request, from the headers of its response,
+// or an empty string if there's none.
+func RequestID(header http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// newAPIError returns the APIError of a response with the given status code and body.
+func newAPIError(statusCode int, body string) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Body: body}
+
+	// errorDetails is the error object of the standard format. Some servers, like
+	// vLLM, put its fields at the top level instead. The code may be a number.
+	type errorDetails struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    any    `json:"code"`
+	}
+	var parsed struct {
+		errorDetails
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &parsed); err != nil {
+		return apiErr
+	}
+
+	details := parsed.errorDetails
+	if len(parsed.Error) > 0 {
+		// The error is either an object, or only a message.
+		if err := json.Unmarshal(parsed.Error, &details); err != nil {
+			_ = json.Unmarshal(parsed.Error, &details.Message)
+		}
+	}
+
+	apiErr.Message, apiErr.Type = details.Message, details.Type
+	if details.Code != nil {
+		apiErr.Code = fmt.Sprint(details.Code)
+	}
+	return apiErr
 }
 
 // ChatMessage represents a single message in the LLM chat.
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Parts holds additional non-text content, like images, for multimodal models.
+	// If non-empty, the message is sent in the multi-part content format, with
+	// Content as the leading text part.
+	Parts []ContentPart `json:"-"`
+	// ToolCalls holds the tool calls of an assistant message.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID is the ID of the call that a tool message holds the result of.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// ContentPart represents a single part of a multi-part message content.
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL is the image payload of an image content part.
+// The URL may be a regular URL or a base64 encoded data URL.
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+// NewImagePart returns an image ContentPart for the given URL.
+func NewImagePart(url string) ContentPart {
+	return ContentPart{Type: ContentTypeImageURL, ImageURL: &ImageURL{URL: url}}
+}
+
+// MarshalJSON encodes the message using the multi-part content format if it has any parts,
+// and the plain string content format otherwise, as not all servers support the former.
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	// The alias type does not inherit the MarshalJSON method, which prevents infinite recursion.
+	type plainMessage ChatMessage
+	if len(m.Parts) == 0 {
+		return json.Marshal(plainMessage(m))
+	}
+
+	parts := make([]ContentPart, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		parts = append(parts, ContentPart{Type: ContentTypeText, Text: m.Content})
+	}
+	parts = append(parts, m.Parts...)
+
+	return json.Marshal(struct {
+		plainMessage
+		Content []ContentPart `json:"content"`
+	}{plainMessage: plainMessage(m), Content: parts})
+}
+
+// UnmarshalJSON decodes a message in either the plain string or the multi-part content format.
+// In the latter case, all text parts are joined into Content and the rest are kept in Parts.
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	type plainMessage ChatMessage
+	var raw struct {
+		plainMessage
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = ChatMessage(raw.plainMessage)
+	// Content may be absent or null, for example, in tool call messages.
+	if len(raw.Content) == 0 || string(raw.Content) == "null" {
+		return nil
+	}
+
+	// Plain string content.
+	if raw.Content[0] == '"' {
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+
+	var parts []ContentPart
+	if err := json.Unmarshal(raw.Content, &parts); err != nil {
+		return err
+	}
+
+	var texts []string
+	for _, part := range parts {
+		if part.Type == ContentTypeText {
+			texts = append(texts, part.Text)
+			continue
+		}
+		m.Parts = append(m.Parts, part)
+	}
+	m.Content = strings.Join(texts, "\n")
+
+	return nil
+}
+
+// WithLogger makes the client report its activity to the given logger.
+//
+// Completed requests and retries are logged at the info level. Timing phases of
+// requests and the lifecycle of streams are logged at the debug level.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) { c.logger = logger }
+}
+
+// WithTransport makes the client perform its requests through the given transport,
+// for example to record or replay them.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) { c.httpClient.Transport = transport }
 }
 
 // NewClient returns a new Client instance.
-func NewClient(baseURL string) *Client {
-	return &Client{
-		baseURL:    baseURL,
-		httpClient: &httpx.RetryClient{Client: &http.Client{}},
+func NewClient(baseURL string, options ...ClientOption) *Client {
+	client := &Client{
+		baseURL:     baseURL,
+		httpClient:  &httpx.RetryClient{Client: &http.Client{}},
+		headers:     http.Header{},
+		maxAttempts: DefaultMaxAttempts,
+		retryDelay:  DefaultRetryDelay,
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		clock:       clock.Real,
+	}
+
+	for _, option := range options {
+		option(client)
+	}
+
+	// The dumps wrap whatever transport is used, so they show what goes over it, with every retry.
+	if client.debugWriter != nil {
+		next := client.httpClient.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		client.httpClient.Transport = &debugTransport{next: next, mu: &sync.Mutex{}, writer: client.debugWriter}
+	}
+
+	client.httpClient.Logger = client.logger
+	client.httpClient.Clock = client.clock
+	return client
+}
+
+// ChatOptions holds the optional parameters of a chat completion request.
+// The zero value leaves all of them to the server's defaults.
+type ChatOptions struct {
+	// ResponseFormat constrains the format of the output, for example, to a JSON schema.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
+	// Temperature and TopP control the randomness of the sampling. They are
+	// pointers because zero is a meaningful value for both.
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	// MaxTokens limits the number of tokens of the response.
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// N is the number of choices to generate. The deltas of all choices interleave in
+	// the stream, which SplitChoices splits into one stream per choice.
+	N int `json:"n,omitempty"`
+	// PresencePenalty and FrequencyPenalty discourage repeating tokens, between -2 and 2.
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	// Stop holds the sequences at which the model stops generating.
+	Stop []string `json:"stop,omitempty"`
+	// Seed makes the sampling deterministic, on a best-effort basis, for requests with the same seed and parameters.
+	Seed *int `json:"seed,omitempty"`
+	// User identifies the end user on whose behalf the request is made, which helps servers attribute abuse.
+	User string `json:"user,omitempty"`
+
+	// Logprobs makes the server return the log probabilities of the generated tokens, in the
+	// Logprobs of every choice. TopLogprobs, up to 20, also returns as many of the most likely
+	// alternatives at every position.
+	Logprobs    bool `json:"logprobs,omitempty"`
+	TopLogprobs int  `json:"top_logprobs,omitempty"`
+
+	// StreamOptions holds the options of the streamed response, like including the token usage.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+
+	// Tools holds the tools that the model may call.
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice controls which tool the model calls, if any. It is either "none",
+	// "auto" or "required", or an object like {"type": "function", "function": {"name": "f"}}.
+	ToolChoice any `json:"tool_choice,omitempty"`
+
+	// Extra holds additional fields of the request body, for the parameters that only some servers
+	// support, like "min_p", "repetition_penalty" or "grammar". They take precedence over the other fields.
+	Extra map[string]any `json:"-"`
+}
+
+// chatCompletionRequest is the request body of the /chat/completions API.
+// Marshalling a struct makes the JSON formation injection-proof.
+type chatCompletionRequest struct {
+	Stream   bool          `json:"stream"`
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	ChatOptions
+}
+
+// MarshalJSON encodes the request, adding the extra fields of its options.
+func (r chatCompletionRequest) MarshalJSON() ([]byte, error) {
+	// The alias type does not inherit the MarshalJSON method, which prevents infinite recursion.
+	type plainRequest chatCompletionRequest
+	body, err := json.Marshal(plainRequest(r))
+	if err != nil || len(r.Extra) == 0 {
+		return body, err
 	}
+
+	// Raw fields keep the encoding of the values, like of large integers.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range r.Extra {
+		if fields[key], err = json.Marshal(value); err != nil {
+			return nil, fmt.Errorf("failed to encode extra field %q: %w", key, err)
+		}
+	}
+	return json.Marshal(fields)
 }
 
 // ChatCompletionStream is a wrapper for the /chat/completions API with stream enabled.
 func (c *Client) ChatCompletionStream(
-	ctx context.Context, model string, messages []ChatMessage,
+	ctx context.Context, model string, messages []ChatMessage, options ChatOptions,
 ) (*streams.Stream[ChatCompletionEvent], error) {
-	// Form the API endpoint URL.
-	endpoint, err := url.JoinPath(c.baseURL, "v1/chat/completions")
+	requestBody := chatCompletionRequest{Stream: true, Model: model, Messages: messages, ChatOptions: options}
+
+	response, err := c.postJSON(ctx, "v1/chat/completions", requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to form API endpoint URL: %w", err)
+		return nil, err
+	}
+
+	// Start reading the events.
+	sseChan := httpx.ReadServerSentEvents(ctx, response.Body, c.clock)
+	// Relaying the events costs a little latency, so it's done only when they're going to be logged.
+	if c.logger.Enabled(ctx, slog.LevelDebug) {
+		sseChan = c.logStream(ctx, sseChan)
+	}
+	requestID := RequestID(response.Header)
+	return streams.Map(streams.New(sseChan), func(sse httpx.ServerSentEvent) ChatCompletionEvent {
+		event := convertSSE(sse)
+		event.requestID = requestID
+		return c.interceptEvent(event)
+	}), nil
+}
+
+// ChatCompletionText is like ChatCompletionStream, but consumes the stream and returns the assembled
+// response of the first choice, along with how it ended.
+func (c *Client) ChatCompletionText(
+	ctx context.Context, model string, messages []ChatMessage, options ChatOptions,
+) (ChatCompletionResult, error) {
+	stream, err := c.ChatCompletionStream(ctx, model, messages, options)
+	if err != nil {
+		return ChatCompletionResult{}, err
+	}
+
+	var result ChatCompletionResult
+	var content, reasoning strings.Builder
+	for {
+		event, ok, err := stream.NextContext(ctx)
+		if err != nil {
+			return ChatCompletionResult{}, err
+		}
+		if !ok {
+			break
+		}
+		if event.err != nil {
+			return ChatCompletionResult{}, event.err
+		}
+
+		result.Add(event)
+		for _, choice := range event.Choices {
+			if choice.Index != 0 {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			reasoning.WriteString(choice.Delta.ReasoningContent)
+			result.ToolCalls = MergeToolCalls(result.ToolCalls, choice.Delta.ToolCalls)
+		}
+	}
+
+	result.Content, result.ReasoningContent = content.String(), reasoning.String()
+	return result, nil
+}
+
+// SplitChoices splits the stream of a request with ChatOptions.N choices into n streams, one per choice index.
+// The events of every stream hold only the choice of its index. Events without choices, like the usage
+// event, and the events with errors, go to all streams.
+//
+// The streams may be consumed one after the other, or concurrently.
+func SplitChoices(stream *streams.Stream[ChatCompletionEvent], n int) []*streams.Stream[ChatCompletionEvent] {
+	return streams.Demux(stream, n, func(event ChatCompletionEvent, emit func(int, ChatCompletionEvent)) {
+		if len(event.Choices) == 0 || event.err != nil {
+			for i := 0; i < n; i++ {
+				emit(i, event)
+			}
+			return
+		}
+		for _, choice := range event.Choices {
+			choiceEvent := event
+			choiceEvent.Choices = []ChatCompletionChoice{choice}
+			emit(choice.Index, choiceEvent)
+		}
+	})
+}
+
+// Embeddings is a wrapper for the /embeddings API.
+// It returns one embedding vector per input, in the same order as the inputs.
+func (c *Client) Embeddings(ctx context.Context, model string, inputs []string) ([][]float64, error) {
+	requestBodyMap := map[string]any{"model": model, "input": inputs}
+
+	response, err := c.postJSON(ctx, "v1/embeddings", requestBodyMap)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	var responseBody EmbeddingsResponse
+	if err := json.NewDecoder(response.Body).Decode(&responseBody); err != nil {
+		return nil, fmt.Errorf("failed to decode API response body: %w", err)
+	}
+
+	if len(responseBody.Data) != len(inputs) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(inputs), len(responseBody.Data))
+	}
+
+	// The API may not return the embeddings in the input order, so use the index to place them.
+	vectors := make([][]float64, len(inputs))
+	for _, data := range responseBody.Data {
+		if data.Index < 0 || data.Index >= len(vectors) {
+			return nil, fmt.Errorf("embedding index out of range: %d", data.Index)
+		}
+		vectors[data.Index] = data.Embedding
+	}
+
+	return vectors, nil
+}
+
+// ListModels is a wrapper for the /models API.
+// It returns the models available at the API, sorted by ID.
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	response, err := c.do(ctx, http.MethodGet, "v1/models", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	var responseBody ModelsResponse
+	if err := json.NewDecoder(response.Body).Decode(&responseBody); err != nil {
+		return nil, fmt.Errorf("failed to decode API response body: %w", err)
+	}
+
+	models := responseBody.Data
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// Transcribe is a wrapper for the /audio/transcriptions API.
+// It uploads the audio file at the given path, and returns its transcription.
+func (c *Client) Transcribe(ctx context.Context, model, audioPath string) (string, error) {
+	audio, err := os.ReadFile(audioPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read audio file: %w", err)
+	}
+
+	// The body is formed in memory, so that it can be sent again on retries.
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+	_ = writer.WriteField("model", model)
+	_ = writer.WriteField("response_format", "json")
+	part, err := writer.CreateFormFile("file", filepath.Base(audioPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to form API request body: %w", err)
+	}
+	_, _ = part.Write(audio)
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to form API request body: %w", err)
 	}
 
-	// Create a map for marshalling. This makes the JSON formation injection-proof.
-	requestBodyMap := map[string]any{"stream": true, "model": model, "messages": messages}
-	requestBody, err := json.Marshal(requestBodyMap)
+	response, err := c.do(ctx, http.MethodPost, "v1/audio/transcriptions", writer.FormDataContentType(), requestBody.Bytes())
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	var responseBody TranscriptionResponse
+	if err := json.NewDecoder(response.Body).Decode(&responseBody); err != nil {
+		return "", fmt.Errorf("failed to decode API response body: %w", err)
+	}
+	return responseBody.Text, nil
+}
+
+// RateLimitInfo returns the rate limits reported by the last response that reported any,
+// and whether there was one.
+func (c *Client) RateLimitInfo() (RateLimitInfo, bool) {
+	c.rateLimitMu.Lock()
+	defer c.rateLimitMu.Unlock()
+	if c.rateLimit == nil {
+		return RateLimitInfo{}, false
+	}
+	return *c.rateLimit, true
+}
+
+// postJSON executes a POST request with the given body marshalled as JSON against the given API path.
+//
+// A non-nil response is returned only if the status code is 200.
+// In that case, the caller is responsible for closing the response body.
+func (c *Client) postJSON(ctx context.Context, path string, body any) (*http.Response, error) {
+	requestBody, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to form API request body: %w", err)
 	}
+	return c.do(ctx, http.MethodPost, path, "application/json", requestBody)
+}
+
+// do executes a request with the given method and body, of the given content type, against the
+// given API path. A nil body means there's no body, and then the content type is ignored.
+//
+// A non-nil response is returned only if the status code is 200.
+// In that case, the caller is responsible for closing the response body.
+func (c *Client) do(ctx context.Context, method, path, contentType string, requestBody []byte) (*http.Response, error) {
+	// Form the API endpoint URL. The query, if any, must not be escaped as part of the path.
+	path, query, _ := strings.Cut(path, "?")
+	endpoint, err := url.JoinPath(c.baseURL, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to form API endpoint URL: %w", err)
+	}
+	if query != "" {
+		endpoint += "?" + query
+	}
+
+	// Trace the timing phases of the request if they're going to be logged.
+	start := c.clock.Now()
+	if c.logger.Enabled(ctx, slog.LevelDebug) {
+		ctx = httptrace.WithClientTrace(ctx, c.requestTrace(start))
+	}
 
 	// Create the HTTP request.
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(requestBody))
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(requestBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	// Body is a JSON.
-	request.Header.Set("Content-Type", "application/json")
+	// Extra headers go first, so they can't override the essential ones.
+	for name, values := range c.headers {
+		request.Header[name] = values
+	}
+	if requestBody != nil {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		request.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
 	// Make the request retryable.
 	request.GetBody = func() (io.ReadCloser, error) {
 		return io.NopCloser(bytes.NewReader(requestBody)), nil
 	}
 
+	for _, interceptor := range c.interceptors {
+		if interceptor.OnRequest == nil {
+			continue
+		}
+		if err := interceptor.OnRequest(request); err != nil {
+			return nil, fmt.Errorf("request interceptor failed: %w", err)
+		}
+	}
+
+	if c.dryRunWriter != nil {
+		dumpRequest(c.dryRunWriter, request, requestBody)
+		return nil, ErrDryRun
+	}
+
 	// Execute request with retries.
-	response, err := c.httpClient.DoRetry(request, 20, time.Millisecond*50)
+	response, err := c.httpClient.DoRetry(request, c.maxAttempts, c.retryDelay)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
 	}
 
+	if info, ok := ParseRateLimitInfo(response.Header); ok {
+		c.rateLimitMu.Lock()
+		c.rateLimit = &info
+		c.rateLimitMu.Unlock()
+	}
+
+	for _, interceptor := range c.interceptors {
+		if interceptor.OnResponse != nil {
+			interceptor.OnResponse(response)
+		}
+	}
+
+	c.logger.Info("request completed", "url", endpoint, "status", response.StatusCode,
+		"request_id", RequestID(response.Header), "elapsed", c.clock.Now().Sub(start))
+
 	// In case of error, return the status code with the body.
 	if response.StatusCode != http.StatusOK {
 		defer func() { _ = response.Body.Close() }()
@@ -78,12 +733,79 @@ func (c *Client) ChatCompletionStream(
 		if err != nil {
 			responseBody = []byte("failed to read response body: " + err.Error())
 		}
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", response.StatusCode, string(responseBody))
+		apiErr := newAPIError(response.StatusCode, string(responseBody))
+		apiErr.RequestID = RequestID(response.Header)
+		return nil, apiErr
 	}
 
-	// Start reading the events.
-	sseChan := httpx.ReadServerSentEvents(ctx, response.Body)
-	return streams.Map(streams.New(sseChan), convertSSE), nil
+	return response, nil
+}
+
+// requestTrace returns an HTTP trace that logs the timing phases of a request started at the given time.
+func (c *Client) requestTrace(start time.Time) *httptrace.ClientTrace {
+	phase := func(name string, args ...any) {
+		c.logger.Debug("request phase: "+name, append(args, "elapsed", c.clock.Now().Sub(start))...)
+	}
+
+	return &httptrace.ClientTrace{
+		DNSDone:              func(httptrace.DNSDoneInfo) { phase("dns lookup done") },
+		ConnectDone:          func(_, addr string, _ error) { phase("connected", "addr", addr) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { phase("tls handshake done") },
+		GotConn:              func(info httptrace.GotConnInfo) { phase("got connection", "reused", info.Reused) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { phase("request written") },
+		GotFirstResponseByte: func() { phase("first response byte") },
+	}
+}
+
+// interceptEvent passes the given stream event through the interceptors, unless it failed to be read.
+func (c *Client) interceptEvent(event ChatCompletionEvent) ChatCompletionEvent {
+	if event.err != nil {
+		return event
+	}
+	for _, interceptor := range c.interceptors {
+		if interceptor.OnStreamEvent != nil {
+			interceptor.OnStreamEvent(&event)
+		}
+	}
+	return event
+}
+
+// logStream relays the given events to the returned channel, logging the lifecycle of the stream.
+// It stops relaying once the context is canceled, as the events may not be read anymore.
+func (c *Client) logStream(ctx context.Context, sseChan <-chan httpx.ServerSentEvent) <-chan httpx.ServerSentEvent {
+	relayChan := make(chan httpx.ServerSentEvent, cap(sseChan))
+
+	go func() {
+		defer close(relayChan)
+
+		start := c.clock.Now()
+		c.logger.Debug("stream opened")
+
+		var count int
+		var errFinal error
+		for event := range sseChan {
+			if count == 0 {
+				c.logger.Debug("stream received first event", "elapsed", c.clock.Now().Sub(start))
+			}
+			if event.Error != nil {
+				errFinal = event.Error
+			}
+			count++
+
+			select {
+			case relayChan <- event:
+			case <-ctx.Done():
+				// The rest of the events are drained so that the reader of the body is not blocked.
+				for range sseChan {
+				}
+				errFinal = ctx.Err()
+			}
+		}
+
+		c.logger.Debug("stream closed", "events", count, "elapsed", c.clock.Now().Sub(start), "error", errFinal)
+	}()
+
+	return relayChan
 }
 
 // convertSSE converts the given Server-Sent Event to a ChatCompletionEvent type.
@@ -1,12 +1,20 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -81,7 +89,7 @@ data: [DONE]`
 				},
 			},
 			ctx:         context.Background(),
-			expectedErr: errors.New("unexpected status code: 400"),
+			expectedErr: errors.New("API error (status 400): bad request"),
 		},
 		{
 			name:    "Network Error from HTTP Client",
@@ -135,7 +143,7 @@ data: {"choices":` // Malformed JSON
 			}
 
 			// Execution: Call the method under test.
-			stream, err := client.ChatCompletionStream(tc.ctx, "test-model", nil)
+			stream, err := client.ChatCompletionStream(tc.ctx, "test-model", nil, ChatOptions{})
 
 			// Assertion for the function's direct return value.
 			if tc.expectedErr != nil {
@@ -185,6 +193,58 @@ func Test_convertSSE(t *testing.T) {
 		assert.Equal(t, " test ", event.Choices[0].Delta.Content)
 	})
 
+	t.Run("SSE with Tool Calls", func(t *testing.T) {
+		sse := httpx.ServerSentEvent{Value: `{"choices":[{"delta":{"tool_calls":[
+			{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}
+		]}}]}`}
+		event := convertSSE(sse)
+		assert.NoError(t, event.err)
+		require.Len(t, event.Choices, 1)
+		assert.Equal(t, []ToolCallDelta{{
+			Index: 0, ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather"},
+		}}, event.Choices[0].Delta.ToolCalls)
+	})
+
+	t.Run("SSE with Reasoning", func(t *testing.T) {
+		for _, field := range []string{"reasoning_content", "reasoning"} {
+			sse := httpx.ServerSentEvent{Value: `{"choices":[{"delta":{"content":"","` + field + `":"Let me think."}}]}`}
+			event := convertSSE(sse)
+			assert.NoError(t, event.err)
+			require.Len(t, event.Choices, 1)
+			assert.Equal(t, "Let me think.", event.Choices[0].Delta.ReasoningContent, field)
+		}
+	})
+
+	t.Run("SSE with Usage", func(t *testing.T) {
+		sse := httpx.ServerSentEvent{Value: `{"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12}}`}
+		event := convertSSE(sse)
+		assert.NoError(t, event.err)
+		assert.Equal(t, &Usage{PromptTokens: 5, CompletionTokens: 7, TotalTokens: 12}, event.Usage)
+
+		tokens, ok := event.CompletionTokens()
+		assert.True(t, ok)
+		assert.Equal(t, 7, tokens)
+
+		// Other events don't report the completion tokens.
+		_, ok = convertSSE(httpx.ServerSentEvent{Value: `{"choices":[{"delta":{"content":"hi"}}]}`}).CompletionTokens()
+		assert.False(t, ok)
+	})
+
+	t.Run("SSE with Logprobs", func(t *testing.T) {
+		sse := httpx.ServerSentEvent{Value: `{"choices":[{"delta":{"content":"Hi"},"logprobs":{"content":[
+			{"token":"Hi","logprob":0,"bytes":[72,105],"top_logprobs":[{"token":"Hi","logprob":0},{"token":"Hey","logprob":-9.5}]}
+		]}}]}`}
+		event := convertSSE(sse)
+		assert.NoError(t, event.err)
+		require.Len(t, event.Choices, 1)
+		require.NotNil(t, event.Choices[0].Logprobs)
+		assert.Equal(t, []TokenLogprob{{
+			Token: "Hi", Logprob: 0, Bytes: []int{72, 105},
+			TopLogprobs: []TopLogprob{{Token: "Hi", Logprob: 0}, {Token: "Hey", Logprob: -9.5}},
+		}}, event.Choices[0].Logprobs.Content)
+		assert.Equal(t, 1.0, event.Choices[0].Logprobs.Content[0].Probability())
+	})
+
 	t.Run("SSE with Error", func(t *testing.T) {
 		expectedErr := errors.New("read error")
 		sse := httpx.ServerSentEvent{Error: expectedErr}
@@ -199,3 +259,576 @@ func Test_convertSSE(t *testing.T) {
 		assert.Contains(t, event.err.Error(), "failed to unmarshal")
 	})
 }
+
+// TestClient_ChatCompletionText verifies that the stream is assembled into the response of the first choice.
+func TestClient_ChatCompletionText(t *testing.T) {
+	newClientWithBody := func(body string) *Client {
+		return NewClient("http://localhost:8080", WithTransport(&mockRoundTripper{
+			responseFunc: func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+			},
+		}))
+	}
+
+	client := newClientWithBody(strings.Join([]string{
+		`data: {"choices":[{"index":0,"delta":{"reasoning_content":"Hmm."}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"content":"Hello"}},{"index":1,"delta":{"content":"Other"}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"content":", world!"},"finish_reason":"length"}]}`,
+		`data: {"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`,
+		`data: [DONE]`,
+	}, "\n"))
+	result, err := client.ChatCompletionText(context.Background(), "test-model", nil, ChatOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, ChatCompletionResult{
+		Content:          "Hello, world!",
+		ReasoningContent: "Hmm.",
+		StreamResult: StreamResult{
+			FinishReason: FinishReasonLength,
+			Usage:        &Usage{PromptTokens: 5, CompletionTokens: 3, TotalTokens: 8},
+		},
+	}, result)
+
+	// A malformed event fails the whole response.
+	client = newClientWithBody("data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\ndata: {oops}\n")
+	_, err = client.ChatCompletionText(context.Background(), "test-model", nil, ChatOptions{})
+	assert.ErrorContains(t, err, "failed to unmarshal server-sent event")
+}
+
+// TestStreamResult verifies that the end of a stream is recorded from its events.
+func TestStreamResult(t *testing.T) {
+	var result StreamResult
+	for _, value := range []string{
+		`{"choices":[{"index":0,"delta":{"refusal":"I can't"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"refusal":" help with that."}},{"index":1,"finish_reason":"stop"}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"content_filter"}]}`,
+	} {
+		event := convertSSE(httpx.ServerSentEvent{Value: value})
+		require.NoError(t, event.err)
+		result.Add(event)
+	}
+
+	assert.Equal(t, StreamResult{FinishReason: FinishReasonContentFilter, Refusal: "I can't help with that."}, result)
+}
+
+// TestSplitChoices verifies that the interleaved deltas of several choices are split into one stream per choice.
+func TestSplitChoices(t *testing.T) {
+	var requestBody map[string]any
+	client := NewClient("http://localhost:8080", WithTransport(&mockRoundTripper{
+		responseFunc: func(r *http.Request) (*http.Response, error) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+			body := strings.Join([]string{
+				`data: {"choices":[{"index":0,"delta":{"content":"A"}},{"index":1,"delta":{"content":"X"}}]}`,
+				`data: {"choices":[{"index":1,"delta":{"content":"Y"}}]}`,
+				`data: {"choices":[{"index":0,"delta":{"content":"B"},"finish_reason":"stop"}]}`,
+				`data: {"choices":[{"index":1,"delta":{},"finish_reason":"length"}]}`,
+				`data: {"choices":[],"usage":{"total_tokens":9}}`,
+				`data: [DONE]`,
+			}, "\n")
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+		},
+	}))
+
+	stream, err := client.ChatCompletionStream(context.Background(), "test-model", nil, ChatOptions{N: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, requestBody["n"])
+
+	choices := SplitChoices(stream, 2)
+	require.Len(t, choices, 2)
+
+	// The second choice is consumed first.
+	expected := []struct {
+		content string
+		result  StreamResult
+	}{
+		{content: "AB", result: StreamResult{FinishReason: FinishReasonStop, Usage: &Usage{TotalTokens: 9}}},
+		{content: "XY", result: StreamResult{Index: 1, FinishReason: FinishReasonLength, Usage: &Usage{TotalTokens: 9}}},
+	}
+	for _, i := range []int{1, 0} {
+		events, err := choices[i].Drain(context.Background())
+		require.NoError(t, err)
+
+		var content strings.Builder
+		result := StreamResult{Index: i}
+		for _, event := range events {
+			for _, choice := range event.Choices {
+				assert.Equal(t, i, choice.Index)
+				content.WriteString(choice.Delta.Content)
+			}
+			result.Add(event)
+		}
+		assert.Equal(t, expected[i].content, content.String())
+		assert.Equal(t, expected[i].result, result)
+	}
+}
+
+// TestClient_Embeddings verifies the request and response handling of the Embeddings API.
+func TestClient_Embeddings(t *testing.T) {
+	t.Run("Embeddings Ordered by Index", func(t *testing.T) {
+		client := NewClient("http://localhost:8080")
+		client.httpClient = &httpx.RetryClient{Client: &http.Client{Transport: &mockRoundTripper{
+			responseFunc: func(r *http.Request) (*http.Response, error) {
+				assert.Equal(t, "/v1/embeddings", r.URL.Path)
+				// Return the embeddings out of order.
+				body := `{"data":[{"index":1,"embedding":[0.3,0.4]},{"index":0,"embedding":[0.1,0.2]}]}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+			},
+		}}}
+
+		vectors, err := client.Embeddings(context.Background(), "test-model", []string{"a", "b"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]float64{{0.1, 0.2}, {0.3, 0.4}}, vectors)
+	})
+
+	t.Run("Mismatched Embedding Count", func(t *testing.T) {
+		client := NewClient("http://localhost:8080")
+		client.httpClient = &httpx.RetryClient{Client: &http.Client{Transport: &mockRoundTripper{
+			responseFunc: func(r *http.Request) (*http.Response, error) {
+				body := `{"data":[{"index":0,"embedding":[0.1,0.2]}]}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+			},
+		}}}
+
+		vectors, err := client.Embeddings(context.Background(), "test-model", []string{"a", "b"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected 2 embeddings, got 1")
+		assert.Nil(t, vectors)
+	})
+}
+
+// TestClient_Transcribe verifies the multipart upload and the response handling of the Transcriptions API.
+func TestClient_Transcribe(t *testing.T) {
+	audioPath := filepath.Join(t.TempDir(), "speech.wav")
+	require.NoError(t, os.WriteFile(audioPath, []byte("RIFF audio"), 0o600))
+
+	client := NewClient("http://localhost:8080")
+	client.httpClient = &httpx.RetryClient{Client: &http.Client{Transport: &mockRoundTripper{
+		responseFunc: func(r *http.Request) (*http.Response, error) {
+			assert.Equal(t, "/v1/audio/transcriptions", r.URL.Path)
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			assert.Equal(t, "whisper-1", r.FormValue("model"))
+
+			file, header, err := r.FormFile("file")
+			require.NoError(t, err)
+			content, err := io.ReadAll(file)
+			require.NoError(t, err)
+			assert.Equal(t, "speech.wav", header.Filename)
+			assert.Equal(t, "RIFF audio", string(content))
+
+			body := `{"text":"Hello there."}`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+		},
+	}}}
+
+	text, err := client.Transcribe(context.Background(), "whisper-1", audioPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello there.", text)
+
+	_, err = client.Transcribe(context.Background(), "whisper-1", filepath.Join(t.TempDir(), "missing.wav"))
+	assert.ErrorContains(t, err, "failed to read audio file")
+}
+
+// TestClient_ListModels verifies the request and response handling of the Models API.
+func TestClient_ListModels(t *testing.T) {
+	client := NewClient("http://localhost:8080", WithAPIKey("secret"))
+	client.httpClient = &httpx.RetryClient{Client: &http.Client{Transport: &mockRoundTripper{
+		responseFunc: func(r *http.Request) (*http.Response, error) {
+			assert.Equal(t, http.MethodGet, r.Method)
+			assert.Equal(t, "/v1/models", r.URL.Path)
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			assert.Empty(t, r.Header.Get("Content-Type"), "A request without a body has no content type.")
+
+			body := `{"object":"list","data":[{"id":"model-b","owned_by":"b"},{"id":"model-a","owned_by":"a"}]}`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+		},
+	}}}
+
+	models, err := client.ListModels(context.Background())
+	require.NoError(t, err)
+	require.Len(t, models, 2)
+	assert.Equal(t, "model-a", models[0].ID, "Models should be sorted by ID.")
+	assert.Equal(t, "b", models[1].OwnedBy)
+}
+
+// TestMergeToolCalls verifies that streamed tool call fragments are assembled into complete calls.
+func TestMergeToolCalls(t *testing.T) {
+	deltas := [][]ToolCallDelta{
+		{{Index: 0, ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather"}}},
+		{{Index: 0, Function: FunctionCall{Arguments: `{"city":`}}},
+		{{Index: 0, Function: FunctionCall{Arguments: `"Paris"}`}}},
+		{{Index: 1, ID: "call_2", Type: "function", Function: FunctionCall{Name: "get_time", Arguments: `{}`}}},
+	}
+
+	var calls []ToolCall
+	for _, delta := range deltas {
+		calls = MergeToolCalls(calls, delta)
+	}
+
+	assert.Equal(t, []ToolCall{
+		{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+		{ID: "call_2", Type: "function", Function: FunctionCall{Name: "get_time", Arguments: `{}`}},
+	}, calls)
+}
+
+// TestMergeToolCalls_InvalidIndex verifies that fragments with invalid indices, sent by faulty servers, are skipped.
+func TestMergeToolCalls_InvalidIndex(t *testing.T) {
+	calls := []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "now"}}}
+
+	testCases := []struct {
+		name  string
+		index int
+	}{
+		{name: "Negative Index", index: -1},
+		{name: "Huge Index", index: 1 << 30},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			merged := MergeToolCalls(slices.Clone(calls), []ToolCallDelta{{Index: tc.index, ID: "call_2"}})
+			assert.Equal(t, calls, merged)
+		})
+	}
+}
+
+// TestChatMessage_JSON verifies that messages are encoded in the right content format and
+// that both formats can be decoded.
+func TestChatMessage_JSON(t *testing.T) {
+	t.Run("Plain Content", func(t *testing.T) {
+		message := ChatMessage{Role: RoleUser, Content: "hello"}
+		encoded, err := json.Marshal(message)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"role":"user","content":"hello"}`, string(encoded))
+
+		var decoded ChatMessage
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, message, decoded)
+	})
+
+	t.Run("Multi-Part Content", func(t *testing.T) {
+		message := ChatMessage{
+			Role:    RoleUser,
+			Content: "what is this?",
+			Parts:   []ContentPart{NewImagePart("data:image/png;base64,AAAA")},
+		}
+		encoded, err := json.Marshal(message)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"role":"user","content":[
+			{"type":"text","text":"what is this?"},
+			{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}
+		]}`, string(encoded))
+
+		var decoded ChatMessage
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, message, decoded)
+	})
+
+	t.Run("Tool Calls", func(t *testing.T) {
+		messages := []ChatMessage{
+			{Role: RoleAssistant, ToolCalls: []ToolCall{
+				{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_time", Arguments: "{}"}},
+			}},
+			{Role: RoleTool, Content: "12:00", ToolCallID: "call_1"},
+		}
+		encoded, err := json.Marshal(messages)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[
+			{"role":"assistant","content":"","tool_calls":[
+				{"id":"call_1","type":"function","function":{"name":"get_time","arguments":"{}"}}
+			]},
+			{"role":"tool","content":"12:00","tool_call_id":"call_1"}
+		]`, string(encoded))
+
+		var decoded []ChatMessage
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, messages, decoded)
+	})
+}
+
+// TestClient_ChatCompletionStream_Options verifies that the chat options are sent in the request body.
+func TestClient_ChatCompletionStream_Options(t *testing.T) {
+	var requestBody map[string]any
+
+	client := NewClient("http://localhost:8080")
+	client.httpClient = &httpx.RetryClient{Client: &http.Client{Transport: &mockRoundTripper{
+		responseFunc: func(r *http.Request) (*http.Response, error) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		},
+	}}}
+
+	temperature, penalty, seed := 0.0, 0.5, 0
+	options := ChatOptions{
+		ResponseFormat: &ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &JSONSchemaFormat{Name: "answer", Schema: json.RawMessage(`{"type":"object"}`)},
+		},
+		Temperature:     &temperature,
+		MaxTokens:       100,
+		PresencePenalty: &penalty,
+		Stop:            []string{"\n\n"},
+		Seed:            &seed,
+		User:            "user-42",
+		Tools:           []Tool{NewFunctionTool("get_time", "Get the time.", json.RawMessage(`{"type":"object"}`))},
+		ToolChoice:      "auto",
+		StreamOptions:   &StreamOptions{IncludeUsage: true},
+		Logprobs:        true,
+		TopLogprobs:     3,
+		Extra:           map[string]any{"min_p": 0.05, "grammar": "root ::= [a-z]+", "max_tokens": 200},
+	}
+
+	stream, err := client.ChatCompletionStream(context.Background(), "test-model", nil, options)
+	require.NoError(t, err)
+	_, _ = stream.Drain(context.Background())
+
+	assert.Equal(t, true, requestBody["stream"])
+	assert.Equal(t, "test-model", requestBody["model"])
+	assert.Equal(t, map[string]any{
+		"type":        "json_schema",
+		"json_schema": map[string]any{"name": "answer", "schema": map[string]any{"type": "object"}},
+	}, requestBody["response_format"])
+
+	// A zero temperature is sent, while unset options are left out.
+	assert.Equal(t, 0.0, requestBody["temperature"])
+	assert.Equal(t, 200.0, requestBody["max_tokens"], "Extra fields take precedence.")
+	assert.Equal(t, 0.5, requestBody["presence_penalty"])
+	assert.Equal(t, []any{"\n\n"}, requestBody["stop"])
+	assert.Equal(t, 0.0, requestBody["seed"])
+	assert.Equal(t, "user-42", requestBody["user"])
+	assert.Equal(t, []any{map[string]any{"type": "function", "function": map[string]any{
+		"name": "get_time", "description": "Get the time.", "parameters": map[string]any{"type": "object"},
+	}}}, requestBody["tools"])
+	assert.Equal(t, "auto", requestBody["tool_choice"])
+	assert.Equal(t, map[string]any{"include_usage": true}, requestBody["stream_options"])
+	assert.Equal(t, true, requestBody["logprobs"])
+	assert.Equal(t, 3.0, requestBody["top_logprobs"])
+	assert.Equal(t, 0.05, requestBody["min_p"])
+	assert.Equal(t, "root ::= [a-z]+", requestBody["grammar"])
+	assert.NotContains(t, requestBody, "top_p")
+	assert.NotContains(t, requestBody, "frequency_penalty")
+	assert.NotContains(t, requestBody, "Extra")
+}
+
+// TestWithInterceptor verifies that the interceptors are called for the request, the response, and every stream event.
+func TestWithInterceptor(t *testing.T) {
+	var calls []string
+	client := NewClient("http://localhost:8080",
+		WithInterceptor(Interceptor{
+			OnRequest: func(request *http.Request) error {
+				calls = append(calls, "request")
+				request.Header.Set("X-Trace-Id", "trace-1")
+				return nil
+			},
+			OnStreamEvent: func(event *ChatCompletionEvent) {
+				calls = append(calls, "event")
+				event.Choices[0].Delta.Content = strings.ToUpper(event.Choices[0].Delta.Content)
+			},
+		}),
+		WithInterceptor(Interceptor{
+			OnResponse: func(response *http.Response) { calls = append(calls, fmt.Sprint("response ", response.StatusCode)) },
+		}),
+	)
+	client.httpClient = &httpx.RetryClient{Client: &http.Client{Transport: &mockRoundTripper{
+		responseFunc: func(r *http.Request) (*http.Response, error) {
+			assert.Equal(t, "trace-1", r.Header.Get("X-Trace-Id"))
+			body := "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\ndata: [DONE]\n"
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"X-Request-Id": {"req_123"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}, nil
+		},
+	}}}
+
+	stream, err := client.ChatCompletionStream(context.Background(), "test-model", nil, ChatOptions{})
+	require.NoError(t, err)
+	events, err := stream.Drain(context.Background())
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "HI", events[0].Choices[0].Delta.Content)
+	assert.Equal(t, "req_123", events[0].RequestID())
+	assert.Equal(t, []string{"request", "response 200", "event"}, calls)
+
+	// An error of OnRequest aborts the request.
+	client = NewClient("http://localhost:8080", WithInterceptor(Interceptor{
+		OnRequest: func(*http.Request) error { return errors.New("denied") },
+	}))
+	_, err = client.ListModels(context.Background())
+	assert.ErrorContains(t, err, "request interceptor failed: denied")
+}
+
+// TestNewClient_Options verifies that the client options are applied to every request.
+func TestNewClient_Options(t *testing.T) {
+	var attempts int
+	var lastRequest *http.Request
+
+	client := NewClient("http://localhost:8080",
+		WithAPIKey("secret"),
+		WithHeaders(map[string]string{"X-Tenant": "acme", "Content-Type": "text/plain"}),
+		WithOrganization("org-1"),
+		WithProject("proj-1"),
+		WithRetry(2, time.Millisecond),
+	)
+	client.httpClient = &httpx.RetryClient{Client: &http.Client{Transport: &mockRoundTripper{
+		responseFunc: func(r *http.Request) (*http.Response, error) {
+			attempts++
+			lastRequest = r
+			return nil, errors.New("connection refused")
+		},
+	}}}
+
+	_, err := client.ChatCompletionStream(context.Background(), "test-model", nil, ChatOptions{})
+	require.Error(t, err)
+
+	assert.Equal(t, 2, attempts, "The configured number of attempts should be made.")
+	assert.Equal(t, "Bearer secret", lastRequest.Header.Get("Authorization"))
+	assert.Equal(t, "acme", lastRequest.Header.Get("X-Tenant"))
+	assert.Equal(t, "org-1", lastRequest.Header.Get("OpenAI-Organization"))
+	assert.Equal(t, "proj-1", lastRequest.Header.Get("OpenAI-Project"))
+	assert.Equal(t, "application/json", lastRequest.Header.Get("Content-Type"), "Essential headers must not be overridden.")
+}
+
+// TestWithLogger verifies that requests and the lifecycle of streams are logged.
+func TestWithLogger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client := NewClient("http://localhost:8080", WithLogger(logger))
+	client.httpClient = &httpx.RetryClient{Client: &http.Client{Transport: &mockRoundTripper{
+		responseFunc: func(r *http.Request) (*http.Response, error) {
+			body := "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n"
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"X-Request-Id": []string{"req-123"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}, nil
+		},
+	}}}
+
+	stream, err := client.ChatCompletionStream(context.Background(), "test-model", nil, ChatOptions{})
+	require.NoError(t, err)
+	_, err = stream.Drain(context.Background())
+	require.NoError(t, err)
+
+	output := logs.String()
+	assert.Contains(t, output, `msg="request completed"`)
+	assert.Contains(t, output, "request_id=req-123")
+	assert.Contains(t, output, `msg="stream opened"`)
+	assert.Contains(t, output, `msg="stream closed" events=`)
+}
+
+// TestClient_logStream_Cancel verifies that the relay of a logged stream ends once the context is canceled,
+// even if the events are not read anymore.
+func TestClient_logStream_Cancel(t *testing.T) {
+	client := NewClient("http://localhost:8080", WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The events are unbuffered, so that every send waits for the relay.
+	sseChan := make(chan httpx.ServerSentEvent)
+	relayChan := client.logStream(ctx, sseChan)
+
+	sseChan <- httpx.ServerSentEvent{Index: 0}
+	assert.Equal(t, 0, (<-relayChan).Index)
+
+	// The second event is taken by the relay, but never read.
+	sseChan <- httpx.ServerSentEvent{Index: 1}
+	cancel()
+
+	// The relay must keep draining the events, and close its channel once they end.
+	select {
+	case sseChan <- httpx.ServerSentEvent{Index: 2}:
+	case <-time.After(time.Second):
+		require.Fail(t, "The events were not drained after the cancellation.")
+	}
+	close(sseChan)
+
+	select {
+	case _, ok := <-relayChan:
+		assert.False(t, ok, "No events should be relayed after the cancellation.")
+	case <-time.After(time.Second):
+		require.Fail(t, "The relay did not end after the cancellation.")
+	}
+}
+
+// TestAPIError verifies that a non-200 response is returned as an APIError.
+func TestAPIError(t *testing.T) {
+	// The status is retryable, but the retries are tested along with the RetryClient.
+	client := NewClient("http://localhost:8080", WithRetry(1, 0))
+	client.httpClient = &httpx.RetryClient{Client: &http.Client{Transport: &mockRoundTripper{
+		responseFunc: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header: http.Header{
+					"X-Request-Id":                   {"req_123"},
+					"X-Ratelimit-Remaining-Requests": {"0"},
+				},
+				Body: io.NopCloser(strings.NewReader("slow down")),
+			}, nil
+		},
+	}}}
+
+	_, err := client.ChatCompletionStream(context.Background(), "test-model", nil, ChatOptions{})
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, "slow down", apiErr.Body)
+	assert.Equal(t, "req_123", apiErr.RequestID)
+
+	// The rate limits are recorded from failed responses too.
+	info, ok := client.RateLimitInfo()
+	require.True(t, ok)
+	assert.Equal(t, &RateLimit{Remaining: 0}, info.Requests)
+	assert.Equal(t, "unexpected status code: 429, body: slow down (request ID: req_123)", apiErr.Error())
+}
+
+// TestRequestID verifies that the request ID is found in the common header, or in the ones of providers.
+func TestRequestID(t *testing.T) {
+	assert.Equal(t, "req_1", RequestID(http.Header{"X-Request-Id": {"req_1"}, "Request-Id": {"req_2"}}))
+	assert.Equal(t, "req_2", RequestID(http.Header{"Request-Id": {"req_2"}}))
+	assert.Equal(t, "", RequestID(http.Header{}))
+}
+
+// TestNewAPIError verifies that the standard error bodies are parsed, and that other bodies are kept as they are.
+func TestNewAPIError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected APIError
+		message  string
+	}{
+		{
+			name: "OpenAI error object",
+			body: `{"error": {"message": "Rate limit reached.", "type": "requests", "code": "rate_limit_exceeded"}}`,
+			expected: APIError{
+				StatusCode: 429, Code: "rate_limit_exceeded", Type: "requests", Message: "Rate limit reached.",
+			},
+			message: "API error (status 429, code rate_limit_exceeded): Rate limit reached.",
+		},
+		{
+			name:     "Numeric code at the top level",
+			body:     `{"object": "error", "message": "Model not found.", "type": "NotFoundError", "code": 404}`,
+			expected: APIError{StatusCode: 429, Code: "404", Type: "NotFoundError", Message: "Model not found."},
+			message:  "API error (status 429, code 404): Model not found.",
+		},
+		{
+			name:     "Error message only",
+			body:     `{"error": "Model is loading."}`,
+			expected: APIError{StatusCode: 429, Message: "Model is loading."},
+			message:  "API error (status 429): Model is loading.",
+		},
+		{
+			name:     "Plain text",
+			body:     "slow down",
+			expected: APIError{StatusCode: 429},
+			message:  "unexpected status code: 429, body: slow down",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			apiErr := newAPIError(http.StatusTooManyRequests, tc.body)
+			tc.expected.Body = tc.body
+			assert.Equal(t, tc.expected, *apiErr)
+			assert.Equal(t, tc.message, apiErr.Error())
+		})
+	}
+}
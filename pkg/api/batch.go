@@ -0,0 +1,201 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// BatchCompletionWindow is the time frame within which a batch is processed. It is the only one the API supports.
+const BatchCompletionWindow = "24h"
+
+// FilePurposeBatch is the purpose of the files that hold the requests of batches.
+const FilePurposeBatch = "batch"
+
+// The statuses of a batch.
+const (
+	BatchStatusValidating = "validating"
+	BatchStatusFailed     = "failed"
+	BatchStatusInProgress = "in_progress"
+	BatchStatusFinalizing = "finalizing"
+	BatchStatusCompleted  = "completed"
+	BatchStatusExpired    = "expired"
+	BatchStatusCancelling = "cancelling"
+	BatchStatusCancelled  = "cancelled"
+)
+
+// Batch is a group of requests that the API processes asynchronously, within the completion window.
+type Batch struct {
+	ID       string `json:"id"`
+	Object   string `json:"object"`
+	Endpoint string `json:"endpoint"`
+	// Status is one of the BatchStatus constants.
+	Status           string `json:"status"`
+	CompletionWindow string `json:"completion_window"`
+
+	// InputFileID is the ID of the file of the requests. OutputFileID and ErrorFileID are the IDs of the files
+	// of the successful responses and of the errors, once the batch is done.
+	InputFileID  string `json:"input_file_id"`
+	OutputFileID string `json:"output_file_id,omitempty"`
+	ErrorFileID  string `json:"error_file_id,omitempty"`
+
+	// The Unix timestamps of the life cycle of the batch. Those of the steps that haven't happened are zero.
+	CreatedAt   int64 `json:"created_at"`
+	CompletedAt int64 `json:"completed_at,omitempty"`
+	ExpiresAt   int64 `json:"expires_at,omitempty"`
+
+	RequestCounts BatchRequestCounts `json:"request_counts"`
+	Metadata      map[string]string  `json:"metadata,omitempty"`
+}
+
+// Done tells whether the batch reached a final status, after which it doesn't change anymore.
+func (b Batch) Done() bool {
+	switch b.Status {
+	case BatchStatusFailed, BatchStatusCompleted, BatchStatusExpired, BatchStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// BatchRequestCounts holds the number of requests of a batch by their state.
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// BatchList is a page of batches, from the most recent.
+type BatchList struct {
+	Data []Batch `json:"data"`
+	// HasMore tells whether there are older batches, which are listed after LastID.
+	HasMore bool   `json:"has_more"`
+	LastID  string `json:"last_id"`
+}
+
+// File is a file uploaded to the API.
+type File struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Purpose  string `json:"purpose"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// UploadFile is a wrapper for the /files API.
+// It uploads the file at the given path for the given purpose, like FilePurposeBatch.
+func (c *Client) UploadFile(ctx context.Context, path, purpose string) (File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// The body is formed in memory, so that it can be sent again on retries.
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+	_ = writer.WriteField("purpose", purpose)
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return File{}, fmt.Errorf("failed to form API request body: %w", err)
+	}
+	_, _ = part.Write(content)
+	if err := writer.Close(); err != nil {
+		return File{}, fmt.Errorf("failed to form API request body: %w", err)
+	}
+
+	response, err := c.do(ctx, http.MethodPost, "v1/files", writer.FormDataContentType(), requestBody.Bytes())
+	if err != nil {
+		return File{}, err
+	}
+	return decodeResponse[File](response)
+}
+
+// FileContent is a wrapper for the /files/{id}/content API. It returns the content of the file
+// with the given ID, like the output of a batch, which the caller must close.
+func (c *Client) FileContent(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	response, err := c.do(ctx, http.MethodGet, "v1/files/"+url.PathEscape(fileID)+"/content", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// CreateBatch uploads the JSONL file of requests at the given path, and creates a batch of them for the
+// given endpoint, like "/v1/chat/completions". Every line of the file is a request, like
+// {"custom_id": "1", "method": "POST", "url": "/v1/chat/completions", "body": {"model": "gpt-4.1", ...}}.
+func (c *Client) CreateBatch(ctx context.Context, requestsPath, endpoint string) (Batch, error) {
+	file, err := c.UploadFile(ctx, requestsPath, FilePurposeBatch)
+	if err != nil {
+		return Batch{}, fmt.Errorf("failed to upload requests file: %w", err)
+	}
+
+	requestBody := map[string]any{
+		"input_file_id":     file.ID,
+		"endpoint":          endpoint,
+		"completion_window": BatchCompletionWindow,
+	}
+	response, err := c.postJSON(ctx, "v1/batches", requestBody)
+	if err != nil {
+		return Batch{}, err
+	}
+	return decodeResponse[Batch](response)
+}
+
+// GetBatch is a wrapper for the /batches/{id} API. It returns the batch with the given ID.
+func (c *Client) GetBatch(ctx context.Context, batchID string) (Batch, error) {
+	response, err := c.do(ctx, http.MethodGet, "v1/batches/"+url.PathEscape(batchID), "", nil)
+	if err != nil {
+		return Batch{}, err
+	}
+	return decodeResponse[Batch](response)
+}
+
+// ListBatches is a wrapper for the /batches API. It returns up to the given number of batches, from the
+// most recent, after the batch with the given ID, if any. A limit of zero leaves it to the server.
+func (c *Client) ListBatches(ctx context.Context, after string, limit int) (BatchList, error) {
+	query := url.Values{}
+	if after != "" {
+		query.Set("after", after)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	path := "v1/batches"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	response, err := c.do(ctx, http.MethodGet, path, "", nil)
+	if err != nil {
+		return BatchList{}, err
+	}
+	return decodeResponse[BatchList](response)
+}
+
+// CancelBatch is a wrapper for the /batches/{id}/cancel API. It cancels the batch with the given ID,
+// which is cancelling for a while before it is cancelled, and returns it.
+func (c *Client) CancelBatch(ctx context.Context, batchID string) (Batch, error) {
+	response, err := c.do(ctx, http.MethodPost, "v1/batches/"+url.PathEscape(batchID)+"/cancel", "", nil)
+	if err != nil {
+		return Batch{}, err
+	}
+	return decodeResponse[Batch](response)
+}
+
+// decodeResponse decodes the JSON body of the response, and closes it.
+func decodeResponse[T any](response *http.Response) (T, error) {
+	defer func() { _ = response.Body.Close() }()
+
+	var responseBody T
+	if err := json.NewDecoder(response.Body).Decode(&responseBody); err != nil {
+		return responseBody, fmt.Errorf("failed to decode API response body: %w", err)
+	}
+	return responseBody, nil
+}
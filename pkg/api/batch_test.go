@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient_Batches verifies the requests of the batch life cycle: creation with the upload of
+// the requests file, retrieval, listing, cancellation and the download of the results.
+func TestClient_Batches(t *testing.T) {
+	requestsPath := filepath.Join(t.TempDir(), "requests.jsonl")
+	requests := `{"custom_id":"1","method":"POST","url":"/v1/chat/completions","body":{"model":"m"}}` + "\n"
+	require.NoError(t, os.WriteFile(requestsPath, []byte(requests), 0o600))
+
+	batch := `{"id":"batch_1","status":"validating","input_file_id":"file_1","request_counts":{"total":1}}`
+	var calls []string
+	client := NewClient("http://localhost:8080", WithTransport(&mockRoundTripper{
+		responseFunc: func(r *http.Request) (*http.Response, error) {
+			calls = append(calls, r.Method+" "+r.URL.RequestURI())
+			body := batch
+			switch r.URL.Path {
+			case "/v1/files":
+				require.NoError(t, r.ParseMultipartForm(1<<20))
+				assert.Equal(t, FilePurposeBatch, r.FormValue("purpose"))
+				file, header, err := r.FormFile("file")
+				require.NoError(t, err)
+				content, err := io.ReadAll(file)
+				require.NoError(t, err)
+				assert.Equal(t, "requests.jsonl", header.Filename)
+				assert.Equal(t, requests, string(content))
+				body = `{"id":"file_1","filename":"requests.jsonl","purpose":"batch","bytes":80}`
+			case "/v1/batches":
+				if r.Method == http.MethodPost {
+					var requestBody map[string]any
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+					assert.Equal(t, map[string]any{
+						"input_file_id": "file_1", "endpoint": "/v1/chat/completions", "completion_window": "24h",
+					}, requestBody)
+				} else {
+					body = `{"data":[` + batch + `],"has_more":true,"last_id":"batch_1"}`
+				}
+			case "/v1/files/file_2/content":
+				body = `{"custom_id":"1","response":{"status_code":200}}` + "\n"
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+		},
+	}))
+	ctx := context.Background()
+
+	created, err := client.CreateBatch(ctx, requestsPath, "/v1/chat/completions")
+	require.NoError(t, err)
+	assert.Equal(t, Batch{
+		ID: "batch_1", Status: BatchStatusValidating, InputFileID: "file_1", RequestCounts: BatchRequestCounts{Total: 1},
+	}, created)
+	assert.False(t, created.Done())
+
+	_, err = client.GetBatch(ctx, "batch_1")
+	require.NoError(t, err)
+
+	list, err := client.ListBatches(ctx, "batch_0", 10)
+	require.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	assert.True(t, list.HasMore)
+
+	_, err = client.CancelBatch(ctx, "batch_1")
+	require.NoError(t, err)
+
+	content, err := client.FileContent(ctx, "file_2")
+	require.NoError(t, err)
+	output, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	assert.Contains(t, string(output), `"custom_id":"1"`)
+
+	assert.Equal(t, []string{
+		"POST /v1/files",
+		"POST /v1/batches",
+		"GET /v1/batches/batch_1",
+		"GET /v1/batches?after=batch_0&limit=10",
+		"POST /v1/batches/batch_1/cancel",
+		"GET /v1/files/file_2/content",
+	}, calls)
+
+	_, err = client.CreateBatch(ctx, filepath.Join(t.TempDir(), "missing.jsonl"), "/v1/chat/completions")
+	assert.ErrorContains(t, err, "failed to upload requests file")
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// WithDebugHTTP makes the client dump the headers and bodies of every request, including retries, and of their
+// responses to the given writer, for troubleshooting incompatibilities with servers. The values of the headers
+// that hold credentials, like Authorization and api-key, are masked, and so are the credentials in the URL.
+// Streamed responses are dumped as they're read.
+func WithDebugHTTP(writer io.Writer) ClientOption {
+	return func(c *Client) { c.debugWriter = writer }
+}
+
+// ErrDryRun is the error of all the requests of a client made with WithDryRun, which are never sent.
+var ErrDryRun = errors.New("dry run, the request was not sent")
+
+// WithDryRun makes the client dump every request to the given writer instead of sending it, and fail it
+// with ErrDryRun, for troubleshooting how requests are built, like by templates or for gateways. The values
+// of the headers that hold credentials are masked, like the credentials in the URL, and JSON bodies are indented.
+func WithDryRun(writer io.Writer) ClientOption {
+	return func(c *Client) { c.dryRunWriter = writer }
+}
+
+// dumpRequest dumps the request, with the given body, to the writer. JSON bodies are indented.
+func dumpRequest(writer io.Writer, request *http.Request, body []byte) {
+	var dump bytes.Buffer
+	fmt.Fprintf(&dump, "%s %s\n", request.Method, redactURL(request.URL))
+	writeHeaders(&dump, "", request.Header)
+	if err := json.Indent(&dump, body, "", "  "); err != nil {
+		dump.Write(body)
+	}
+	dump.WriteString("\n")
+	_, _ = writer.Write(dump.Bytes())
+}
+
+// debugTransport dumps the requests and responses that go through it to a writer.
+type debugTransport struct {
+	next http.RoundTripper
+
+	// mu keeps the dumps of concurrent requests from interleaving within lines.
+	mu     *sync.Mutex
+	writer io.Writer
+}
+
+// RoundTrip dumps the request, performs it with the next transport, and dumps the response.
+// The response body is dumped as the caller reads it.
+func (t *debugTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	var dump bytes.Buffer
+	fmt.Fprintf(&dump, "> %s %s\n", request.Method, redactURL(request.URL))
+	writeHeaders(&dump, "> ", request.Header)
+	if request.GetBody != nil {
+		// The body is read from a copy, so that the request is sent untouched.
+		if body, err := request.GetBody(); err == nil {
+			_, _ = io.Copy(&dump, body)
+			_ = body.Close()
+		}
+	}
+	t.write("\n", dump.Bytes())
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		t.write("\n", []byte(fmt.Sprintf("< request failed: %v\n", err)))
+		return nil, err
+	}
+
+	dump.Reset()
+	fmt.Fprintf(&dump, "< %d %s\n", response.StatusCode, http.StatusText(response.StatusCode))
+	writeHeaders(&dump, "< ", response.Header)
+	t.write("", dump.Bytes())
+
+	response.Body = &debugBody{ReadCloser: response.Body, transport: t}
+	return response, nil
+}
+
+// write writes the given chunk of the dump, ending with the given suffix, as a whole.
+func (t *debugTransport) write(suffix string, chunk []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = t.writer.Write(chunk)
+	_, _ = io.WriteString(t.writer, suffix)
+}
+
+// debugBody dumps a response body as it is read.
+type debugBody struct {
+	io.ReadCloser
+	transport *debugTransport
+}
+
+// Read reads from the body, and dumps what it read.
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.transport.write("", p[:n])
+	}
+	if err == io.EOF {
+		b.transport.write("\n", nil)
+	}
+	return n, err
+}
+
+// writeHeaders writes the given headers in name order, one per line with the given prefix, and a blank
+// line after them. The values of the headers that hold credentials are masked.
+func writeHeaders(writer io.Writer, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			if isSecretHeader(name) {
+				value = maskSecret(value)
+			}
+			fmt.Fprintf(writer, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+	fmt.Fprintf(writer, "%s\n", strings.TrimSpace(prefix))
+}
+
+// redactURL returns the URL without the credentials and the query values, which may hold API keys,
+// like "?api-key=...".
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+
+	query := redacted.Query()
+	for name := range query {
+		query.Set(name, "REDACTED")
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// isSecretHeader tells whether the header with the given name holds credentials.
+func isSecretHeader(name string) bool {
+	name = strings.ToLower(name)
+	return name == "authorization" || name == "proxy-authorization" || strings.HasSuffix(name, "api-key")
+}
+
+// maskSecret masks a credential, only keeping its scheme, like "Bearer", and its last 4
+// characters if it is long enough for them not to give it away, to tell keys apart.
+func maskSecret(value string) string {
+	scheme, secret, ok := strings.Cut(value, " ")
+	if !ok {
+		scheme, secret = "", value
+	} else {
+		scheme += " "
+	}
+
+	if len(secret) < 16 {
+		return scheme + "****"
+	}
+	return scheme + "****" + secret[len(secret)-4:]
+}
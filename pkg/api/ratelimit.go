@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitInfo holds the rate limits of the API, as reported by the x-ratelimit-* headers
+// of a response, like OpenAI's. Limits that the server doesn't report are nil.
+type RateLimitInfo struct {
+	Requests *RateLimit
+	Tokens   *RateLimit
+}
+
+// RateLimit is the state of one rate limit.
+type RateLimit struct {
+	// Limit is the maximum number of requests or tokens in the window. It is zero if unknown.
+	Limit int
+	// Remaining is the number of requests or tokens left in the window.
+	Remaining int
+	// Reset is the time until the limit is reset. It is zero if unknown.
+	Reset time.Duration
+}
+
+// RemainingFraction returns the fraction of the limit that is left, between 0 and 1.
+// It is 1 if the limit is unknown.
+func (r RateLimit) RemainingFraction() float64 {
+	if r.Limit <= 0 {
+		return 1
+	}
+	return min(float64(r.Remaining)/float64(r.Limit), 1)
+}
+
+// ParseRateLimitInfo parses the rate limits reported by the given response headers,
+// and reports whether there are any.
+func ParseRateLimitInfo(header http.Header) (RateLimitInfo, bool) {
+	info := RateLimitInfo{Requests: parseRateLimit(header, "requests"), Tokens: parseRateLimit(header, "tokens")}
+	return info, info.Requests != nil || info.Tokens != nil
+}
+
+// parseRateLimit parses the rate limit of the given kind, "requests" or "tokens", from the
+// response headers. It returns nil if the remaining count is not reported.
+func parseRateLimit(header http.Header, kind string) *RateLimit {
+	remaining, err := strconv.Atoi(header.Get("X-Ratelimit-Remaining-" + kind))
+	if err != nil {
+		return nil
+	}
+
+	limit := &RateLimit{Remaining: remaining}
+	limit.Limit, _ = strconv.Atoi(header.Get("X-Ratelimit-Limit-" + kind))
+	limit.Reset = parseReset(header.Get("X-Ratelimit-Reset-" + kind))
+	return limit
+}
+
+// parseReset parses the time until a rate limit resets, given either as a duration, like "6m0s",
+// or as a number of seconds, like "0.5". It returns zero if the value is in neither format.
+func parseReset(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if reset, err := time.ParseDuration(value); err == nil {
+		return reset
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return 0
+}
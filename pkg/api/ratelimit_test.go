@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseRateLimitInfo verifies the parsing of the rate limit headers.
+func TestParseRateLimitInfo(t *testing.T) {
+	testCases := []struct {
+		name       string
+		header     http.Header
+		expected   RateLimitInfo
+		expectedOk bool
+	}{
+		{
+			name: "Requests and Tokens",
+			header: http.Header{
+				"X-Ratelimit-Limit-Requests":     {"500"},
+				"X-Ratelimit-Remaining-Requests": {"499"},
+				"X-Ratelimit-Reset-Requests":     {"120ms"},
+				"X-Ratelimit-Limit-Tokens":       {"30000"},
+				"X-Ratelimit-Remaining-Tokens":   {"1500"},
+				"X-Ratelimit-Reset-Tokens":       {"6m0s"},
+			},
+			expected: RateLimitInfo{
+				Requests: &RateLimit{Limit: 500, Remaining: 499, Reset: 120 * time.Millisecond},
+				Tokens:   &RateLimit{Limit: 30000, Remaining: 1500, Reset: 6 * time.Minute},
+			},
+			expectedOk: true,
+		},
+		{
+			name:       "Remaining Only, with Reset in Seconds",
+			header:     http.Header{"X-Ratelimit-Remaining-Tokens": {"10"}, "X-Ratelimit-Reset-Tokens": {"1.5"}},
+			expected:   RateLimitInfo{Tokens: &RateLimit{Remaining: 10, Reset: 1500 * time.Millisecond}},
+			expectedOk: true,
+		},
+		{
+			name:   "No Headers",
+			header: http.Header{},
+		},
+		{
+			name:   "Invalid Remaining",
+			header: http.Header{"X-Ratelimit-Remaining-Requests": {"many"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, ok := ParseRateLimitInfo(tc.header)
+			assert.Equal(t, tc.expectedOk, ok)
+			assert.Equal(t, tc.expected, info)
+		})
+	}
+}
+
+// TestRateLimit_RemainingFraction verifies the fraction of a limit that is left.
+func TestRateLimit_RemainingFraction(t *testing.T) {
+	assert.Equal(t, 0.05, RateLimit{Limit: 20000, Remaining: 1000}.RemainingFraction())
+	assert.Equal(t, 1.0, RateLimit{Remaining: 3}.RemainingFraction(), "An unknown limit is not considered low.")
+}
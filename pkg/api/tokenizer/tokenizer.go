@@ -0,0 +1,177 @@
+// Package tokenizer splits text into tokens, to count them without calling the API.
+//
+// The tokenizers approximate the byte-pair encodings used by the models, without
+// shipping their vocabularies. Counts are typically within a few percent for English
+// text and code, but they are estimates and can differ from the counts the API reports.
+package tokenizer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+// Tokenizer splits text into tokens.
+type Tokenizer interface {
+	// Name returns the name of the tokenizer.
+	Name() string
+	// Tokenize splits the text into tokens. Joining the tokens gives back the text.
+	Tokenize(text string) []string
+}
+
+// Count returns the number of tokens in the text, according to the given tokenizer.
+func Count(t Tokenizer, text string) int {
+	return len(t.Tokenize(text))
+}
+
+// The tokens that chat messages take besides their content, as in the chat formats of OpenAI models.
+const (
+	// tokensPerMessage delimit every message.
+	tokensPerMessage = 3
+	// tokensPerReply prime the reply of the assistant.
+	tokensPerReply = 3
+)
+
+// CountTokens returns the number of prompt tokens of the given messages, for the given model.
+// Besides the contents, it counts the roles, the tool calls, and the overhead of the chat format.
+// Images are not counted.
+func CountTokens(model string, messages []api.ChatMessage) int {
+	t := ForModel(model)
+
+	total := tokensPerReply
+	for _, message := range messages {
+		total += tokensPerMessage + Count(t, message.Role) + Count(t, message.Content)
+		for _, call := range message.ToolCalls {
+			total += Count(t, call.Function.Name) + Count(t, call.Function.Arguments)
+		}
+	}
+	return total
+}
+
+// Repeat returns the text, repeated as needed, cut to the given number of tokens.
+// It is useful to make prompts of exact lengths.
+func Repeat(t Tokenizer, text string, tokens int) string {
+	if tokens <= 0 || text == "" {
+		return ""
+	}
+
+	// Repeating the text may merge tokens across the joints, so the text is doubled until it has
+	// enough tokens, then cut. Doubling keeps the tokenizing linear in the number of tokens.
+	repeated := text
+	split := t.Tokenize(repeated)
+	for len(split) < tokens {
+		repeated += " " + repeated
+		split = t.Tokenize(repeated)
+	}
+	return strings.Join(split[:tokens], "")
+}
+
+// Names of the available tokenizers.
+const (
+	// NameBPE approximates the byte-pair encodings of modern models, like cl100k and o200k.
+	NameBPE = "bpe"
+	// NameChars uses the rule of thumb of four characters per token.
+	NameChars = "chars"
+)
+
+// tokenizers holds the available tokenizers by name.
+var tokenizers = map[string]Tokenizer{
+	NameBPE:   bpe{},
+	NameChars: chars{},
+}
+
+// Names returns the names of the available tokenizers, sorted.
+func Names() []string {
+	names := make([]string, 0, len(tokenizers))
+	for name := range tokenizers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Get returns the tokenizer with the given name.
+func Get(name string) (Tokenizer, error) {
+	t, ok := tokenizers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer %q, available: %s", name, strings.Join(Names(), ", "))
+	}
+	return t, nil
+}
+
+// ForModel returns the tokenizer to use for the given model.
+// No tokenizer is specific to a model yet, so all models use the BPE approximation.
+func ForModel(string) Tokenizer {
+	return bpe{}
+}
+
+// preTokenPattern splits text the way the cl100k encoding does before applying the byte-pair merges:
+// contractions, words with an optional leading non-letter, up to three digits, punctuation runs, and whitespace.
+var preTokenPattern = regexp.MustCompile(
+	`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// maxWordRunes is the longest word, including its leading space, that is taken as a single token.
+// Longer, rarer words are split into pieces of about this size, like a BPE vocabulary would.
+const maxWordRunes = 8
+
+// bpe approximates byte-pair encoding tokenizers.
+type bpe struct{}
+
+func (bpe) Name() string { return NameBPE }
+
+func (bpe) Tokenize(text string) []string {
+	var tokens []string
+	for _, piece := range preTokenPattern.FindAllString(text, -1) {
+		switch {
+		case isLogographic(piece):
+			// Scripts like Chinese take about one token per character.
+			tokens = append(tokens, splitRunes(piece, 1)...)
+		case utf8.RuneCountInString(piece) > maxWordRunes:
+			tokens = append(tokens, splitRunes(piece, maxWordRunes)...)
+		default:
+			tokens = append(tokens, piece)
+		}
+	}
+	return tokens
+}
+
+// chars splits text into tokens of four characters.
+type chars struct{}
+
+func (chars) Name() string { return NameChars }
+
+func (chars) Tokenize(text string) []string {
+	return splitRunes(text, 4)
+}
+
+// splitRunes splits the text into pieces of the given number of runes, with a shorter last piece if needed.
+// For sizes greater than one, the pieces are balanced, so that the last one isn't tiny.
+func splitRunes(text string, size int) []string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return nil
+	}
+
+	count := (len(runes) + size - 1) / size
+	pieces := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		// Balanced boundaries: piece i spans [i*n/count, (i+1)*n/count).
+		pieces = append(pieces, string(runes[i*len(runes)/count:(i+1)*len(runes)/count]))
+	}
+	return pieces
+}
+
+// isLogographic reports whether the text contains characters of scripts that have no spaces between words.
+func isLogographic(text string) bool {
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package tokenizer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/api/tokenizer"
+)
+
+// TestTokenize verifies the tokens produced for various kinds of text.
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name      string
+		tokenizer string
+		text      string
+		want      []string
+	}{
+		{
+			name:      "Empty Text",
+			tokenizer: tokenizer.NameBPE,
+			text:      "",
+			want:      nil,
+		},
+		{
+			name:      "English Sentence",
+			tokenizer: tokenizer.NameBPE,
+			text:      "Hello, world! It's fine.",
+			want:      []string{"Hello", ",", " world", "!", " It", "'s", " fine", "."},
+		},
+		{
+			name:      "Numbers Split Into Three Digits",
+			tokenizer: tokenizer.NameBPE,
+			text:      "1234567",
+			want:      []string{"123", "456", "7"},
+		},
+		{
+			name:      "Long Word Split",
+			tokenizer: tokenizer.NameBPE,
+			text:      " internationalization",
+			want:      []string{" intern", "ational", "ization"},
+		},
+		{
+			name:      "Chinese One Token Per Character",
+			tokenizer: tokenizer.NameBPE,
+			text:      "你好",
+			want:      []string{"你", "好"},
+		},
+		{
+			name:      "Characters",
+			tokenizer: tokenizer.NameChars,
+			text:      "abcdefghij",
+			want:      []string{"abc", "def", "ghij"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok, err := tokenizer.Get(tt.tokenizer)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tok.Tokenize(tt.text))
+			assert.Equal(t, len(tt.want), tokenizer.Count(tok, tt.text))
+		})
+	}
+}
+
+// TestTokenize_Lossless verifies that joining the tokens gives back the original text.
+func TestTokenize_Lossless(t *testing.T) {
+	text := "func main() {\n\tfmt.Println(\"héllo wörld\")  \n}\n\n日本語のテキスト 42%"
+	for _, name := range tokenizer.Names() {
+		tok, err := tokenizer.Get(name)
+		require.NoError(t, err)
+		assert.Equal(t, text, strings.Join(tok.Tokenize(text), ""), "Tokenizer %s lost text.", name)
+	}
+}
+
+// TestGet verifies the lookup of tokenizers.
+func TestGet(t *testing.T) {
+	_, err := tokenizer.Get("missing")
+	assert.Error(t, err)
+
+	assert.Equal(t, tokenizer.NameBPE, tokenizer.ForModel("gpt-4.1").Name())
+}
+
+// TestCountTokens verifies that the messages are counted with their roles and the overhead of the chat format.
+func TestCountTokens(t *testing.T) {
+	assert.Equal(t, 3, tokenizer.CountTokens("gpt-4.1", nil), "The reply is primed even without messages.")
+
+	messages := []api.ChatMessage{
+		{Role: api.RoleSystem, Content: "Be brief."},   // 3 + 1 + 3 tokens.
+		{Role: api.RoleUser, Content: "Hello, world!"}, // 3 + 1 + 4 tokens.
+		{Role: api.RoleAssistant, ToolCalls: []api.ToolCall{{ // 3 + 2 + 2 tokens.
+			Function: api.FunctionCall{Name: "now", Arguments: "{}"},
+		}}},
+	}
+	assert.Equal(t, 3+7+8+7, tokenizer.CountTokens("gpt-4.1", messages))
+}
+
+// TestRepeat verifies that the text is repeated and cut to the exact number of tokens.
+func TestRepeat(t *testing.T) {
+	bpe, err := tokenizer.Get(tokenizer.NameBPE)
+	require.NoError(t, err)
+
+	for _, tokens := range []int{1, 7, 100, 1000} {
+		text := tokenizer.Repeat(bpe, "The quick brown fox jumps over the lazy dog.", tokens)
+		assert.Equal(t, tokens, tokenizer.Count(bpe, text), tokens)
+		assert.True(t, strings.HasPrefix(text, "The"))
+	}
+	assert.Empty(t, tokenizer.Repeat(bpe, "text", 0))
+}
+
+// TestRepeat_Large verifies that long prompts are made quickly, as the cost grows linearly with the tokens.
+func TestRepeat_Large(t *testing.T) {
+	bpe, err := tokenizer.Get(tokenizer.NameBPE)
+	require.NoError(t, err)
+
+	start := time.Now()
+	text := tokenizer.Repeat(bpe, "The quick brown fox jumps over the lazy dog.", 32_000)
+	assert.Equal(t, 32_000, tokenizer.Count(bpe, text))
+	assert.Less(t, time.Since(start), 30*time.Second)
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/json"
+	"math"
 	"time"
 )
 
@@ -8,6 +10,12 @@ const (
 	RoleSystem    = "system"
 	RoleUser      = "user"
 	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+const (
+	ContentTypeText     = "text"
+	ContentTypeImageURL = "image_url"
 )
 
 // ChatCompletionEvent represents a single event from the Chat-Completion API response stream.
@@ -20,6 +28,12 @@ type ChatCompletionEvent struct {
 	SystemFingerprint string `json:"system_fingerprint"`
 	Object            string `json:"object"`
 
+	// Usage holds the token counts of the whole stream. It is only set on the
+	// last event, usually without choices, if usage is included with StreamOptions.
+	Usage *Usage `json:"usage,omitempty"`
+
+	// requestID is the ID that the server assigned to the request of the stream, if any.
+	requestID string
 	// index can be used to process events in the correct order.
 	index int
 	// timestamp is the local timestamp of event reception.
@@ -32,13 +46,269 @@ type ChatCompletionEvent struct {
 func (cce ChatCompletionEvent) Index() int           { return cce.index }
 func (cce ChatCompletionEvent) Timestamp() time.Time { return cce.timestamp }
 
+// RequestID returns the ID that the server assigned to the request of the stream, if any,
+// to correlate it with the server logs.
+func (cce ChatCompletionEvent) RequestID() string { return cce.requestID }
+
+// CompletionTokens returns the number of tokens generated in the whole stream, and
+// whether the event reports it, which only the usage event does.
+func (cce ChatCompletionEvent) CompletionTokens() (int, bool) {
+	if cce.Usage == nil {
+		return 0, false
+	}
+	return cce.Usage.CompletionTokens, true
+}
+
+// ChatCompletionResult is the response of a chat completion stream, assembled by ChatCompletionText.
+type ChatCompletionResult struct {
+	Content          string
+	ReasoningContent string
+	ToolCalls        []ToolCall
+	StreamResult
+}
+
+// FinishReason tells why the model stopped generating a choice.
+type FinishReason string
+
+// The finish reasons of the API. Servers may send others.
+const (
+	// FinishReasonStop is a natural stop, or a stop at one of the stop sequences.
+	FinishReasonStop FinishReason = "stop"
+	// FinishReasonLength is a stop at the maximum number of tokens, or at the end of the context window.
+	FinishReasonLength FinishReason = "length"
+	// FinishReasonToolCalls is a stop to call tools.
+	FinishReasonToolCalls FinishReason = "tool_calls"
+	// FinishReasonContentFilter is a stop by the content filter of the server.
+	FinishReasonContentFilter FinishReason = "content_filter"
+)
+
+// StreamResult tells how a chat completion stream ended, for one of its choices.
+//
+// Consumers of the stream Add every event to it, and read it once the stream ends.
+type StreamResult struct {
+	// Index is the index of the choice, which is the first one by default.
+	Index int
+	// FinishReason is empty if the stream ended before the model finished.
+	FinishReason FinishReason
+	// Refusal is the explanation of the model if it refused to respond.
+	Refusal string
+	// Usage is only set if it was requested with ChatOptions.StreamOptions.
+	Usage *Usage
+}
+
+// Add records what the event tells about the end of the stream.
+func (r *StreamResult) Add(event ChatCompletionEvent) {
+	if event.Usage != nil {
+		r.Usage = event.Usage
+	}
+	for _, choice := range event.Choices {
+		if choice.Index != r.Index {
+			continue
+		}
+		r.Refusal += choice.Delta.Refusal
+		if choice.FinishReason != "" {
+			r.FinishReason = choice.FinishReason
+		}
+	}
+}
+
+// Usage holds the token counts of a response.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// StreamOptions holds the options of a streamed response.
+type StreamOptions struct {
+	// IncludeUsage makes the server send the token usage of the stream in a last event.
+	IncludeUsage bool `json:"include_usage"`
+}
+
 type ChatCompletionChoice struct {
 	Delta ChatCompletionDelta `json:"delta"`
 
-	FinishReason any `json:"finish_reason"`
-	Index        int `json:"index"`
+	// FinishReason is set on the last delta of the choice, and is empty, or null, before it.
+	FinishReason FinishReason `json:"finish_reason"`
+	Index        int          `json:"index"`
+
+	// Logprobs holds the log probabilities of the tokens of the delta, if requested with ChatOptions.Logprobs.
+	Logprobs *Logprobs `json:"logprobs,omitempty"`
+}
+
+// Logprobs holds the log probabilities of the tokens of a choice.
+type Logprobs struct {
+	Content []TokenLogprob `json:"content"`
+}
+
+// TokenLogprob is the log probability of a generated token, along with the most likely
+// alternatives at its position, if requested with ChatOptions.TopLogprobs.
+type TokenLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	// Bytes is the UTF-8 encoding of the token, which is useful for tokens that are parts of characters.
+	Bytes       []int        `json:"bytes,omitempty"`
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+// Probability returns the probability of the token, between 0 and 1.
+func (t TokenLogprob) Probability() float64 { return math.Exp(t.Logprob) }
+
+// TopLogprob is the log probability of one of the most likely tokens at a position.
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
 }
 
 type ChatCompletionDelta struct {
 	Content string `json:"content"`
+	// ReasoningContent holds the reasoning tokens of reasoning models that
+	// stream them separately from the answer (DeepSeek-R1 style). Servers that
+	// send them in a "reasoning" field instead, like OpenRouter, are supported too.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	// Refusal holds the explanation of the model if it refuses to respond, instead of the content.
+	Refusal string `json:"refusal,omitempty"`
+	// ToolCalls holds fragments of the tool calls of the model. Use MergeToolCalls to assemble them.
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, to read the reasoning from either field.
+func (d *ChatCompletionDelta) UnmarshalJSON(data []byte) error {
+	// The alias type does not inherit the UnmarshalJSON method, which prevents infinite recursion.
+	type plainDelta ChatCompletionDelta
+	var raw struct {
+		plainDelta
+		Reasoning string `json:"reasoning"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*d = ChatCompletionDelta(raw.plainDelta)
+	if d.ReasoningContent == "" {
+		d.ReasoningContent = raw.Reasoning
+	}
+	return nil
+}
+
+// Tool is a tool that the model may call. Only functions are supported by the API.
+type Tool struct {
+	// Type is always "function".
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// NewFunctionTool returns a function Tool with the given JSON schema of its parameters.
+func NewFunctionTool(name, description string, parameters json.RawMessage) Tool {
+	return Tool{Type: "function", Function: FunctionDefinition{Name: name, Description: description, Parameters: parameters}}
+}
+
+// FunctionDefinition describes a function that the model may call.
+type FunctionDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema of the arguments of the function.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToolCall is a complete call of a tool by the model.
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall holds the name of the called function, and its arguments as a JSON string.
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCallDelta is a fragment of a tool call in a stream. The first fragment of
+// a call carries its ID, type and name, and its arguments arrive in pieces.
+type ToolCallDelta struct {
+	// Index identifies the call that the fragment belongs to.
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// maxToolCallGap is how far past the last tool call the index of a fragment may be. Indices are sequential,
+// so farther ones are invalid, and would otherwise make room for countless calls.
+const maxToolCallGap = 64
+
+// MergeToolCalls assembles the given fragments into the tool calls they belong to,
+// which are returned with the fragments merged in, in the order of their indices.
+// Fragments with invalid indices, negative or far past the last call, are skipped.
+func MergeToolCalls(calls []ToolCall, deltas []ToolCallDelta) []ToolCall {
+	for _, delta := range deltas {
+		if delta.Index < 0 || delta.Index > len(calls)+maxToolCallGap {
+			continue
+		}
+		for len(calls) <= delta.Index {
+			calls = append(calls, ToolCall{})
+		}
+
+		call := &calls[delta.Index]
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Type != "" {
+			call.Type = delta.Type
+		}
+		call.Function.Name += delta.Function.Name
+		call.Function.Arguments += delta.Function.Arguments
+	}
+	return calls
+}
+
+// ResponseFormat represents the format that the model's output must follow.
+type ResponseFormat struct {
+	// Type is the kind of format, like "json_object" or "json_schema".
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat holds the JSON schema of a "json_schema" ResponseFormat.
+type JSONSchemaFormat struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict,omitempty"`
+}
+
+// EmbeddingsResponse represents the response body of the Embeddings API.
+type EmbeddingsResponse struct {
+	Data []EmbeddingsData `json:"data"`
+
+	Model  string `json:"model"`
+	Object string `json:"object"`
+}
+
+// ModelsResponse represents the response body of the Models API.
+type ModelsResponse struct {
+	Data   []Model `json:"data"`
+	Object string  `json:"object"`
+}
+
+// Model represents a model available at the API.
+type Model struct {
+	ID string `json:"id"`
+	// Created is the Unix timestamp of the creation of the model.
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+	Object  string `json:"object"`
+}
+
+type EmbeddingsData struct {
+	Embedding []float64 `json:"embedding"`
+
+	Index  int    `json:"index"`
+	Object string `json:"object"`
+}
+
+// TranscriptionResponse represents the response body of the Transcriptions API.
+type TranscriptionResponse struct {
+	Text string `json:"text"`
 }
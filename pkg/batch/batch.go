@@ -0,0 +1,182 @@
+// Package batch runs a list of prompts through a processing function concurrently,
+// with rate limiting and retries, and keeps track of the results to support resuming.
+package batch
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Item is one prompt of a batch.
+type Item struct {
+	// ID identifies the item in the results. It defaults to the item's position in the input, starting at 1.
+	ID     string `json:"id"`
+	Prompt string `json:"prompt"`
+	// System is an optional system prompt.
+	System string `json:"system,omitempty"`
+}
+
+// Result is the outcome of processing an item.
+type Result struct {
+	ID        string `json:"id"`
+	Prompt    string `json:"prompt"`
+	Response  string `json:"response,omitempty"`
+	Reasoning string `json:"reasoning,omitempty"`
+	Usage     *Usage `json:"usage,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	Attempts int `json:"attempts"`
+	// LatencyMS is the duration of the last attempt, in milliseconds.
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// Usage holds the token counts of a result.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	// Estimated is true if the counts were estimated locally instead of reported by the API.
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// ReadItems reads the items from the file at the given path.
+//
+// Files with the .csv extension must have a header row with a "prompt" column, and optionally
+// "id" and "system" columns. Any other file is read as JSONL, with one Item object per line.
+func ReadItems(path string) ([]Item, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var items []Item
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		items, err = readCSV(file)
+	} else {
+		items, err = readJSONL(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// IDs must be unique for the results to be attributable, and for resuming to work.
+	seen := make(map[string]bool, len(items))
+	for i := range items {
+		if items[i].ID == "" {
+			items[i].ID = strconv.Itoa(i + 1)
+		}
+		if seen[items[i].ID] {
+			return nil, fmt.Errorf("duplicate item ID %q", items[i].ID)
+		}
+		seen[items[i].ID] = true
+
+		if strings.TrimSpace(items[i].Prompt) == "" {
+			return nil, fmt.Errorf("item %q has no prompt", items[i].ID)
+		}
+	}
+
+	return items, nil
+}
+
+// readJSONL reads items from JSONL, skipping blank lines.
+func readJSONL(reader io.Reader) ([]Item, error) {
+	var items []Item
+
+	scanner := bufio.NewScanner(reader)
+	// Prompts can be long, so allow lines of up to 10 MB.
+	scanner.Buffer(make([]byte, 0, 64*1024), 10<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var item Item
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal line %d: %w", line, err)
+		}
+		items = append(items, item)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	return items, nil
+}
+
+// readCSV reads items from CSV with a header row.
+func readCSV(reader io.Reader) ([]Item, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("CSV has no header row")
+	}
+
+	columns := map[string]int{"id": -1, "prompt": -1, "system": -1}
+	for i, name := range records[0] {
+		if _, ok := columns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+	}
+	if columns["prompt"] < 0 {
+		return nil, errors.New(`CSV has no "prompt" column`)
+	}
+
+	column := func(record []string, name string) string {
+		if columns[name] < 0 {
+			return ""
+		}
+		return record[columns[name]]
+	}
+
+	items := make([]Item, 0, len(records)-1)
+	for _, record := range records[1:] {
+		items = append(items, Item{
+			ID:     column(record, "id"),
+			Prompt: column(record, "prompt"),
+			System: column(record, "system"),
+		})
+	}
+	return items, nil
+}
+
+// CompletedIDs returns the IDs of the successful results in the results file at the given path.
+// A missing file has no completed IDs.
+func CompletedIDs(path string) (map[string]bool, error) {
+	completed := map[string]bool{}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return completed, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10<<20)
+	for scanner.Scan() {
+		var result Result
+		// A run that was killed may have left a partial last line, which is ignored.
+		if json.Unmarshal(scanner.Bytes(), &result) != nil {
+			continue
+		}
+		if result.Error == "" {
+			completed[result.ID] = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read results file: %w", err)
+	}
+	return completed, nil
+}
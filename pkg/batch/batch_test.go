@@ -0,0 +1,152 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/batch"
+	"github.com/shivanshkc/llmb/pkg/clock"
+)
+
+// writeFile writes the content to a file with the given name in a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// TestReadItems verifies the reading of JSONL and CSV input files.
+func TestReadItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []batch.Item
+		wantErr bool
+	}{
+		{
+			name:    "JSONL",
+			file:    "in.jsonl",
+			content: "{\"id\":\"a\",\"prompt\":\"Hi\",\"system\":\"Be brief.\"}\n\n{\"prompt\":\"Bye\"}\n",
+			want:    []batch.Item{{ID: "a", Prompt: "Hi", System: "Be brief."}, {ID: "2", Prompt: "Bye"}},
+		},
+		{
+			name:    "CSV",
+			file:    "in.csv",
+			content: "Prompt,ID\n\"Hello, there\",x\nBye,y\n",
+			want:    []batch.Item{{ID: "x", Prompt: "Hello, there"}, {ID: "y", Prompt: "Bye"}},
+		},
+		{name: "CSV Without Prompt Column", file: "in.csv", content: "id,text\n1,Hi\n", wantErr: true},
+		{name: "Malformed JSONL", file: "in.jsonl", content: "{\"prompt\":", wantErr: true},
+		{name: "Duplicate IDs", file: "in.jsonl", content: "{\"id\":\"a\",\"prompt\":\"1\"}\n{\"id\":\"a\",\"prompt\":\"2\"}", wantErr: true},
+		{name: "Empty Prompt", file: "in.jsonl", content: "{\"id\":\"a\",\"prompt\":\" \"}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := batch.ReadItems(writeFile(t, tt.file, tt.content))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, items)
+		})
+	}
+}
+
+// TestCompletedIDs verifies that only the successful results count as completed.
+func TestCompletedIDs(t *testing.T) {
+	path := writeFile(t, "out.jsonl", `{"id":"1","response":"ok"}
+{"id":"2","error":"boom"}
+{"id":"3","resp`)
+
+	completed, err := batch.CompletedIDs(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"1": true}, completed)
+
+	completed, err = batch.CompletedIDs(filepath.Join(t.TempDir(), "missing.jsonl"))
+	require.NoError(t, err)
+	assert.Empty(t, completed)
+}
+
+// TestRun verifies the processing of items with concurrency, retries and rate limiting.
+func TestRun(t *testing.T) {
+	items := []batch.Item{{ID: "1", Prompt: "a"}, {ID: "2", Prompt: "b"}, {ID: "3", Prompt: "c"}, {ID: "4", Prompt: "d"}}
+
+	t.Run("Retries and Errors", func(t *testing.T) {
+		var mu sync.Mutex
+		calls := map[string]int{}
+		process := func(ctx context.Context, item batch.Item) (batch.Result, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls[item.ID]++
+			switch {
+			case item.ID == "2" && calls[item.ID] == 1:
+				return batch.Result{}, errors.New("transient")
+			case item.ID == "3":
+				return batch.Result{}, errors.New("permanent")
+			}
+			return batch.Result{Response: "re: " + item.Prompt}, nil
+		}
+
+		results := map[string]batch.Result{}
+		// With a fake clock, the retry delay is instant, and the latencies are zero.
+		options := batch.Options{Concurrency: 2, Attempts: 2, RetryDelay: time.Hour, Clock: clock.NewFake(time.Time{})}
+		err := batch.Run(context.Background(), items, options, process, func(r batch.Result) { results[r.ID] = r })
+		require.NoError(t, err)
+
+		require.Len(t, results, 4)
+		assert.Equal(t, batch.Result{ID: "1", Prompt: "a", Response: "re: a", Attempts: 1}, results["1"])
+		assert.Equal(t, 2, results["2"].Attempts)
+		assert.Empty(t, results["2"].Error)
+		assert.Equal(t, "permanent", results["3"].Error)
+		assert.Equal(t, 2, calls["3"], "Failed items should be attempted the maximum number of times.")
+	})
+
+	t.Run("Rate Limit", func(t *testing.T) {
+		process := func(ctx context.Context, item batch.Item) (batch.Result, error) { return batch.Result{}, nil }
+
+		start := time.Now()
+		options := batch.Options{Concurrency: 4, RPS: 50, Attempts: 1}
+		require.NoError(t, batch.Run(context.Background(), items, options, process, func(batch.Result) {}))
+
+		// 4 attempts at 50 per second are spaced 20ms apart.
+		assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+	})
+
+	t.Run("Unmeasurable Rate", func(t *testing.T) {
+		process := func(ctx context.Context, item batch.Item) (batch.Result, error) { return batch.Result{}, nil }
+
+		// Rates too high for an interval between the attempts mean no limit, rather than a panic.
+		for _, rps := range []float64{math.Inf(1), 1e12} {
+			options := batch.Options{Concurrency: 4, RPS: rps, Attempts: 1}
+			assert.NoError(t, batch.Run(context.Background(), items, options, process, func(batch.Result) {}), rps)
+		}
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var emitted atomic.Int32
+		process := func(ctx context.Context, item batch.Item) (batch.Result, error) {
+			cancel()
+			<-ctx.Done()
+			return batch.Result{}, ctx.Err()
+		}
+
+		err := batch.Run(ctx, items, batch.Options{Concurrency: 1, Attempts: 3}, process, func(batch.Result) { emitted.Add(1) })
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, emitted.Load(), "Canceled items should not be emitted, so that they're retried on resume.")
+	})
+}
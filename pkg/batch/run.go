@@ -0,0 +1,158 @@
+package batch
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shivanshkc/llmb/pkg/clock"
+)
+
+// ProcessFunc processes a single item. It fills the response-related fields of the result,
+// and returns an error if the attempt failed and may be retried.
+type ProcessFunc func(ctx context.Context, item Item) (Result, error)
+
+// Options controls how a batch is run.
+type Options struct {
+	// Concurrency is the number of items processed at a time.
+	Concurrency int
+	// RPS is the maximum number of attempts started per second. Zero means no limit.
+	RPS float64
+	// Attempts is the maximum number of attempts per item.
+	Attempts int
+	// RetryDelay is the delay between the attempts of an item.
+	RetryDelay time.Duration
+	// Clock, if set, measures the latencies and the delays between attempts, instead of the real clock.
+	Clock clock.Clock
+}
+
+// Run processes all the items and passes each result to the emit function as soon as it's ready.
+// The emit function is never called concurrently.
+//
+// Failed items don't stop the run, their last error is reported in their result instead.
+// Run returns early only if the context is canceled, in which case the items in progress are not emitted.
+func Run(ctx context.Context, items []Item, options Options, process ProcessFunc, emit func(Result)) error {
+	limiter := newRateLimiter(options.RPS)
+	defer limiter.stop()
+
+	itemChan := make(chan Item)
+	var emitMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for range max(options.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range itemChan {
+				result, ok := processWithRetries(ctx, item, options, limiter, process)
+				if !ok {
+					continue // Canceled.
+				}
+				emitMu.Lock()
+				emit(result)
+				emitMu.Unlock()
+			}
+		}()
+	}
+
+	// Feed the items to the workers until done or canceled.
+feed:
+	for _, item := range items {
+		select {
+		case <-ctx.Done():
+			break feed
+		case itemChan <- item:
+		}
+	}
+	close(itemChan)
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// processWithRetries processes the item, retrying failed attempts.
+// It returns false if the context was canceled before the item could be completed.
+func processWithRetries(ctx context.Context, item Item, options Options, limiter *rateLimiter, process ProcessFunc) (Result, bool) {
+	var result Result
+	var err error
+	var latency time.Duration
+	clk := clock.OrReal(options.Clock)
+	for attempt := 1; attempt <= max(options.Attempts, 1); attempt++ {
+		if attempt > 1 && !sleep(ctx, clk, options.RetryDelay) {
+			return Result{}, false
+		}
+		if !limiter.wait(ctx) {
+			return Result{}, false
+		}
+
+		start := clk.Now()
+		result, err = process(ctx, item)
+		latency = clk.Now().Sub(start)
+		result.Attempts = attempt
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return Result{}, false
+		}
+	}
+
+	result.ID, result.Prompt = item.ID, item.Prompt
+	result.LatencyMS = float64(latency) / float64(time.Millisecond)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, true
+}
+
+// rateLimiter lets through at most a given number of events per second, evenly spaced.
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+// newRateLimiter returns a limiter for the given rate. A zero rate means no limit, and so does a rate
+// too high for the interval between two events to be measured, like an infinite one.
+func newRateLimiter(rps float64) *rateLimiter {
+	if rps <= 0 || math.IsNaN(rps) {
+		return &rateLimiter{}
+	}
+	interval := time.Duration(float64(time.Second) / rps)
+	if interval <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{ticker: time.NewTicker(interval)}
+}
+
+// wait blocks until the next event is allowed. It returns false if the context was canceled first.
+func (r *rateLimiter) wait(ctx context.Context) bool {
+	if r.ticker == nil {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-r.ticker.C:
+		return true
+	}
+}
+
+// stop releases the resources of the limiter.
+func (r *rateLimiter) stop() {
+	if r.ticker != nil {
+		r.ticker.Stop()
+	}
+}
+
+// sleep waits for the given delay on the clock. It returns false if the context was canceled first.
+func sleep(ctx context.Context, clk clock.Clock, delay time.Duration) bool {
+	timer := clk.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
@@ -2,120 +2,361 @@ package bench
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/shivanshkc/llmb/pkg/clock"
 )
 
+// ErrTooManyErrors is returned when more requests fail than a benchmark tolerates.
+var ErrTooManyErrors = errors.New("too many failed requests")
+
 // StreamBenchmarkResults holds the final aggregated metrics for a benchmark run.
 type StreamBenchmarkResults struct {
-	TTFT Metrics // Time To First Token.
-	TBT  Metrics // Time Between Tokens.
-	TT   Metrics // Total Time (end-to-end).
+	TTFT Metrics `json:"ttft"` // Time To First Token.
+	TBT  Metrics `json:"tbt"`  // Time Between Tokens.
+	TT   Metrics `json:"tt"`   // Total Time (end-to-end).
+
+	Succeeded int `json:"succeeded"` // Number of requests that succeeded, which the metrics are made of.
+	Failed    int `json:"failed"`    // Number of requests that failed, which are tolerated with WithMaxErrors.
+
+	// CompletionTokens is the total number of generated tokens, if the streams report it (see TokenCounter).
+	CompletionTokens int `json:"completion_tokens"`
+	// TokensPerSecond is the average rate at which a request generates tokens, over its total time.
+	TokensPerSecond float64 `json:"tokens_per_second"`
+
+	// Elapsed is the wall-clock time from the start of the first successful request to the end of the last,
+	// and RequestsPerSecond the rate at which the requests succeeded over it. Like the metrics, Elapsed is
+	// encoded in milliseconds, as elapsed_ms.
+	Elapsed           time.Duration `json:"-"`
+	RequestsPerSecond float64       `json:"requests_per_second"`
+}
+
+// Option configures optional behavior of a benchmark.
+type Option func(*options)
+
+// options holds the optional settings of a benchmark.
+type options struct {
+	clock clock.Clock
+	// maxErrors is the number of failed requests tolerated.
+	maxErrors int
+	// progress is told about every completed request, if set.
+	progress func(Progress)
+	// measure is given the measurements of every completed request, if set.
+	measure func(Measurement)
+	// duration is how long requests are started for, instead of a number of them, if set.
+	duration time.Duration
+	// rate is the number of requests started per second regardless of concurrency, if set, and arrivals
+	// the process of their arrival times.
+	rate     float64
+	arrivals string
+}
+
+// The arrival processes of the requests of a benchmark at a rate, for WithRate.
+const (
+	// ArrivalsUniform starts the requests at regular intervals.
+	ArrivalsUniform = "uniform"
+	// ArrivalsPoisson starts the requests at random, exponentially distributed intervals, like many
+	// independent users do.
+	ArrivalsPoisson = "poisson"
+)
+
+// interval returns the time until the arrival of the next request, with a rate.
+func (o options) interval() time.Duration {
+	mean := float64(time.Second) / o.rate
+	if o.arrivals == ArrivalsPoisson {
+		return time.Duration(rand.ExpFloat64() * mean)
+	}
+	return time.Duration(mean)
+}
+
+// WithClock makes the benchmark time the streams with the given clock, instead of the real clock.
+// The timestamps of the events should come from the same clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// WithMaxErrors makes the benchmark tolerate up to the given number of failed requests, instead of
+// stopping at the first one. Once more requests fail, the benchmark stops, and returns the results
+// gathered so far along with ErrTooManyErrors.
+func WithMaxErrors(maxErrors int) Option {
+	return func(o *options) { o.maxErrors = maxErrors }
+}
+
+// Progress is the state of a running benchmark, as told to the function of WithProgress.
+type Progress struct {
+	// Completed and Failed are the numbers of requests that succeeded and failed so far.
+	Completed, Failed int
+	// Total is the number of requests of the benchmark, which is zero for a benchmark that runs for a duration.
+	Total int
+	// Err is the error of the request that just failed, if any.
+	Err error
+}
+
+// WithProgress makes the benchmark call the given function whenever a request completes, successfully
+// or with a tolerated failure. It is called from a single goroutine.
+func WithProgress(progress func(Progress)) Option {
+	return func(o *options) { o.progress = progress }
+}
+
+// WithMeasurements makes the benchmark call the given function with the measurements of every request
+// that completes successfully, for analyzing them individually. It is called from a single goroutine.
+func WithMeasurements(measure func(Measurement)) Option {
+	return func(o *options) { o.measure = measure }
+}
+
+// WithDuration makes the benchmark keep starting requests, at its concurrency, until the given duration
+// has passed, instead of a fixed number of them. The request count of the benchmark is then ignored.
+// The requests in flight when the duration passes are completed, and count as part of the run.
+func WithDuration(duration time.Duration) Option {
+	return func(o *options) { o.duration = duration }
+}
+
+// MaxRate is the highest rate of WithRate, in requests per second. As the requests are started however many
+// are in flight, higher rates would exhaust the memory of the client, rather than load the server.
+const MaxRate = 10000
+
+// WithRate makes the benchmark start the given number of requests per second, up to MaxRate, with the given
+// arrival process, ArrivalsUniform or ArrivalsPoisson, however many requests are in flight.
+// This open loop measures the latencies at a fixed offered load, while without a rate, the load
+// depends on how fast the server responds. The concurrency of the benchmark is then ignored.
+func WithRate(requestsPerSecond float64, arrivals string) Option {
+	return func(o *options) { o.rate, o.arrivals = requestsPerSecond, arrivals }
 }
 
 // BenchmarkStream concurrently executes a given stream-producing function and
 // aggregates timing metrics. It manages concurrency with a semaphore and ensures
 // safe, leak-free shutdown using a context and WaitGroup.
+//
+// By default, the first failed request stops the benchmark, which returns no results.
 func BenchmarkStream(
-	ctx context.Context, requestCount, concurrency int, funk StreamFunc,
+	ctx context.Context, requestCount, concurrency int, funk StreamFunc, opts ...Option,
 ) (StreamBenchmarkResults, error) {
+	settings := options{clock: clock.Real}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	if settings.rate > 0 && settings.arrivals != ArrivalsUniform && settings.arrivals != ArrivalsPoisson {
+		return StreamBenchmarkResults{}, fmt.Errorf("unknown arrival process %q", settings.arrivals)
+	}
+	if settings.rate > MaxRate || math.IsNaN(settings.rate) {
+		return StreamBenchmarkResults{}, fmt.Errorf("rate of %g requests per second is above the maximum of %d",
+			settings.rate, MaxRate)
+	}
+
 	// Run all streams and collect results.
-	timingsArr, err := runStreams(ctx, requestCount, concurrency, funk)
-	if err != nil {
+	timingsArr, failed, err := runStreams(ctx, requestCount, concurrency, funk, settings)
+	if err != nil && !errors.Is(err, ErrTooManyErrors) {
 		return StreamBenchmarkResults{}, fmt.Errorf("error while running streams: %w", err)
 	}
 
-	// All runs were successful, calculate and return final metrics.
-	return StreamBenchmarkResults{
-		TTFT: durations(timingsArr.TTFTs()).Metrics(),
-		TBT:  durations(timingsArr.TBTs()).Metrics(),
-		TT:   durations(timingsArr.TTs()).Metrics(),
-	}, nil
+	// Calculate the metrics of the successful runs, which are all of them without an error.
+	tokens, tokensPerSecond := timingsArr.Throughput()
+	results := StreamBenchmarkResults{
+		TTFT:             durations(timingsArr.TTFTs()).Metrics(),
+		TBT:              durations(timingsArr.TBTs()).Metrics(),
+		TT:               durations(timingsArr.TTs()).Metrics(),
+		Succeeded:        len(timingsArr),
+		Failed:           failed,
+		CompletionTokens: tokens,
+		TokensPerSecond:  tokensPerSecond,
+		Elapsed:          timingsArr.Elapsed(),
+	}
+	if results.Elapsed > 0 {
+		results.RequestsPerSecond = float64(len(timingsArr)) / results.Elapsed.Seconds()
+	}
+	return results, err
+}
+
+// Warmup executes the stream-producing function for a total of `requestCount` times with the given level
+// of concurrency, without measuring the streams, so that cold-start effects, like loading the model or
+// setting up connections, are over before a benchmark. The first failed request stops it.
+func Warmup(ctx context.Context, requestCount, concurrency int, funk StreamFunc) error {
+	if _, _, err := runStreams(ctx, requestCount, concurrency, funk, options{clock: clock.Real}); err != nil {
+		return fmt.Errorf("failed to warm up: %w", err)
+	}
+	return nil
+}
+
+// failures counts the failed streams of a run, and decides when the run must stop.
+type failures struct {
+	maxErrors int
+
+	mu    sync.Mutex
+	count int
+	// err is set once the run must stop.
+	err error
+}
+
+// add records a failed stream, unless the run is already stopping, in which case the failure
+// is caused by stopping. It returns true if the run must stop now, and tolerated if it goes on
+// despite the failure.
+func (f *failures) add(err error, canceled bool) (stop, tolerated bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return false, false
+	}
+	f.count++
+
+	switch {
+	case f.maxErrors == 0 || canceled:
+		// Without tolerance, or when canceled, the first failure is fatal.
+		f.err = fmt.Errorf("a stream worker failed: %w", err)
+	case f.count > f.maxErrors:
+		f.err = fmt.Errorf("%w: %d failed, more than the %d tolerated, last error: %w",
+			ErrTooManyErrors, f.count, f.maxErrors, err)
+	default:
+		return false, true
+	}
+	return true, false
+}
+
+// outcome is the outcome of a stream run: its timings, or the error of a tolerated failure.
+type outcome struct {
+	timings timings
+	err     error
 }
 
 // runStreams executes the stream-producing function for a total of `requestCount`
-// times with the given level of concurrency, and returns the timings information
-// of all streams.
-func runStreams(ctx context.Context, requestCount, concurrency int, funk StreamFunc,
-) (timingsArray, error) {
+// times, or for the duration of the settings, with the given level of concurrency,
+// and returns the timings information of all successful streams, along with the
+// number of failed ones.
+//
+// It stops once more streams fail than the settings tolerate.
+func runStreams(ctx context.Context, requestCount, concurrency int, funk StreamFunc, settings options,
+) (timingsArray, int, error) {
 	// Use a cancellable context to manage the lifecycle of all workers.
 	// This context is passed down to every operation.
+	parentCtx := ctx
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// more tells whether another request is to be started, after the given number of them.
+	start, total := settings.clock.Now(), requestCount
+	more := func(started int) bool { return started < requestCount }
+	if settings.duration > 0 {
+		total = 0
+		more = func(int) bool { return settings.clock.Now().Sub(start) < settings.duration }
+	}
+
 	// Channels required for the operation.
-	timingsChan := make(chan timings, requestCount)
-	errChan := make(chan error, 1) // Channel to capture the first fatal error.
+	outcomes := make(chan outcome, concurrency)
 	semaphore := make(chan struct{}, concurrency)
+	failed := &failures{maxErrors: settings.maxErrors}
 
 	// WaitGroup ensures that the channels are not closed before all goroutines finish.
+	// The launcher counts as one, so that the wait can't end before all workers are launched.
 	var wg sync.WaitGroup
-	wg.Add(requestCount)
+	wg.Add(1)
 
-	// Launch a goroutine to spawn workers, preventing the main thread from blocking.
-	go func() {
-		for i := 0; i < requestCount; i++ {
-			select {
-			case <-ctx.Done(): // Stop launching new workers if context is canceled.
-				wg.Done() // Decrement wg for workers that will never be launched.
-				continue
-			case semaphore <- struct{}{}:
-				// Acquired a concurrency spot.
+	// acquire waits until the request with the given index may start, and returns false if the run is
+	// over meanwhile. In a closed loop, a request starts once a concurrency spot is free, which it
+	// releases when done. In the open loop of a rate, it starts at its arrival time instead.
+	acquire, release := func(i int) bool {
+		select {
+		case <-ctx.Done(): // Stop launching new workers if context is canceled.
+			return false
+		case semaphore <- struct{}{}:
+			// Acquired a concurrency spot, but the context may have been canceled,
+			// or the duration may have passed, meanwhile.
+			if ctx.Err() != nil || !more(i) {
+				<-semaphore
+				return false
+			}
+			return true
+		}
+	}, func() { <-semaphore }
+
+	if settings.rate > 0 {
+		arrival := start
+		acquire = func(i int) bool {
+			if i > 0 {
+				arrival = arrival.Add(settings.interval())
 			}
+			// Late requests start at once, so that the schedule is kept.
+			if wait := arrival.Sub(settings.clock.Now()); wait > 0 {
+				timer := settings.clock.NewTimer(wait)
+				defer timer.Stop()
+				select {
+				case <-ctx.Done():
+					return false
+				case <-timer.C():
+				}
+			}
+			return ctx.Err() == nil && more(i)
+		}
+		release = func() {}
+	}
 
+	// Launch a goroutine to spawn workers, preventing the main thread from blocking.
+	go func() {
+		defer wg.Done()
+		for i := 0; more(i) && acquire(i); i++ {
+			wg.Add(1)
 			go func() {
-				defer func() { <-semaphore }() // Release spot when done.
+				defer release() // Release spot when done.
 				defer wg.Done()
 
-				if t, err := runOneStream(ctx, funk); err != nil {
-					// On error, send it without blocking and cancel all other workers.
-					select {
-					case errChan <- err:
+				t, err := runOneStream(ctx, funk, settings.clock)
+				t.Request = i + 1
+				if err != nil {
+					// The failure is accounted before the spot is released, so that no new
+					// worker starts if the run must stop.
+					stop, tolerated := failed.add(err, parentCtx.Err() != nil)
+					if stop {
 						cancel() // Signal all other goroutines to stop.
-					default:
 					}
-				} else {
-					// This won't block as timingsChan has the size equal to the total request count.
-					timingsChan <- t
+					if tolerated {
+						outcomes <- outcome{err: err}
+					}
+					return
 				}
+				// This is received by the main goroutine until all workers are done.
+				outcomes <- outcome{timings: t}
 			}()
 		}
 	}()
 
 	// Launch a final goroutine to wait for all workers to finish and then
-	// close the channels. This signals the main goroutine that all results are in.
+	// close the channel. This signals the main goroutine that all results are in.
 	go func() {
 		wg.Wait()
-		close(timingsChan)
-		close(errChan)
+		close(outcomes)
 	}()
 
 	timingsArr := make(timingsArray, 0, requestCount)
-	// This approach waits for all workers to complete before checking for an error.
-	// A select-case loop on both timingsChan and errChan would allow for a "fail-fast"
-	// behavior, returning immediately upon the first error. However, for typical
-	// benchmark runs, the current simpler approach is more than sufficient.
-	for t := range timingsChan {
-		timingsArr = append(timingsArr, t)
-		fmt.Printf("[%d/%d] requests complete.\n", len(timingsArr), requestCount)
-	}
-
-	// After collecting all successful results, check if an error occurred.
-	if err := <-errChan; err != nil {
-		return nil, fmt.Errorf("a stream worker failed: %w", err)
+	var tolerated int
+	// This approach waits for all workers to complete before checking for an error,
+	// so that no worker is left running.
+	for o := range outcomes {
+		if o.err != nil {
+			tolerated++
+		} else {
+			timingsArr = append(timingsArr, o.timings)
+			if settings.measure != nil {
+				settings.measure(o.timings.measurement())
+			}
+		}
+		if settings.progress != nil {
+			settings.progress(Progress{Completed: len(timingsArr), Failed: tolerated, Total: total, Err: o.err})
+		}
 	}
 
-	// All runs were successful.
-	return timingsArr, nil
+	// All workers are done, so the failures can be read without locking.
+	return timingsArr, failed.count, failed.err
 }
 
 // runOneStream executes the stream-producing function once and returns its
-// timings or an error.
-func runOneStream(ctx context.Context, funk StreamFunc) (timings, error) {
+// timings, measured with the given clock, or an error.
+func runOneStream(ctx context.Context, funk StreamFunc, clk clock.Clock) (timings, error) {
 	// Time at which stream started.
-	start := time.Now()
+	start := clk.Now()
 	// Begin the stream.
 	eventStream, err := funk(ctx)
 	// Handle fatal error.
@@ -130,17 +371,19 @@ func runOneStream(ctx context.Context, funk StreamFunc) (timings, error) {
 	}
 
 	// Time at which stream ended.
-	end := time.Now()
+	end := clk.Now()
 
 	// Sort events by index to ensure correct TTFT and TBT calculations,
 	// as concurrency might jumble collection order.
 	sort.SliceStable(events, func(i, j int) bool { return events[i].Index() < events[j].Index() })
 
-	// Collect event timestamps.
-	eventTimestamps := make([]time.Time, len(events))
-	for i, event := range events {
-		eventTimestamps[i] = event.Timestamp()
+	// Collect the timestamps of the tokens, and the token count, if reported.
+	timer := NewStreamTimer(start)
+	for _, event := range events {
+		timer.Add(event)
 	}
+	result := timer.timings
+	result.End = end
 
-	return timings{Start: start, End: end, Events: eventTimestamps}, nil
+	return result, nil
 }
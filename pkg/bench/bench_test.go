@@ -3,6 +3,7 @@ package bench_test
 import (
 	"context"
 	"errors"
+	"math"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/shivanshkc/llmb/pkg/bench"
+	"github.com/shivanshkc/llmb/pkg/clock"
 	"github.com/shivanshkc/llmb/pkg/streams"
 )
 
@@ -23,6 +25,14 @@ type mockEvent struct {
 func (m mockEvent) Index() int           { return m.index }
 func (m mockEvent) Timestamp() time.Time { return m.timestamp }
 
+// mockUsageEvent is a mockEvent that reports the number of generated tokens.
+type mockUsageEvent struct {
+	mockEvent
+	tokens int
+}
+
+func (m mockUsageEvent) CompletionTokens() (int, bool) { return m.tokens, true }
+
 // newSuccessfulStreamFunc creates a StreamFunc that successfully produces a
 // stream of mock events with a configurable delay.
 func newSuccessfulStreamFunc(delay time.Duration, eventCount int) bench.StreamFunc {
@@ -73,6 +83,166 @@ func TestBenchmarkStream(t *testing.T) {
 		assert.NotZero(t, results.TT.Max, "Total Time Max should not be zero")
 	})
 
+	t.Run("Deterministic Timings with a Fake Clock", func(t *testing.T) {
+		// Every stream takes 100ms to its first event, and then 10ms between events, in fake time.
+		fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		streamFunc := func(ctx context.Context) (*streams.Stream[bench.Event], error) {
+			ch := make(chan bench.Event, 3)
+			fake.Advance(100 * time.Millisecond)
+			for i := range 3 {
+				if i > 0 {
+					fake.Advance(10 * time.Millisecond)
+				}
+				ch <- mockEvent{index: i, timestamp: fake.Now()}
+			}
+			close(ch)
+			return streams.New(ch), nil
+		}
+
+		// With no concurrency, the streams don't share the fake time.
+		results, err := bench.BenchmarkStream(context.Background(), 4, 1, streamFunc, bench.WithClock(fake))
+		require.NoError(t, err)
+		assert.Equal(t, 100*time.Millisecond, results.TTFT.Avg)
+		assert.Equal(t, 100*time.Millisecond, results.TTFT.P95)
+		assert.Equal(t, 10*time.Millisecond, results.TBT.Max)
+		assert.Equal(t, 120*time.Millisecond, results.TT.Med)
+		assert.Equal(t, 480*time.Millisecond, results.Elapsed)
+		assert.InDelta(t, 4/0.48, results.RequestsPerSecond, 0.001)
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		// Every stream takes 100ms in fake time, one at a time, so that 5 of them start within 450ms.
+		fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		streamFunc := func(ctx context.Context) (*streams.Stream[bench.Event], error) {
+			fake.Advance(100 * time.Millisecond)
+			ch := make(chan bench.Event, 1)
+			ch <- mockEvent{timestamp: fake.Now()}
+			close(ch)
+			return streams.New(ch), nil
+		}
+
+		var totals []int
+		progress := bench.WithProgress(func(p bench.Progress) { totals = append(totals, p.Total) })
+		results, err := bench.BenchmarkStream(context.Background(), 1, 1, streamFunc,
+			bench.WithClock(fake), bench.WithDuration(450*time.Millisecond), progress)
+		require.NoError(t, err)
+		assert.Equal(t, 5, results.Succeeded)
+		assert.Equal(t, 500*time.Millisecond, results.Elapsed)
+		assert.InDelta(t, 10, results.RequestsPerSecond, 0.001)
+		assert.Equal(t, []int{0, 0, 0, 0, 0}, totals, "the total is unknown for a duration")
+	})
+
+	t.Run("Throughput from Usage Events", func(t *testing.T) {
+		// Every stream generates 12 tokens in 3 events over 120ms, followed by a usage event, in fake time.
+		fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		streamFunc := func(ctx context.Context) (*streams.Stream[bench.Event], error) {
+			ch := make(chan bench.Event, 4)
+			fake.Advance(100 * time.Millisecond)
+			for i := range 3 {
+				if i > 0 {
+					fake.Advance(10 * time.Millisecond)
+				}
+				ch <- mockEvent{index: i, timestamp: fake.Now()}
+			}
+			// The usage event comes much later, but it is no token, so it must not be timed as one.
+			ch <- mockUsageEvent{mockEvent: mockEvent{index: 3, timestamp: fake.Now().Add(time.Second)}, tokens: 12}
+			close(ch)
+			return streams.New(ch), nil
+		}
+
+		results, err := bench.BenchmarkStream(context.Background(), 2, 1, streamFunc, bench.WithClock(fake))
+		require.NoError(t, err)
+		assert.Equal(t, 10*time.Millisecond, results.TBT.Max)
+		assert.Equal(t, 24, results.CompletionTokens)
+		assert.InDelta(t, 100, results.TokensPerSecond, 0.001)
+	})
+
+	t.Run("Progress", func(t *testing.T) {
+		streamFunc := newSuccessfulStreamFunc(time.Millisecond, 2)
+		var completed []int
+		progress := bench.WithProgress(func(p bench.Progress) {
+			assert.Equal(t, 4, p.Total)
+			assert.Zero(t, p.Failed)
+			completed = append(completed, p.Completed)
+		})
+
+		_, err := bench.BenchmarkStream(context.Background(), 4, 2, streamFunc, progress)
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3, 4}, completed)
+	})
+
+	t.Run("Measurements", func(t *testing.T) {
+		streamFunc := newSuccessfulStreamFunc(time.Millisecond, 3)
+		var measurements []bench.Measurement
+		measure := bench.WithMeasurements(func(m bench.Measurement) { measurements = append(measurements, m) })
+
+		_, err := bench.BenchmarkStream(context.Background(), 4, 2, streamFunc, measure)
+		require.NoError(t, err)
+
+		require.Len(t, measurements, 4)
+		var requests []int
+		for _, m := range measurements {
+			requests = append(requests, m.Request)
+			assert.Equal(t, 3, m.Tokens)
+			assert.Len(t, m.Gaps, 2)
+			assert.Positive(t, m.TTFT)
+			assert.GreaterOrEqual(t, m.Total, m.TTFT)
+		}
+		assert.ElementsMatch(t, []int{1, 2, 3, 4}, requests)
+	})
+
+	t.Run("Rate", func(t *testing.T) {
+		// At 200 requests per second, a request starts every 5ms, while every one takes 30ms, so that
+		// they overlap even with a concurrency of 1.
+		var inFlight, maxInFlight atomic.Int32
+		streamFunc := func(ctx context.Context) (*streams.Stream[bench.Event], error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for current := maxInFlight.Load(); n > current && !maxInFlight.CompareAndSwap(current, n); {
+				current = maxInFlight.Load()
+			}
+			return newSuccessfulStreamFunc(30*time.Millisecond, 1)(ctx)
+		}
+
+		var starts []time.Time
+		measure := bench.WithMeasurements(func(m bench.Measurement) { starts = append(starts, m.Start) })
+		results, err := bench.BenchmarkStream(context.Background(), 5, 1, streamFunc,
+			bench.WithRate(200, bench.ArrivalsUniform), measure)
+		require.NoError(t, err)
+		assert.Equal(t, 5, results.Succeeded)
+		assert.Greater(t, maxInFlight.Load(), int32(1), "the rate must not be limited by the concurrency")
+
+		first, last := starts[0], starts[0]
+		for _, start := range starts {
+			if start.Before(first) {
+				first = start
+			}
+			if start.After(last) {
+				last = start
+			}
+		}
+		assert.GreaterOrEqual(t, last.Sub(first), 15*time.Millisecond, "the requests must be spread over 20ms")
+	})
+
+	t.Run("Poisson Rate", func(t *testing.T) {
+		results, err := bench.BenchmarkStream(context.Background(), 5, 1, newSuccessfulStreamFunc(time.Millisecond, 1),
+			bench.WithRate(1000, bench.ArrivalsPoisson))
+		require.NoError(t, err)
+		assert.Equal(t, 5, results.Succeeded)
+
+		_, err = bench.BenchmarkStream(context.Background(), 5, 1, newSuccessfulStreamFunc(time.Millisecond, 1),
+			bench.WithRate(1000, "bursty"))
+		assert.ErrorContains(t, err, "unknown arrival process")
+	})
+
+	t.Run("Rate Above Maximum", func(t *testing.T) {
+		for _, rate := range []float64{math.Inf(1), bench.MaxRate + 1} {
+			_, err := bench.BenchmarkStream(context.Background(), 5, 1, newSuccessfulStreamFunc(time.Millisecond, 1),
+				bench.WithRate(rate, bench.ArrivalsUniform))
+			assert.ErrorContains(t, err, "above the maximum", rate)
+		}
+	})
+
 	t.Run("Run with Zero Requests", func(t *testing.T) {
 		streamFunc := newSuccessfulStreamFunc(10*time.Millisecond, 5)
 		results, err := bench.BenchmarkStream(context.Background(), 0, 5, streamFunc)
@@ -115,6 +285,56 @@ func TestBenchmarkStream(t *testing.T) {
 		assert.Less(t, duration, 200*time.Millisecond, "Benchmark should fail fast and not wait for all requests")
 	})
 
+	t.Run("Tolerated Errors", func(t *testing.T) {
+		// Every other request fails.
+		var callCount int32
+		streamFunc := func(ctx context.Context) (*streams.Stream[bench.Event], error) {
+			if atomic.AddInt32(&callCount, 1)%2 == 0 {
+				return nil, errors.New("simulated API error")
+			}
+			return newSuccessfulStreamFunc(time.Millisecond, 2)(ctx)
+		}
+
+		// The tolerated failures are told to the progress, with their errors.
+		var last bench.Progress
+		var failures []error
+		progress := bench.WithProgress(func(p bench.Progress) {
+			last = p
+			if p.Err != nil {
+				failures = append(failures, p.Err)
+			}
+		})
+
+		results, err := bench.BenchmarkStream(context.Background(), 6, 1, streamFunc, bench.WithMaxErrors(3), progress)
+		require.NoError(t, err)
+		assert.Equal(t, 3, results.Succeeded)
+		assert.Equal(t, 3, results.Failed)
+		assert.NotZero(t, results.TT.Avg)
+
+		assert.Equal(t, bench.Progress{Completed: 3, Failed: 3, Total: 6, Err: last.Err}, last)
+		require.Len(t, failures, 3)
+		assert.ErrorContains(t, failures[0], "simulated API error")
+	})
+
+	t.Run("Too Many Errors", func(t *testing.T) {
+		// The first two requests succeed, and all the others fail.
+		var callCount int32
+		streamFunc := func(ctx context.Context) (*streams.Stream[bench.Event], error) {
+			if atomic.AddInt32(&callCount, 1) > 2 {
+				return nil, errors.New("simulated API error")
+			}
+			return newSuccessfulStreamFunc(time.Millisecond, 2)(ctx)
+		}
+
+		results, err := bench.BenchmarkStream(context.Background(), 100, 1, streamFunc, bench.WithMaxErrors(2))
+		require.ErrorIs(t, err, bench.ErrTooManyErrors)
+		assert.Contains(t, err.Error(), "simulated API error")
+		assert.Equal(t, 2, results.Succeeded, "The results gathered so far are returned.")
+		assert.Equal(t, 3, results.Failed)
+		assert.NotZero(t, results.TT.Avg)
+		assert.Less(t, atomic.LoadInt32(&callCount), int32(10), "The benchmark stops once the errors exceed the limit.")
+	})
+
 	t.Run("Context Cancellation", func(t *testing.T) {
 		// Use a slow stream func so cancellation is guaranteed to happen mid-flight.
 		streamFunc := newSuccessfulStreamFunc(5*time.Second, 10)
@@ -134,3 +354,45 @@ func TestBenchmarkStream(t *testing.T) {
 		assert.Less(t, duration, 150*time.Millisecond, "Benchmark should respect context cancellation")
 	})
 }
+
+// TestWarmup verifies that a warm-up executes all its requests, and stops at the first failure.
+func TestWarmup(t *testing.T) {
+	t.Run("All Requests", func(t *testing.T) {
+		var calls atomic.Int32
+		streamFunc := func(ctx context.Context) (*streams.Stream[bench.Event], error) {
+			calls.Add(1)
+			return newSuccessfulStreamFunc(time.Millisecond, 2)(ctx)
+		}
+
+		require.NoError(t, bench.Warmup(context.Background(), 5, 2, streamFunc))
+		assert.Equal(t, int32(5), calls.Load())
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		expectedErr := errors.New("model not loaded")
+		err := bench.Warmup(context.Background(), 5, 2, newFailingStreamFunc(expectedErr))
+		assert.ErrorIs(t, err, expectedErr)
+		assert.ErrorContains(t, err, "failed to warm up")
+	})
+}
+
+// TestStreamTimer verifies the metrics of a single stream, with and without a reported token count.
+func TestStreamTimer(t *testing.T) {
+	start := time.Unix(0, 0)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	timer := bench.NewStreamTimer(start)
+	timer.Add(mockEvent{index: 0, timestamp: at(200)})
+	timer.Add(mockEvent{index: 1, timestamp: at(300)})
+	timer.Add(mockUsageEvent{mockEvent: mockEvent{index: 2, timestamp: at(400)}, tokens: 10})
+	assert.Equal(t, bench.StreamStats{
+		TTFT: 200 * time.Millisecond, Total: 500 * time.Millisecond, Tokens: 10, TokensPerSecond: 20,
+	}, timer.Stop(at(500)))
+
+	timer = bench.NewStreamTimer(start)
+	timer.Add(mockEvent{index: 0, timestamp: at(100)})
+	timer.Add(mockEvent{index: 1, timestamp: at(200)})
+	assert.Equal(t, bench.StreamStats{
+		TTFT: 100 * time.Millisecond, Total: time.Second, Tokens: 2, Estimated: true, TokensPerSecond: 2,
+	}, timer.Stop(at(1000)))
+}
@@ -17,7 +17,8 @@ import (
 )
 
 // Metrics holds a collection of standard statistical measurements for a set of
-// timing durations. All values are expressed as time.Duration.
+// timing durations. All values are expressed as time.Duration, and encoded in JSON
+// as milliseconds, like "p95_ms": 812.5.
 type Metrics struct {
 	Avg time.Duration // The average (mean) duration.
 	Min time.Duration // The minimum (fastest) duration.
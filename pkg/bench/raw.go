@@ -0,0 +1,156 @@
+package bench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Measurement holds the raw measurements of a single request of a benchmark, for analyzing
+// the requests individually, instead of through the aggregated metrics.
+type Measurement struct {
+	// Request is the number of the request, from 1, in the order that the requests were started.
+	Request int
+	Start   time.Time
+	StreamStats
+	// Gaps are the times between the consecutive tokens of the request.
+	Gaps []time.Duration
+}
+
+// measurement returns the measurements of the stream run.
+func (t timings) measurement() Measurement {
+	return Measurement{
+		Request:     t.Request,
+		Start:       t.Start,
+		StreamStats: t.stats(),
+		Gaps:        timingsArray{t}.TBTs(),
+	}
+}
+
+// The formats that measurements can be written in, besides the formats of reports.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// rawRow is a measurement as written by a RawWriter. The durations are in milliseconds.
+type rawRow struct {
+	Request         int       `json:"request"`
+	Start           time.Time `json:"start"`
+	TTFTMillis      float64   `json:"ttft_ms"`
+	TotalMillis     float64   `json:"total_ms"`
+	Tokens          int       `json:"tokens"`
+	TokensEstimated bool      `json:"tokens_estimated"`
+	TokensPerSecond float64   `json:"tokens_per_second"`
+	GapsMillis      []float64 `json:"gaps_ms,omitempty"`
+}
+
+// RawWriter writes measurements, one per row, as CSV with a header, or as JSON lines, for statistical analysis
+// with other tools. The durations are written in milliseconds.
+type RawWriter struct {
+	format string
+	// gaps tells whether the times between the tokens are written too, which make rows much longer.
+	gaps bool
+
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// NewRawWriter returns a RawWriter that writes to the writer in the given format, FormatCSV or FormatJSONL.
+// If gaps is true, the times between the tokens of every request are written too: as a JSON array, or as a
+// CSV column of space-separated values.
+func NewRawWriter(writer io.Writer, format string, gaps bool) (*RawWriter, error) {
+	w := &RawWriter{format: format, gaps: gaps}
+	switch format {
+	case FormatCSV:
+		w.csv = csv.NewWriter(writer)
+		header := []string{"request", "start", "ttft_ms", "total_ms", "tokens", "tokens_estimated", "tokens_per_second"}
+		if gaps {
+			header = append(header, "gaps_ms")
+		}
+		if err := w.writeCSV(header); err != nil {
+			return nil, err
+		}
+	case FormatJSONL:
+		w.json = json.NewEncoder(writer)
+	default:
+		return nil, fmt.Errorf("unknown measurement format %q", format)
+	}
+	return w, nil
+}
+
+// Write writes the measurement as the next row.
+func (w *RawWriter) Write(m Measurement) error {
+	row := rawRow{
+		Request:         m.Request,
+		Start:           m.Start,
+		TTFTMillis:      millis(m.TTFT),
+		TotalMillis:     millis(m.Total),
+		Tokens:          m.Tokens,
+		TokensEstimated: m.Estimated,
+		TokensPerSecond: m.TokensPerSecond,
+	}
+	if w.gaps {
+		row.GapsMillis = make([]float64, len(m.Gaps))
+		for i, gap := range m.Gaps {
+			row.GapsMillis[i] = millis(gap)
+		}
+	}
+
+	if w.format == FormatJSONL {
+		if err := w.json.Encode(row); err != nil {
+			return fmt.Errorf("failed to write measurement: %w", err)
+		}
+		return nil
+	}
+
+	record := []string{
+		strconv.Itoa(row.Request),
+		row.Start.Format(time.RFC3339Nano),
+		formatFloat(row.TTFTMillis),
+		formatFloat(row.TotalMillis),
+		strconv.Itoa(row.Tokens),
+		strconv.FormatBool(row.TokensEstimated),
+		formatFloat(row.TokensPerSecond),
+	}
+	if w.gaps {
+		gaps := make([]string, len(row.GapsMillis))
+		for i, gap := range row.GapsMillis {
+			gaps[i] = formatFloat(gap)
+		}
+		record = append(record, strings.Join(gaps, " "))
+	}
+	return w.writeCSV(record)
+}
+
+// writeCSV writes the record and flushes it, so that the rows of a benchmark that is interrupted are kept.
+func (w *RawWriter) writeCSV(record []string) error {
+	if err := w.csv.Write(record); err != nil {
+		return fmt.Errorf("failed to write measurement: %w", err)
+	}
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return fmt.Errorf("failed to write measurement: %w", err)
+	}
+	return nil
+}
+
+// millis returns the duration in milliseconds.
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// fromMillis returns the duration of the given milliseconds, to the nanosecond.
+func fromMillis(ms float64) time.Duration {
+	return time.Duration(math.Round(ms * float64(time.Millisecond)))
+}
+
+// formatFloat formats the number in the shortest way that keeps its value.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
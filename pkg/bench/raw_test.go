@@ -0,0 +1,77 @@
+package bench_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/bench"
+)
+
+// TestRawWriter verifies the rows written for measurements in every format, with and without the gaps.
+func TestRawWriter(t *testing.T) {
+	measurement := bench.Measurement{
+		Request: 2,
+		Start:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		StreamStats: bench.StreamStats{
+			TTFT:            1500 * time.Microsecond,
+			Total:           2 * time.Second,
+			Tokens:          3,
+			Estimated:       true,
+			TokensPerSecond: 1.5,
+		},
+		Gaps: []time.Duration{time.Millisecond, 250 * time.Microsecond},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		gaps   bool
+		want   string
+	}{
+		{
+			name:   "CSV",
+			format: bench.FormatCSV,
+			want: "request,start,ttft_ms,total_ms,tokens,tokens_estimated,tokens_per_second\n" +
+				"2,2024-01-02T03:04:05Z,1.5,2000,3,true,1.5\n",
+		},
+		{
+			name:   "CSV with Gaps",
+			format: bench.FormatCSV,
+			gaps:   true,
+			want: "request,start,ttft_ms,total_ms,tokens,tokens_estimated,tokens_per_second,gaps_ms\n" +
+				"2,2024-01-02T03:04:05Z,1.5,2000,3,true,1.5,1 0.25\n",
+		},
+		{
+			name:   "JSONL",
+			format: bench.FormatJSONL,
+			want: `{"request":2,"start":"2024-01-02T03:04:05Z","ttft_ms":1.5,"total_ms":2000,"tokens":3,` +
+				`"tokens_estimated":true,"tokens_per_second":1.5}` + "\n",
+		},
+		{
+			name:   "JSONL with Gaps",
+			format: bench.FormatJSONL,
+			gaps:   true,
+			want: `{"request":2,"start":"2024-01-02T03:04:05Z","ttft_ms":1.5,"total_ms":2000,"tokens":3,` +
+				`"tokens_estimated":true,"tokens_per_second":1.5,"gaps_ms":[1,0.25]}` + "\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+			writer, err := bench.NewRawWriter(&output, tc.format, tc.gaps)
+			require.NoError(t, err)
+			require.NoError(t, writer.Write(measurement))
+			assert.Equal(t, tc.want, output.String())
+		})
+	}
+
+	t.Run("Unknown Format", func(t *testing.T) {
+		_, err := bench.NewRawWriter(&bytes.Buffer{}, "xml", false)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,231 @@
+package bench
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Report is a saved benchmark result, along with the metadata of its run.
+type Report struct {
+	Metadata Metadata               `json:"metadata"`
+	Results  StreamBenchmarkResults `json:"results"`
+}
+
+// Metadata describes the environment of a benchmark run, so that saved results
+// remain interpretable, and comparable to each other, long after the run.
+type Metadata struct {
+	// RunID identifies the run. It looks like a Git commit hash.
+	RunID string `json:"run_id"`
+	// StartedAt and FinishedAt are when the requests of the run started and ended.
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Version is the version of llmb that ran the benchmark.
+	Version string `json:"version"`
+	// URL is the base URL of the benchmarked API, without credentials or query values.
+	URL   string `json:"url"`
+	Model string `json:"model"`
+	// Flags holds the values of all the flags of the run by name, including the defaults.
+	Flags map[string]string `json:"flags"`
+	Host  Host              `json:"host"`
+}
+
+// Host describes the client machine that ran a benchmark.
+type Host struct {
+	Hostname  string `json:"hostname"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	CPUs      int    `json:"cpus"`
+	GoVersion string `json:"go_version"`
+}
+
+// CurrentHost returns the description of the machine this process runs on.
+func CurrentHost() Host {
+	// The hostname is informational only, so failing to get it is no reason to fail the run.
+	hostname, _ := os.Hostname()
+	return Host{
+		Hostname:  hostname,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		GoVersion: runtime.Version(),
+	}
+}
+
+// NewRunID returns a random run ID, formatted like a Git commit hash.
+func NewRunID() string {
+	id := make([]byte, 20)
+	_, _ = rand.Read(id) // Never fails, as documented.
+	return hex.EncodeToString(id)
+}
+
+// SanitizeURL removes the credentials and the query values, which may hold API keys,
+// from the given URL. URLs that can't be parsed are dropped altogether.
+func SanitizeURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	parsed.User = nil
+
+	query := parsed.Query()
+	for name := range query {
+		query.Set(name, "REDACTED")
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// metricsJSON is the encoding of Metrics, with the durations in milliseconds, for them to be readable by
+// people and other tools alike.
+type metricsJSON struct {
+	Avg float64 `json:"avg_ms"`
+	Min float64 `json:"min_ms"`
+	Med float64 `json:"p50_ms"`
+	Max float64 `json:"max_ms"`
+	P90 float64 `json:"p90_ms"`
+	P95 float64 `json:"p95_ms"`
+}
+
+// MarshalJSON encodes the metrics with the durations in milliseconds.
+func (m Metrics) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metricsJSON{
+		Avg: millis(m.Avg),
+		Min: millis(m.Min),
+		Med: millis(m.Med),
+		Max: millis(m.Max),
+		P90: millis(m.P90),
+		P95: millis(m.P95),
+	})
+}
+
+// UnmarshalJSON decodes metrics encoded by MarshalJSON.
+func (m *Metrics) UnmarshalJSON(data []byte) error {
+	var encoded metricsJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	*m = Metrics{
+		Avg: fromMillis(encoded.Avg),
+		Min: fromMillis(encoded.Min),
+		Med: fromMillis(encoded.Med),
+		Max: fromMillis(encoded.Max),
+		P90: fromMillis(encoded.P90),
+		P95: fromMillis(encoded.P95),
+	}
+	return nil
+}
+
+// results is StreamBenchmarkResults without its methods, for them to encode the fields that need no conversion.
+type results StreamBenchmarkResults
+
+// resultsJSON is the encoding of StreamBenchmarkResults, with the elapsed time in milliseconds.
+type resultsJSON struct {
+	results
+	Elapsed float64 `json:"elapsed_ms"`
+}
+
+// MarshalJSON encodes the results, with the elapsed time in milliseconds.
+func (r StreamBenchmarkResults) MarshalJSON() ([]byte, error) {
+	return json.Marshal(resultsJSON{results: results(r), Elapsed: millis(r.Elapsed)})
+}
+
+// UnmarshalJSON decodes results encoded by MarshalJSON.
+func (r *StreamBenchmarkResults) UnmarshalJSON(data []byte) error {
+	var encoded resultsJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	*r = StreamBenchmarkResults(encoded.results)
+	r.Elapsed = fromMillis(encoded.Elapsed)
+	return nil
+}
+
+// The formats that a report can be encoded in.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Encode writes the report to the writer in the given format, either as indented JSON, like Save does,
+// or as YAML with the same fields.
+func (r *Report) Encode(writer io.Writer, format string) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	switch format {
+	case FormatJSON:
+		_, err = writer.Write(append(content, '\n'))
+	case FormatYAML:
+		// JSON is YAML, so converting it keeps the fields in order, and named like in JSON.
+		var document yaml.Node
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return fmt.Errorf("failed to convert report to YAML: %w", err)
+		}
+		clearStyle(&document)
+
+		encoder := yaml.NewEncoder(writer)
+		encoder.SetIndent(2)
+		if err = encoder.Encode(&document); err == nil {
+			err = encoder.Close()
+		}
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// clearStyle resets the style of the node and its descendants, like the flow style and the quotes
+// of JSON, so that they are encoded in the block style of YAML. Scalars are still quoted as needed.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// Save writes the report as JSON to the file at the given path, creating parent directories as required.
+func (r *Report) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+	return nil
+}
+
+// LoadReport reads a report from the file at the given path.
+func LoadReport(path string) (*Report, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report file: %w", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal report file: %w", err)
+	}
+	return &report, nil
+}
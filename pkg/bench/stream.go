@@ -14,6 +14,14 @@ type Event interface {
 	Timestamp() time.Time // The time the event was produced or received.
 }
 
+// TokenCounter is an optional interface of events. An event that reports the number of
+// tokens generated in its stream, like the usage event of a chat completion stream, is
+// not timed as a token, and its count is used to compute the throughput.
+type TokenCounter interface {
+	// CompletionTokens returns the number of tokens generated in the stream, and whether the event reports it.
+	CompletionTokens() (int, bool)
+}
+
 // StreamFunc represents any operation that produces a cancellable stream of events.
 // This is the primary input to the benchmark runner.
 type StreamFunc func(ctx context.Context) (*streams.Stream[Event], error)
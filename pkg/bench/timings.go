@@ -6,8 +6,12 @@ import (
 
 // timings holds the complete timing information of a single stream run.
 type timings struct {
+	// Request is the number of the request, from 1, in the order that the requests were started.
+	Request    int
 	Start, End time.Time
 	Events     []time.Time
+	// Tokens is the number of tokens generated in the stream, if reported, and zero otherwise.
+	Tokens int
 }
 
 // timingsArray represents the collection of timing information from multiple
@@ -51,6 +55,39 @@ func (a timingsArray) TBTs() []time.Duration {
 	return out
 }
 
+// Throughput returns the total number of generated tokens of the stream runs that reported it, and
+// their average generation rate in tokens per second, over their total time.
+func (a timingsArray) Throughput() (tokens int, tokensPerSecond float64) {
+	var elapsed time.Duration
+	for _, t := range a {
+		if t.Tokens > 0 {
+			tokens += t.Tokens
+			elapsed += t.End.Sub(t.Start)
+		}
+	}
+	if elapsed <= 0 {
+		return tokens, 0
+	}
+	return tokens, float64(tokens) / elapsed.Seconds()
+}
+
+// Elapsed returns the time from the start of the earliest stream run to the end of the latest one.
+func (a timingsArray) Elapsed() time.Duration {
+	if len(a) == 0 {
+		return 0
+	}
+	first, last := a[0].Start, a[0].End
+	for _, t := range a[1:] {
+		if t.Start.Before(first) {
+			first = t.Start
+		}
+		if t.End.After(last) {
+			last = t.End
+		}
+	}
+	return last.Sub(first)
+}
+
 // TTs accumulates the Total Time (TT) for each stream run into a single slice.
 func (a timingsArray) TTs() []time.Duration {
 	out := make([]time.Duration, len(a))
@@ -59,3 +96,59 @@ func (a timingsArray) TTs() []time.Duration {
 	}
 	return out
 }
+
+// StreamStats holds the timing metrics of a single stream, like of a chat response.
+type StreamStats struct {
+	TTFT  time.Duration // Time To First Token.
+	Total time.Duration // Total Time.
+
+	// Tokens is the number of generated tokens, as reported by the stream (see TokenCounter),
+	// or else the number of timed events, which is an estimate, as Estimated tells.
+	Tokens    int
+	Estimated bool
+	// TokensPerSecond is the rate at which the tokens were generated, over the total time.
+	TokensPerSecond float64
+}
+
+// StreamTimer times the events of a single stream as they are consumed, the way benchmarks do,
+// for reporting the metrics of individual streams, like chat responses.
+type StreamTimer struct {
+	timings timings
+}
+
+// NewStreamTimer returns a timer for a stream that started at the given time.
+func NewStreamTimer(start time.Time) *StreamTimer {
+	return &StreamTimer{timings: timings{Start: start}}
+}
+
+// Add records an event of the stream. Events that report the token count of the stream are not timed.
+func (s *StreamTimer) Add(event Event) {
+	if counter, ok := event.(TokenCounter); ok {
+		if tokens, ok := counter.CompletionTokens(); ok {
+			s.timings.Tokens = tokens
+			return
+		}
+	}
+	s.timings.Events = append(s.timings.Events, event.Timestamp())
+}
+
+// Stop records that the stream ended at the given time, and returns its metrics.
+func (s *StreamTimer) Stop(end time.Time) StreamStats {
+	s.timings.End = end
+	return s.timings.stats()
+}
+
+// stats returns the metrics of the stream run.
+func (t timings) stats() StreamStats {
+	stats := StreamStats{Total: t.End.Sub(t.Start), Tokens: t.Tokens}
+	if ttfts := (timingsArray{t}).TTFTs(); len(ttfts) > 0 {
+		stats.TTFT = ttfts[0]
+	}
+	if stats.Tokens == 0 {
+		stats.Tokens, stats.Estimated = len(t.Events), true
+	}
+	if stats.Total > 0 {
+		stats.TokensPerSecond = float64(stats.Tokens) / stats.Total.Seconds()
+	}
+	return stats
+}
@@ -0,0 +1,270 @@
+// Package cache provides an in-memory and on-disk cache of API responses, so that repeated identical
+// requests are answered instantly, without calling the API.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shivanshkc/llmb/pkg/clock"
+)
+
+// streamEnd is the last event of a complete stream of server-sent events.
+var streamEnd = []byte("data: [DONE]")
+
+// Transport is an http.RoundTripper that caches successful responses in memory, and in a directory.
+//
+// Requests are identified by their method, URL and body, which holds the model, the messages and
+// all the parameters. Only requests with a body are cached, and only their successful responses,
+// once they are read completely.
+//
+// The zero value caches in memory only, which suits deterministic replays in tests.
+type Transport struct {
+	// Dir is the directory of the cache. It is created as required.
+	// If empty, responses are only cached in memory, for the lifetime of the Transport.
+	Dir string
+	// TTL is how long a response remains valid. Zero means forever.
+	TTL time.Duration
+	// Refresh skips the lookup of cached responses, while still caching the new ones.
+	Refresh bool
+	// Transport performs the requests that are not in the cache. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+	// Clock, if set, tells the age of the entries instead of the real clock.
+	Clock clock.Clock
+
+	// memory holds the entries used or stored by this Transport, by key, in front of the directory.
+	mu     sync.Mutex
+	memory map[string]entry
+}
+
+// entry is a cached response, as stored in its file.
+type entry struct {
+	Created     time.Time `json:"created"`
+	ContentType string    `json:"content_type"`
+	Body        string    `json:"body"`
+}
+
+// RoundTrip returns the cached response to the request if there is a valid one,
+// and otherwise performs the request and arranges for its response to be cached.
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if request.Body == nil || request.GetBody == nil {
+		return transport.RoundTrip(request)
+	}
+
+	key, err := Key(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Refresh {
+		if cached, ok := t.lookup(key); ok {
+			return &http.Response{
+				Status:        "200 OK",
+				StatusCode:    http.StatusOK,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        http.Header{"Content-Type": {cached.ContentType}},
+				Body:          io.NopCloser(strings.NewReader(cached.Body)),
+				ContentLength: int64(len(cached.Body)),
+				Request:       request,
+			}, nil
+		}
+	}
+
+	response, err := transport.RoundTrip(request)
+	if err != nil || response.StatusCode != http.StatusOK {
+		return response, err
+	}
+
+	response.Body = &cachingBody{ReadCloser: response.Body, done: func(body []byte) {
+		t.store(key, entry{
+			Created:     clock.OrReal(t.Clock).Now(),
+			ContentType: response.Header.Get("Content-Type"),
+			Body:        string(body),
+		})
+	}}
+	return response, nil
+}
+
+// Key returns the cache key of the request, a hash of its method, URL and body.
+func Key(request *http.Request) (string, error) {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%s %s\n", request.Method, request.URL)
+
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return "", fmt.Errorf("failed to get request body: %w", err)
+		}
+		defer func() { _ = body.Close() }()
+		if _, err := io.Copy(hash, body); err != nil {
+			return "", fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// lookup returns the entry with the given key, from memory, or else from its file, if there is one
+// and it has not expired. Entries found in files are kept in memory for the next lookups.
+func (t *Transport) lookup(key string) (entry, bool) {
+	t.mu.Lock()
+	cached, ok := t.memory[key]
+	t.mu.Unlock()
+
+	if !ok {
+		if cached, ok = t.read(key); !ok {
+			return entry{}, false
+		}
+		t.remember(key, cached)
+	}
+
+	if t.TTL > 0 && clock.OrReal(t.Clock).Now().Sub(cached.Created) > t.TTL {
+		return entry{}, false
+	}
+	return cached, true
+}
+
+// read returns the entry in the file of the given key, if there is one.
+func (t *Transport) read(key string) (entry, bool) {
+	if t.Dir == "" {
+		return entry{}, false
+	}
+	content, err := os.ReadFile(filepath.Join(t.Dir, key+".json"))
+	if err != nil {
+		return entry{}, false
+	}
+
+	var cached entry
+	if err := json.Unmarshal(content, &cached); err != nil {
+		return entry{}, false // A corrupt entry is a miss, and is replaced.
+	}
+	return cached, true
+}
+
+// remember keeps the entry in memory under the given key.
+func (t *Transport) remember(key string, cached entry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.memory == nil {
+		t.memory = map[string]entry{}
+	}
+	t.memory[key] = cached
+}
+
+// store keeps the entry in memory, and writes it to the file of the given key. Failing to cache is not an
+// error of the request, so errors are ignored. The file is replaced atomically, so concurrent lookups
+// never see partial entries.
+func (t *Transport) store(key string, cached entry) {
+	t.remember(key, cached)
+	if t.Dir == "" {
+		return
+	}
+
+	content, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return
+	}
+
+	temp, err := os.CreateTemp(t.Dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = temp.Write(content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(temp.Name(), filepath.Join(t.Dir, key+".json")) != nil {
+		_ = os.Remove(temp.Name())
+	}
+}
+
+// Clear deletes all the entries of the cache in the given directory, and returns how many there were.
+// A missing directory is an empty cache.
+func Clear(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var count int
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return count, fmt.Errorf("failed to delete cache entry: %w", err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// cachingBody is a response body that keeps a copy of what is read, and passes it on once the response
+// is complete. A response is complete once it is read to the end, or, for a stream, once its last event
+// is read, as readers of streams may close them right after it.
+type cachingBody struct {
+	io.ReadCloser
+	done func(body []byte)
+
+	// The body may be closed while being read, so the state is guarded.
+	mu       sync.Mutex
+	data     bytes.Buffer
+	finished bool
+}
+
+// Read reads from the underlying body and keeps a copy of what was read.
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	b.data.Write(p[:n])
+	b.mu.Unlock()
+
+	if errors.Is(err, io.EOF) {
+		b.finish(true)
+	}
+	return n, err
+}
+
+// Close closes the underlying body, caching the response if it is a complete stream.
+func (b *cachingBody) Close() error {
+	b.finish(false)
+	return b.ReadCloser.Close()
+}
+
+// finish passes on the response if it is complete. It is safe to call more than once.
+func (b *cachingBody) finish(eof bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.finished {
+		return
+	}
+	b.finished = true
+
+	if eof || bytes.Contains(b.data.Bytes(), streamEnd) {
+		b.done(b.data.Bytes())
+	}
+}
@@ -0,0 +1,159 @@
+package cache_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/cache"
+	"github.com/shivanshkc/llmb/pkg/clock"
+)
+
+// newServer starts a server that responds with the given status and a body that counts the requests.
+func newServer(t *testing.T, status int) (string, *atomic.Int32) {
+	t.Helper()
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := count.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(status)
+		_, _ = fmt.Fprintf(w, "data: response %d\n\ndata: [DONE]\n\n", n)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &count
+}
+
+// post sends a request with the given body through the transport, reads the response until the given
+// number of bytes, or entirely if negative, and returns the status and what was read.
+func post(t *testing.T, transport http.RoundTripper, url, body string, limit int64) (int, string) {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+
+	response, err := (&http.Client{Transport: transport}).Do(request)
+	require.NoError(t, err)
+	defer func() { _ = response.Body.Close() }()
+
+	var reader io.Reader = response.Body
+	if limit >= 0 {
+		reader = io.LimitReader(response.Body, limit)
+	}
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return response.StatusCode, string(content)
+}
+
+// TestTransport verifies the caching of responses, their expiry, and the bypass of the cache.
+func TestTransport(t *testing.T) {
+	t.Run("Hits and Misses", func(t *testing.T) {
+		url, count := newServer(t, http.StatusOK)
+		transport := &cache.Transport{Dir: t.TempDir()}
+
+		_, first := post(t, transport, url, `{"model":"a"}`, -1)
+		_, second := post(t, transport, url, `{"model":"a"}`, -1)
+		assert.Equal(t, first, second, "An identical request is served from the cache.")
+		assert.Equal(t, int32(1), count.Load())
+
+		_, other := post(t, transport, url, `{"model":"b"}`, -1)
+		assert.NotEqual(t, first, other, "A different request is not a hit.")
+		assert.Equal(t, int32(2), count.Load())
+	})
+
+	t.Run("Memory Only", func(t *testing.T) {
+		url, count := newServer(t, http.StatusOK)
+		transport := &cache.Transport{}
+
+		_, first := post(t, transport, url, `{}`, -1)
+		_, second := post(t, transport, url, `{}`, -1)
+		assert.Equal(t, first, second)
+		assert.Equal(t, int32(1), count.Load(), "The zero value caches in memory.")
+	})
+
+	t.Run("Memory Before Disk", func(t *testing.T) {
+		url, count := newServer(t, http.StatusOK)
+		dir := t.TempDir()
+		transport := &cache.Transport{Dir: dir}
+
+		post(t, transport, url, `{}`, -1)
+		_, err := cache.Clear(dir)
+		require.NoError(t, err)
+
+		post(t, transport, url, `{}`, -1)
+		assert.Equal(t, int32(1), count.Load(), "The entry is still in memory.")
+
+		post(t, &cache.Transport{Dir: dir}, url, `{}`, -1)
+		assert.Equal(t, int32(2), count.Load(), "Another transport only has the directory.")
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		url, count := newServer(t, http.StatusOK)
+		fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		transport := &cache.Transport{Dir: t.TempDir(), TTL: time.Hour, Clock: fake}
+
+		post(t, transport, url, `{}`, -1)
+		fake.Advance(59 * time.Minute)
+		post(t, transport, url, `{}`, -1)
+		assert.Equal(t, int32(1), count.Load())
+
+		fake.Advance(2 * time.Minute)
+		post(t, transport, url, `{}`, -1)
+		assert.Equal(t, int32(2), count.Load(), "An expired entry is a miss.")
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		url, count := newServer(t, http.StatusOK)
+		dir := t.TempDir()
+
+		post(t, &cache.Transport{Dir: dir}, url, `{}`, -1)
+		_, refreshed := post(t, &cache.Transport{Dir: dir, Refresh: true}, url, `{}`, -1)
+		assert.Equal(t, int32(2), count.Load(), "A refresh skips the lookup.")
+
+		_, cached := post(t, &cache.Transport{Dir: dir}, url, `{}`, -1)
+		assert.Equal(t, refreshed, cached, "A refresh stores the new response.")
+		assert.Equal(t, int32(2), count.Load())
+	})
+
+	t.Run("Errors Are Not Cached", func(t *testing.T) {
+		url, count := newServer(t, http.StatusInternalServerError)
+		transport := &cache.Transport{Dir: t.TempDir()}
+
+		post(t, transport, url, `{}`, -1)
+		status, _ := post(t, transport, url, `{}`, -1)
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.Equal(t, int32(2), count.Load())
+	})
+
+	t.Run("Incomplete Responses Are Not Cached", func(t *testing.T) {
+		url, count := newServer(t, http.StatusOK)
+		transport := &cache.Transport{Dir: t.TempDir()}
+
+		post(t, transport, url, `{}`, 5)
+		post(t, transport, url, `{}`, -1)
+		assert.Equal(t, int32(2), count.Load())
+	})
+}
+
+// TestClear verifies the deletion of all entries.
+func TestClear(t *testing.T) {
+	url, _ := newServer(t, http.StatusOK)
+	dir := t.TempDir()
+	transport := &cache.Transport{Dir: dir}
+	post(t, transport, url, `{"a":1}`, -1)
+	post(t, transport, url, `{"a":2}`, -1)
+
+	count, err := cache.Clear(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = cache.Clear(t.TempDir() + "/missing")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
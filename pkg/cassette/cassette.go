@@ -0,0 +1,99 @@
+// Package cassette records API responses, along with their timing, to cassette files,
+// and replays them later, so that streams can be reproduced without a live API server.
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cassette is a recording of a sequence of request-response interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	RequestBody json.RawMessage `json:"request_body,omitempty"`
+
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	// HeaderOffset is the time between the request and the response headers.
+	HeaderOffset Duration `json:"header_offset"`
+	// Chunks make up the response body. For streams, each chunk is one server-sent event.
+	Chunks []Chunk `json:"chunks"`
+}
+
+// Chunk is a part of a response body, with the time at which it arrived.
+type Chunk struct {
+	// Offset is the time between the request and the arrival of the chunk.
+	Offset Duration `json:"offset"`
+	Data   string   `json:"data"`
+}
+
+// Duration is a time.Duration that is represented in JSON as a string like "1.5s", for readability.
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes the duration from a string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// Load reads a cassette from the JSON file at the given path.
+func Load(path string) (*Cassette, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette file: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(content, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cassette file: %w", err)
+	}
+
+	if len(cassette.Interactions) == 0 {
+		return nil, errors.New("cassette has no interactions")
+	}
+
+	return &cassette, nil
+}
+
+// Save writes the cassette as JSON to the file at the given path, creating its directory if needed.
+func (c *Cassette) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette file: %w", err)
+	}
+
+	return nil
+}
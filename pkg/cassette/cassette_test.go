@@ -0,0 +1,130 @@
+package cassette_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/cassette"
+	"github.com/shivanshkc/llmb/pkg/mock"
+)
+
+// streamContent runs a chat completion stream with the given client and returns the
+// streamed content, along with the time taken by the first content chunk.
+func streamContent(t *testing.T, client *api.Client) (string, time.Duration) {
+	t.Helper()
+
+	start := time.Now()
+	stream, err := client.ChatCompletionStream(context.Background(), "m",
+		[]api.ChatMessage{{Role: api.RoleUser, Content: "Hi"}}, api.ChatOptions{})
+	require.NoError(t, err)
+
+	events, err := stream.Drain(context.Background())
+	require.NoError(t, err)
+
+	var content strings.Builder
+	var ttft time.Duration
+	for _, event := range events {
+		if len(event.Choices) > 0 && event.Choices[0].Delta.Content != "" {
+			if ttft == 0 {
+				ttft = event.Timestamp().Sub(start)
+			}
+			content.WriteString(event.Choices[0].Delta.Content)
+		}
+	}
+	return content.String(), ttft
+}
+
+// TestRecordAndReplay verifies that a recorded stream is replayed with the same content and timing.
+func TestRecordAndReplay(t *testing.T) {
+	const ttft = 80 * time.Millisecond
+	server := httptest.NewServer(mock.New(mock.Config{TTFT: ttft, TokenRate: 500, Tokens: 5}))
+	defer server.Close()
+
+	// Record.
+	recorder := &cassette.Recorder{}
+	recordedContent, _ := streamContent(t, api.NewClient(server.URL, api.WithTransport(recorder)))
+	require.NotEmpty(t, recordedContent)
+
+	path := filepath.Join(t.TempDir(), "cassettes", "chat.json")
+	require.NoError(t, recorder.Cassette().Save(path))
+
+	loaded, err := cassette.Load(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Interactions, 1)
+
+	interaction := loaded.Interactions[0]
+	assert.Equal(t, "/v1/chat/completions", interaction.Path)
+	assert.Equal(t, http.StatusOK, interaction.Status)
+	var request struct{ Model string }
+	require.NoError(t, json.Unmarshal(interaction.RequestBody, &request))
+	assert.Equal(t, "m", request.Model)
+	assert.Len(t, interaction.Chunks, 7, "5 tokens, the finish event and [DONE] should be separate chunks.")
+
+	// The mock server is gone, so replaying can only use the cassette.
+	server.Close()
+
+	t.Run("Through the Client", func(t *testing.T) {
+		replayedContent, replayedTTFT := streamContent(t, api.NewClient("http://unused", api.WithTransport(cassette.NewPlayer(loaded))))
+		assert.Equal(t, recordedContent, replayedContent)
+		assert.GreaterOrEqual(t, replayedTTFT, ttft, "The replay should keep the recorded timing.")
+	})
+
+	t.Run("Through a Server", func(t *testing.T) {
+		replayServer := httptest.NewServer(cassette.NewPlayer(loaded))
+		defer replayServer.Close()
+
+		replayedContent, replayedTTFT := streamContent(t, api.NewClient(replayServer.URL))
+		assert.Equal(t, recordedContent, replayedContent)
+		assert.GreaterOrEqual(t, replayedTTFT, ttft)
+	})
+}
+
+// TestPlayer_Cycle verifies that the interactions of a path are replayed in order, cyclically.
+func TestPlayer_Cycle(t *testing.T) {
+	player := cassette.NewPlayer(&cassette.Cassette{Interactions: []cassette.Interaction{
+		{Path: "/a", Status: http.StatusOK, Chunks: []cassette.Chunk{{Data: "first"}}},
+		{Path: "/b", Status: http.StatusOK, Chunks: []cassette.Chunk{{Data: "other"}}},
+		{Path: "/a", Status: http.StatusTooManyRequests, Chunks: []cassette.Chunk{{Data: "second"}}},
+	}})
+	client := &http.Client{Transport: player}
+
+	get := func(path string) (int, string) {
+		response, err := client.Get("http://unused" + path)
+		require.NoError(t, err)
+		defer func() { _ = response.Body.Close() }()
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		return response.StatusCode, string(body)
+	}
+
+	for _, want := range []string{"first", "second", "first"} {
+		_, body := get("/a")
+		assert.Equal(t, want, body)
+	}
+
+	status, _ := get("/a")
+	assert.Equal(t, http.StatusTooManyRequests, status)
+
+	_, err := client.Get("http://unused/missing")
+	assert.Error(t, err)
+}
+
+// TestLoad verifies the validation of cassette files.
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.json")
+	require.NoError(t, (&cassette.Cassette{}).Save(path))
+
+	_, err := cassette.Load(path)
+	assert.Error(t, err, "A cassette without interactions should be rejected.")
+}
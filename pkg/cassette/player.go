@@ -0,0 +1,175 @@
+package cassette
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Player replays the interactions of a cassette, with their original timing.
+//
+// Every request is answered with the next recorded interaction of the same path, cycling
+// through them in order. It can be used as an http.RoundTripper in a client,
+// or as an http.Handler in a server.
+type Player struct {
+	cassette *Cassette
+
+	mu   sync.Mutex
+	next map[string]int
+}
+
+// NewPlayer returns a Player for the given cassette.
+func NewPlayer(cassette *Cassette) *Player {
+	return &Player{cassette: cassette, next: map[string]int{}}
+}
+
+// RoundTrip answers the request with the next recorded interaction.
+// The body of the response produces the recorded chunks at their recorded times.
+func (p *Player) RoundTrip(request *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	// Drain the request body, as a real transport would.
+	if request.Body != nil {
+		_, _ = io.Copy(io.Discard, request.Body)
+		_ = request.Body.Close()
+	}
+
+	interaction, err := p.nextInteraction(request.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := waitUntil(request.Context(), start.Add(time.Duration(interaction.HeaderOffset))); err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	if interaction.ContentType != "" {
+		header.Set("Content-Type", interaction.ContentType)
+	}
+
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode: interaction.Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       newReplayBody(request.Context(), start, interaction.Chunks),
+		Request:    request,
+	}, nil
+}
+
+// ServeHTTP answers the request with the next recorded interaction, writing the recorded
+// chunks at their recorded times.
+func (p *Player) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	interaction, err := p.nextInteraction(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if waitUntil(r.Context(), start.Add(time.Duration(interaction.HeaderOffset))) != nil {
+		return
+	}
+
+	if interaction.ContentType != "" {
+		w.Header().Set("Content-Type", interaction.ContentType)
+	}
+	w.WriteHeader(interaction.Status)
+
+	for _, chunk := range interaction.Chunks {
+		if waitUntil(r.Context(), start.Add(time.Duration(chunk.Offset))) != nil {
+			return
+		}
+		_, _ = io.WriteString(w, chunk.Data)
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+}
+
+// nextInteraction returns the next interaction for the given path.
+func (p *Player) nextInteraction(path string) (*Interaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var matches []*Interaction
+	for i := range p.cassette.Interactions {
+		if p.cassette.Interactions[i].Path == path {
+			matches = append(matches, &p.cassette.Interactions[i])
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("cassette has no interaction for path %q", path)
+	}
+
+	interaction := matches[p.next[path]%len(matches)]
+	p.next[path]++
+	return interaction, nil
+}
+
+// replayBody is a response body that produces the given chunks at their offsets from the start time.
+type replayBody struct {
+	// ctx is canceled when the body is closed, which may happen while it is being read.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	start  time.Time
+	chunks []Chunk
+	// remaining holds the part of the current chunk that is not read yet.
+	remaining *strings.Reader
+}
+
+// newReplayBody returns a replayBody that stops producing chunks when the given context is canceled.
+func newReplayBody(ctx context.Context, start time.Time, chunks []Chunk) *replayBody {
+	ctx, cancel := context.WithCancel(ctx)
+	return &replayBody{ctx: ctx, cancel: cancel, start: start, chunks: chunks}
+}
+
+// Read blocks until the next chunk is due, then reads from it.
+func (b *replayBody) Read(p []byte) (int, error) {
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+	for b.remaining == nil || b.remaining.Len() == 0 {
+		if len(b.chunks) == 0 {
+			return 0, io.EOF
+		}
+		if err := waitUntil(b.ctx, b.start.Add(time.Duration(b.chunks[0].Offset))); err != nil {
+			return 0, err
+		}
+		b.remaining, b.chunks = strings.NewReader(b.chunks[0].Data), b.chunks[1:]
+	}
+	return b.remaining.Read(p)
+}
+
+// Close discards the rest of the body, unblocking any pending read.
+func (b *replayBody) Close() error {
+	b.cancel()
+	return nil
+}
+
+// waitUntil blocks until the given time, or until the context is canceled.
+func waitUntil(ctx context.Context, deadline time.Time) error {
+	delay := time.Until(deadline)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,147 @@
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Recorder is an http.RoundTripper that records every interaction that passes through it.
+//
+// An interaction is recorded once its response body is read completely or closed.
+type Recorder struct {
+	// Transport performs the actual requests. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// RoundTrip performs the request and arranges for the interaction to be recorded.
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	interaction := &Interaction{Method: request.Method, Path: request.URL.Path}
+	if request.Body != nil && request.GetBody != nil {
+		// Read a copy of the body, so the original remains untouched for the transport.
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get request body: %w", err)
+		}
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		// Only JSON bodies are kept, as those are the ones useful for identifying a request.
+		if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			interaction.RequestBody = trimmed
+		}
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	response, err := transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction.Status = response.StatusCode
+	interaction.ContentType = response.Header.Get("Content-Type")
+	interaction.HeaderOffset = Duration(time.Since(start))
+
+	response.Body = &recordingBody{ReadCloser: response.Body, start: start, interaction: interaction, done: r.add}
+	return response, nil
+}
+
+// Cassette returns the cassette of all the interactions recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// add adds a complete interaction to the cassette.
+func (r *Recorder) add(interaction *Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, *interaction)
+}
+
+// recordingBody is a response body that records the chunks of the response as they are read.
+type recordingBody struct {
+	io.ReadCloser
+	start       time.Time
+	interaction *Interaction
+	done        func(*Interaction)
+
+	// The body may be closed while being read, so the recording state is guarded.
+	mu sync.Mutex
+	// pending holds the data of the chunk that is not complete yet.
+	pending  []byte
+	finished bool
+}
+
+// Read reads from the underlying body and records what was read.
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.mu.Lock()
+		if !b.finished {
+			b.pending = append(b.pending, p[:n]...)
+			b.cutChunks()
+		}
+		b.mu.Unlock()
+	}
+	if errors.Is(err, io.EOF) {
+		b.finish()
+	}
+	return n, err
+}
+
+// Close closes the underlying body, recording whatever was read.
+func (b *recordingBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+// cutChunks moves every complete server-sent event from the pending data to the interaction.
+// An event ends with a blank line.
+func (b *recordingBody) cutChunks() {
+	for {
+		end := bytes.Index(b.pending, []byte("\n\n"))
+		if end < 0 {
+			return
+		}
+		b.addChunk(b.pending[:end+2])
+		b.pending = b.pending[end+2:]
+	}
+}
+
+// addChunk records the given data as a chunk that arrived now.
+func (b *recordingBody) addChunk(data []byte) {
+	b.interaction.Chunks = append(b.interaction.Chunks, Chunk{Offset: Duration(time.Since(b.start)), Data: string(data)})
+}
+
+// finish records the remaining data, which is the whole body for responses that aren't streams,
+// and completes the interaction. It is safe to call more than once.
+func (b *recordingBody) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.finished {
+		return
+	}
+	b.finished = true
+
+	if len(b.pending) > 0 {
+		b.addChunk(b.pending)
+		b.pending = nil
+	}
+	b.done(b.interaction)
+}
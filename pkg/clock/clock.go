@@ -0,0 +1,94 @@
+// Package clock abstracts the reading of time and the waiting for durations, so that timing code
+// can be tested deterministically.
+//
+// The Real clock reads the system time, whose values carry a monotonic reading. Durations computed
+// from them, with Sub or Since, are immune to changes of the wall clock, like NTP adjustments.
+// The Fake clock only moves when told to, or when something waits on it.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for durations.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer returns a Timer that fires once the given duration has passed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event in the future, like time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is sent once the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing. It returns false if the timer already fired.
+	Stop() bool
+}
+
+// Real is the Clock of the system.
+var Real Clock = realClock{}
+
+// OrReal returns the given clock, or the Real clock if it is nil.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// realClock is the Clock of the system.
+type realClock struct{}
+
+// Now returns the current time, with a monotonic reading.
+func (realClock) Now() time.Time { return time.Now() }
+
+// NewTimer returns a Timer backed by time.Timer.
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+// realTimer is a Timer backed by time.Timer.
+type realTimer struct{ timer *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.timer.C }
+func (t realTimer) Stop() bool          { return t.timer.Stop() }
+
+// Fake is a Clock for tests. Its time only moves forward with Advance, or when a timer is created:
+// waiting is instant, as the clock jumps to the moment the timer fires.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake returns a Fake clock that starts at the given time.
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now returns the current time of the clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Advance moves the clock forward by the given duration.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// NewTimer moves the clock forward by the given duration, and returns a Timer that already fired.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.Advance(d)
+	c := make(chan time.Time, 1)
+	c <- f.Now()
+	return fakeTimer{c: c}
+}
+
+// fakeTimer is a Timer of the Fake clock, which fires when it is created.
+type fakeTimer struct{ c chan time.Time }
+
+func (t fakeTimer) C() <-chan time.Time { return t.c }
+func (t fakeTimer) Stop() bool          { return false }
@@ -0,0 +1,48 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shivanshkc/llmb/pkg/clock"
+)
+
+// TestFake verifies that the fake clock only moves when advanced or waited on.
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	assert.Equal(t, start, fake.Now())
+
+	fake.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), fake.Now())
+
+	timer := fake.NewTimer(time.Minute)
+	select {
+	case fired := <-timer.C():
+		assert.Equal(t, start.Add(time.Second+time.Minute), fired)
+	default:
+		assert.Fail(t, "The timer of the fake clock fires at once.")
+	}
+	assert.Equal(t, start.Add(time.Second+time.Minute), fake.Now())
+	assert.False(t, timer.Stop(), "The timer already fired.")
+}
+
+// TestOrReal verifies the default to the real clock.
+func TestOrReal(t *testing.T) {
+	assert.Equal(t, clock.Real, clock.OrReal(nil))
+
+	fake := clock.NewFake(time.Time{})
+	assert.Equal(t, fake, clock.OrReal(fake))
+}
+
+// TestReal verifies that the real clock reads the monotonic time and waits for real.
+func TestReal(t *testing.T) {
+	now := clock.Real.Now()
+	assert.Contains(t, now.String(), "m=", "Times of the real clock carry a monotonic reading.")
+
+	timer := clock.Real.NewTimer(time.Millisecond)
+	<-timer.C()
+	assert.GreaterOrEqual(t, time.Since(now), time.Millisecond)
+}
@@ -0,0 +1,256 @@
+// Package config provides the llmb configuration file, which holds named provider profiles.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of the configuration file.
+type Config struct {
+	// DefaultProfile is the profile used when none is selected explicitly.
+	DefaultProfile string `yaml:"default_profile"`
+	// Profiles maps profile names to their settings.
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile holds the settings of one provider.
+//
+// All fields are optional. Unset fields leave the corresponding settings at their defaults.
+type Profile struct {
+	BaseURL string `yaml:"base_url,omitempty"`
+	Model   string `yaml:"model,omitempty"`
+	// Provider is the name of a provider plugin to use instead of calling the API at BaseURL.
+	Provider string `yaml:"provider,omitempty"`
+
+	// APIKey is a reference to the API key, so that the key itself doesn't have to be in the file.
+	// It is either "env:NAME" to read the environment variable NAME,
+	// or "file:PATH" to read the file at PATH. Anything else is taken as the literal key.
+	APIKey string `yaml:"api_key,omitempty"`
+
+	// Insecure skips the verification of the server's TLS certificate.
+	Insecure bool `yaml:"insecure,omitempty"`
+	// CACert is a PEM file of CA certificates to trust in addition to the system ones.
+	CACert string `yaml:"cacert,omitempty"`
+	// Cert and Key are the PEM files of the client certificate, for servers that require mutual TLS.
+	Cert string `yaml:"cert,omitempty"`
+	Key  string `yaml:"key,omitempty"`
+	// Proxy is the URL of an HTTP(S) or SOCKS5 proxy for calling the API.
+	Proxy string `yaml:"proxy,omitempty"`
+
+	// Organization and Project are sent in the OpenAI-Organization and OpenAI-Project headers,
+	// for accounting the usage to them.
+	Organization string `yaml:"organization,omitempty"`
+	Project      string `yaml:"project,omitempty"`
+	// User identifies the end user in the user field of chat requests, for abuse attribution.
+	User string `yaml:"user,omitempty"`
+
+	// Headers are extra headers sent with every request.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Params are the default request parameters, which the flags override.
+	Params Params `yaml:"params,omitempty"`
+
+	Retry Retry `yaml:"retry,omitempty"`
+	// Timeout limits the time of every attempt of a request, including the streaming of the response.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// TranscriptDir is the directory that chat logs every session transcript to.
+	TranscriptDir string `yaml:"transcript_dir,omitempty"`
+}
+
+// Params are the default request parameters of a profile. Unset fields leave the parameters to the flags,
+// or else to the server's defaults.
+type Params struct {
+	Temperature      *float64 `yaml:"temperature,omitempty"`
+	TopP             *float64 `yaml:"top_p,omitempty"`
+	MaxTokens        int      `yaml:"max_tokens,omitempty"`
+	PresencePenalty  *float64 `yaml:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `yaml:"frequency_penalty,omitempty"`
+	Stop             []string `yaml:"stop,omitempty"`
+	Seed             *int     `yaml:"seed,omitempty"`
+	// Extra holds additional fields of the request body, for the parameters that only some servers support.
+	Extra map[string]any `yaml:"extra,omitempty"`
+}
+
+// validate checks that the parameters are within their ranges.
+func (p Params) validate() error {
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+		return errors.New("temperature must be between 0 and 2")
+	}
+	if p.TopP != nil && (*p.TopP < 0 || *p.TopP > 1) {
+		return errors.New("top_p must be between 0 and 1")
+	}
+	if p.MaxTokens < 0 {
+		return errors.New("max_tokens must not be negative")
+	}
+	for _, penalty := range []*float64{p.PresencePenalty, p.FrequencyPenalty} {
+		if penalty != nil && (*penalty < -2 || *penalty > 2) {
+			return errors.New("presence and frequency penalties must be between -2 and 2")
+		}
+	}
+	return nil
+}
+
+// Retry is the retry policy of a profile.
+type Retry struct {
+	// MaxAttempts is the maximum number of attempts per request. Zero means the default.
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+	// Delay is the delay between consecutive attempts. Zero means the default.
+	Delay time.Duration `yaml:"delay,omitempty"`
+	// MaxElapsed limits the total time spent retrying a request. Zero means the default.
+	MaxElapsed time.Duration `yaml:"max_elapsed,omitempty"`
+	// Backoff is the strategy of the delays between attempts, either "constant" or "exponential".
+	// Empty means the default.
+	Backoff string `yaml:"backoff,omitempty"`
+}
+
+// Load reads the configuration from the YAML file at the given path.
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config file: %w", err)
+	}
+
+	if config.DefaultProfile != "" {
+		if _, ok := config.Profiles[config.DefaultProfile]; !ok {
+			return nil, fmt.Errorf("default profile %q is not defined", config.DefaultProfile)
+		}
+	}
+
+	for name, profile := range config.Profiles {
+		if profile.Retry.MaxAttempts < 0 || profile.Retry.Delay < 0 || profile.Retry.MaxElapsed < 0 {
+			return nil, fmt.Errorf("negative retry policy in profile %q", name)
+		}
+		if profile.Timeout < 0 {
+			return nil, fmt.Errorf("negative timeout in profile %q", name)
+		}
+		if err := profile.Params.validate(); err != nil {
+			return nil, fmt.Errorf("invalid params in profile %q: %w", name, err)
+		}
+	}
+
+	return &config, nil
+}
+
+// Profile returns the profile with the given name.
+// An empty name selects the default profile, or an empty profile if there's no default.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile %q is not defined", name)
+	}
+	return profile, nil
+}
+
+// ResolveAPIKey returns the API key that the profile's APIKey reference points to.
+func (p Profile) ResolveAPIKey() (string, error) {
+	switch {
+	case strings.HasPrefix(p.APIKey, "env:"):
+		name := strings.TrimPrefix(p.APIKey, "env:")
+		key, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("API key environment variable %q is not set", name)
+		}
+		return key, nil
+
+	case strings.HasPrefix(p.APIKey, "file:"):
+		content, err := os.ReadFile(strings.TrimPrefix(p.APIKey, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key file: %w", err)
+		}
+		key := strings.TrimSpace(string(content))
+		if key == "" {
+			return "", errors.New("API key file is empty")
+		}
+		return key, nil
+
+	default:
+		return p.APIKey, nil
+	}
+}
+
+// SaveProfile saves the profile with the given name to the configuration file at the given path, replacing
+// the profile of that name, if any, and makes it the default profile if asked. The file and its directory
+// are created if they don't exist. The rest of the file is kept, including its comments.
+func SaveProfile(path, name string, profile Profile, makeDefault bool) error {
+	var document yaml.Node
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return fmt.Errorf("failed to unmarshal config file: %w", err)
+	}
+
+	// An empty file has no document.
+	if len(document.Content) == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return errors.New("config file is not a mapping")
+	}
+
+	var profileNode yaml.Node
+	if err := profileNode.Encode(profile); err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	// The default profile goes first in a new file.
+	if makeDefault {
+		*mappingValue(root, "default_profile") = yaml.Node{Kind: yaml.ScalarNode, Value: name}
+	}
+	profiles := mappingValue(root, "profiles")
+	if profiles.Kind != yaml.MappingNode {
+		*profiles = yaml.Node{Kind: yaml.MappingNode}
+	}
+	*mappingValue(profiles, name) = profileNode
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return fmt.Errorf("failed to marshal config file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// The file may hold API keys, so it's private.
+	if err := os.WriteFile(path, buffer.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value of the given key in the mapping node, adding the key if it's missing.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value
+}
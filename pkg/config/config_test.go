@@ -0,0 +1,198 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/config"
+)
+
+// writeConfig writes the given content to a config file in a temporary directory and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// TestLoad verifies the parsing and validation of configuration files.
+func TestLoad(t *testing.T) {
+	t.Run("Valid Config", func(t *testing.T) {
+		path := writeConfig(t, `
+default_profile: local
+profiles:
+  local:
+    base_url: http://localhost:8080
+    model: llama
+  homelab:
+    base_url: https://llm.home.arpa
+    insecure: true
+    cacert: /etc/llmb/ca.pem
+    cert: /etc/llmb/client.pem
+    key: /etc/llmb/client-key.pem
+    proxy: socks5://localhost:1080
+  openai:
+    base_url: https://api.openai.com
+    api_key: env:OPENAI_API_KEY
+    headers:
+      OpenAI-Project: proj
+    retry:
+      max_attempts: 3
+      delay: 500ms
+      max_elapsed: 5s
+      backoff: exponential
+    timeout: 1m
+    params:
+      temperature: 0
+      max_tokens: 512
+      stop: ["END"]
+      extra:
+        min_p: 0.05
+`)
+		cfg, err := config.Load(path)
+		require.NoError(t, err)
+
+		profile, err := cfg.Profile("")
+		require.NoError(t, err)
+		assert.Equal(t, config.Profile{BaseURL: "http://localhost:8080", Model: "llama"}, profile)
+
+		profile, err = cfg.Profile("homelab")
+		require.NoError(t, err)
+		assert.Equal(t, config.Profile{
+			BaseURL: "https://llm.home.arpa", Insecure: true, CACert: "/etc/llmb/ca.pem",
+			Cert: "/etc/llmb/client.pem", Key: "/etc/llmb/client-key.pem", Proxy: "socks5://localhost:1080",
+		}, profile)
+
+		profile, err = cfg.Profile("openai")
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.openai.com", profile.BaseURL)
+		assert.Equal(t, map[string]string{"OpenAI-Project": "proj"}, profile.Headers)
+		assert.Equal(t, config.Retry{
+			MaxAttempts: 3, Delay: 500 * time.Millisecond, MaxElapsed: 5 * time.Second, Backoff: "exponential",
+		}, profile.Retry)
+		assert.Equal(t, time.Minute, profile.Timeout)
+		temperature := 0.0
+		assert.Equal(t, config.Params{
+			Temperature: &temperature, MaxTokens: 512, Stop: []string{"END"}, Extra: map[string]any{"min_p": 0.05},
+		}, profile.Params)
+
+		_, err = cfg.Profile("missing")
+		assert.Error(t, err)
+	})
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "Malformed YAML", content: "profiles: [unclosed"},
+		{name: "Undefined Default Profile", content: "default_profile: nope\nprofiles: {}"},
+		{name: "Negative Retry", content: "profiles:\n  p:\n    retry:\n      max_attempts: -1"},
+		{name: "Negative Timeout", content: "profiles:\n  p:\n    timeout: -1s"},
+		{name: "Temperature Out Of Range", content: "profiles:\n  p:\n    params:\n      temperature: 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.Load(writeConfig(t, tt.content))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("Missing File", func(t *testing.T) {
+		_, err := config.Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("No Default Profile", func(t *testing.T) {
+		cfg, err := config.Load(writeConfig(t, "profiles: {}"))
+		require.NoError(t, err)
+		profile, err := cfg.Profile("")
+		require.NoError(t, err)
+		assert.Equal(t, config.Profile{}, profile)
+	})
+}
+
+// TestProfile_ResolveAPIKey verifies the resolution of API key references.
+func TestProfile_ResolveAPIKey(t *testing.T) {
+	t.Setenv("LLMB_TEST_API_KEY", "from-env")
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("from-file\n"), 0o600))
+
+	tests := []struct {
+		name      string
+		reference string
+		want      string
+		wantErr   bool
+	}{
+		{name: "Empty", reference: "", want: ""},
+		{name: "Literal", reference: "sk-literal", want: "sk-literal"},
+		{name: "Environment Variable", reference: "env:LLMB_TEST_API_KEY", want: "from-env"},
+		{name: "Unset Environment Variable", reference: "env:LLMB_TEST_UNSET_KEY", wantErr: true},
+		{name: "File", reference: "file:" + keyFile, want: "from-file"},
+		{name: "Missing File", reference: "file:" + keyFile + ".missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := config.Profile{APIKey: tt.reference}.ResolveAPIKey()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, key)
+		})
+	}
+}
+
+// TestSaveProfile verifies that profiles are added to new and existing files, replacing those of the same
+// name, and that the rest of existing files is kept, including comments.
+func TestSaveProfile(t *testing.T) {
+	t.Run("New File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "llmb", "config.yaml")
+		profile := config.Profile{BaseURL: "http://localhost:11434", Model: "llama3.1", APIKey: "env:KEY"}
+		require.NoError(t, config.SaveProfile(path, "local", profile, true))
+
+		cfg, err := config.Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, "local", cfg.DefaultProfile)
+		assert.Equal(t, map[string]config.Profile{"local": profile}, cfg.Profiles)
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "the file may hold API keys")
+	})
+
+	t.Run("Existing File", func(t *testing.T) {
+		path := writeConfig(t, `# My providers.
+default_profile: local
+profiles:
+  local:
+    base_url: http://localhost:8080
+  # The hosted one.
+  openai:
+    base_url: https://api.openai.com
+`)
+		profile := config.Profile{BaseURL: "http://localhost:11434", Model: "qwen2.5"}
+		require.NoError(t, config.SaveProfile(path, "local", profile, false))
+		require.NoError(t, config.SaveProfile(path, "groq", config.Profile{Model: "llama"}, false))
+
+		cfg, err := config.Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, "local", cfg.DefaultProfile)
+		assert.Equal(t, profile, cfg.Profiles["local"])
+		assert.Equal(t, "https://api.openai.com", cfg.Profiles["openai"].BaseURL)
+		assert.Equal(t, "llama", cfg.Profiles["groq"].Model)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "# My providers.")
+		assert.Contains(t, string(content), "# The hosted one.")
+	})
+}
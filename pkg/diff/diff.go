@@ -0,0 +1,219 @@
+// Package diff computes line-based differences between two texts, and formats them
+// as unified diffs or as side-by-side rows.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of a line of a diff.
+type Kind int
+
+// The kinds of lines.
+const (
+	// Equal lines are in both texts.
+	Equal Kind = iota
+	// Delete lines are only in the first text.
+	Delete
+	// Insert lines are only in the second text.
+	Insert
+)
+
+// Line is one line of a diff.
+type Line struct {
+	Kind Kind
+	Text string
+}
+
+// Lines returns the shortest edit script that turns the lines a into the lines b,
+// computed from their longest common subsequence.
+func Lines(a, b []string) []Line {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]Line, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, Line{Kind: Equal, Text: a[i]})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Kind: Delete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, Line{Kind: Insert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, Line{Kind: Delete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, Line{Kind: Insert, Text: b[j]})
+	}
+	return lines
+}
+
+// Split splits a text into lines, without a trailing empty line for a final line break.
+func Split(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified formats the diff in the unified format, with the given number of unchanged context lines
+// around every change. The names label the two texts in the header. It returns an empty string
+// if the texts are equal.
+func Unified(lines []Line, nameA, nameB string, context int) string {
+	var builder strings.Builder
+
+	// The line numbers, starting at 1, of the current line in both texts.
+	lineA, lineB := 1, 1
+	for start := 0; start < len(lines); {
+		// Find the next change.
+		first := start
+		for first < len(lines) && lines[first].Kind == Equal {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+
+		// The hunk extends until a gap of more than twice the context without changes.
+		end := first
+		for end < len(lines) {
+			next := end
+			for next < len(lines) && lines[next].Kind == Equal {
+				next++
+			}
+			if next == len(lines) || next-end > 2*context {
+				break
+			}
+			end = next + 1
+		}
+		end = min(end+context, len(lines))
+		hunkStart := max(first-context, start)
+
+		// Advance the line numbers to the start of the hunk.
+		for _, line := range lines[start:hunkStart] {
+			lineA, lineB = advance(line, lineA, lineB)
+		}
+
+		if builder.Len() == 0 {
+			fmt.Fprintf(&builder, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+
+		var countA, countB int
+		for _, line := range lines[hunkStart:end] {
+			if line.Kind != Insert {
+				countA++
+			}
+			if line.Kind != Delete {
+				countB++
+			}
+		}
+		fmt.Fprintf(&builder, "@@ -%s +%s @@\n", hunkRange(lineA, countA), hunkRange(lineB, countB))
+
+		for _, line := range lines[hunkStart:end] {
+			builder.WriteString(Prefix(line.Kind) + line.Text + "\n")
+			lineA, lineB = advance(line, lineA, lineB)
+		}
+		start = end
+	}
+
+	return builder.String()
+}
+
+// Prefix returns the unified diff prefix of the given kind of line.
+func Prefix(kind Kind) string {
+	switch kind {
+	case Delete:
+		return "-"
+	case Insert:
+		return "+"
+	default:
+		return " "
+	}
+}
+
+// advance returns the line numbers of both texts after the given line.
+func advance(line Line, lineA, lineB int) (int, int) {
+	if line.Kind != Insert {
+		lineA++
+	}
+	if line.Kind != Delete {
+		lineB++
+	}
+	return lineA, lineB
+}
+
+// hunkRange formats the range of a hunk header. Empty ranges start at the line before them.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// Row is one row of a side-by-side diff. Rows of insertions have no left line, and rows of deletions no right line.
+type Row struct {
+	Kind        Kind
+	Left, Right string
+	// HasLeft and HasRight tell an empty line from a missing one.
+	HasLeft, HasRight bool
+}
+
+// SideBySide arranges the diff in rows of two columns. Deleted lines followed by inserted lines
+// are paired up as changed rows, whose Kind is Delete.
+func SideBySide(lines []Line) []Row {
+	var rows []Row
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == Equal {
+			rows = append(rows, Row{Kind: Equal, Left: lines[i].Text, Right: lines[i].Text, HasLeft: true, HasRight: true})
+			i++
+			continue
+		}
+
+		// Collect the run of deletions and the run of insertions that follows.
+		var deleted, inserted []string
+		for ; i < len(lines) && lines[i].Kind == Delete; i++ {
+			deleted = append(deleted, lines[i].Text)
+		}
+		for ; i < len(lines) && lines[i].Kind == Insert; i++ {
+			inserted = append(inserted, lines[i].Text)
+		}
+
+		for k := range max(len(deleted), len(inserted)) {
+			row := Row{Kind: Delete}
+			if k < len(deleted) {
+				row.Left, row.HasLeft = deleted[k], true
+			}
+			if k < len(inserted) {
+				row.Right, row.HasRight = inserted[k], true
+			}
+			if !row.HasLeft {
+				row.Kind = Insert
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
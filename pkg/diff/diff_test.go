@@ -0,0 +1,103 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shivanshkc/llmb/pkg/diff"
+)
+
+// TestLines verifies the edit script between two lists of lines.
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []string
+		expected []diff.Line
+	}{
+		{
+			name:     "Equal",
+			a:        []string{"x", "y"},
+			b:        []string{"x", "y"},
+			expected: []diff.Line{{Kind: diff.Equal, Text: "x"}, {Kind: diff.Equal, Text: "y"}},
+		},
+		{
+			name: "Changed Line",
+			a:    []string{"x", "y", "z"},
+			b:    []string{"x", "Y", "z"},
+			expected: []diff.Line{
+				{Kind: diff.Equal, Text: "x"},
+				{Kind: diff.Delete, Text: "y"},
+				{Kind: diff.Insert, Text: "Y"},
+				{Kind: diff.Equal, Text: "z"},
+			},
+		},
+		{
+			name:     "Empty First",
+			a:        nil,
+			b:        []string{"x"},
+			expected: []diff.Line{{Kind: diff.Insert, Text: "x"}},
+		},
+		{
+			name:     "Empty Second",
+			a:        []string{"x"},
+			b:        nil,
+			expected: []diff.Line{{Kind: diff.Delete, Text: "x"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, diff.Lines(tt.a, tt.b))
+		})
+	}
+}
+
+// TestSplit verifies the splitting of texts into lines.
+func TestSplit(t *testing.T) {
+	assert.Nil(t, diff.Split(""))
+	assert.Equal(t, []string{"a", "b"}, diff.Split("a\nb\n"))
+	assert.Equal(t, []string{"a", "", "b"}, diff.Split("a\n\nb"))
+}
+
+// TestUnified verifies the unified format, including the hunk headers and the merging of nearby changes.
+func TestUnified(t *testing.T) {
+	t.Run("Equal", func(t *testing.T) {
+		lines := diff.Lines([]string{"a"}, []string{"a"})
+		assert.Empty(t, diff.Unified(lines, "a", "b", 3))
+	})
+
+	t.Run("Separate Hunks", func(t *testing.T) {
+		a := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}
+		b := []string{"1", "two", "3", "4", "5", "6", "7", "8", "nine"}
+		expected := "--- left\n+++ right\n" +
+			"@@ -1,3 +1,3 @@\n 1\n-2\n+two\n 3\n" +
+			"@@ -8,2 +8,2 @@\n 8\n-9\n+nine\n"
+		assert.Equal(t, expected, diff.Unified(diff.Lines(a, b), "left", "right", 1))
+	})
+
+	t.Run("Merged Hunks", func(t *testing.T) {
+		a := []string{"1", "2", "3", "4", "5"}
+		b := []string{"one", "2", "3", "4", "five"}
+		expected := "--- left\n+++ right\n" +
+			"@@ -1,5 +1,5 @@\n-1\n+one\n 2\n 3\n 4\n-5\n+five\n"
+		assert.Equal(t, expected, diff.Unified(diff.Lines(a, b), "left", "right", 2))
+	})
+
+	t.Run("Insertion Into Empty", func(t *testing.T) {
+		expected := "--- left\n+++ right\n@@ -0,0 +1 @@\n+x\n"
+		assert.Equal(t, expected, diff.Unified(diff.Lines(nil, []string{"x"}), "left", "right", 3))
+	})
+}
+
+// TestSideBySide verifies the pairing of deleted and inserted lines into rows.
+func TestSideBySide(t *testing.T) {
+	lines := diff.Lines([]string{"a", "b", "c"}, []string{"a", "B", "B2", "c"})
+	expected := []diff.Row{
+		{Kind: diff.Equal, Left: "a", Right: "a", HasLeft: true, HasRight: true},
+		{Kind: diff.Delete, Left: "b", Right: "B", HasLeft: true, HasRight: true},
+		{Kind: diff.Insert, Right: "B2", HasRight: true},
+		{Kind: diff.Equal, Left: "c", Right: "c", HasLeft: true, HasRight: true},
+	}
+	assert.Equal(t, expected, diff.SideBySide(lines))
+}
@@ -0,0 +1,196 @@
+// Package eval runs suites of prompts with expected answers against a model, and reports
+// how often every case passes, to catch regressions in the model's behavior.
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a set of cases, as read from a suite file.
+type Suite struct {
+	// Threshold is the minimum overall pass rate, between 0 and 1, for the suite to pass.
+	// If nil, every run of every case must pass.
+	Threshold *float64 `yaml:"threshold"`
+	Cases     []Case   `yaml:"cases"`
+}
+
+// Case is one prompt of a suite, with the rules its response must follow.
+type Case struct {
+	Name   string `yaml:"name"`
+	Prompt string `yaml:"prompt"`
+	// System is an optional system prompt.
+	System string `yaml:"system"`
+	Expect Expect `yaml:"expect"`
+}
+
+// Expect holds the rules of a case. A response passes only if it follows all the rules that are set.
+type Expect struct {
+	// Equals is the exact expected response, ignoring surrounding whitespace.
+	Equals string `yaml:"equals"`
+	// Contains are substrings that the response must contain.
+	Contains []string `yaml:"contains"`
+	// NotContains are substrings that the response must not contain.
+	NotContains []string `yaml:"not_contains"`
+	// Regex is a regular expression that must match the response.
+	Regex string `yaml:"regex"`
+	// Judge is a rubric for a judge model to grade the response against.
+	Judge string `yaml:"judge"`
+}
+
+// isEmpty returns true if none of the rules are set.
+func (e Expect) isEmpty() bool {
+	return e.Equals == "" && len(e.Contains) == 0 && len(e.NotContains) == 0 && e.Regex == "" && e.Judge == ""
+}
+
+// LoadSuite reads and validates the suite in the YAML file at the given path.
+func LoadSuite(path string) (*Suite, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite file: %w", err)
+	}
+
+	var suite Suite
+	if err := yaml.Unmarshal(content, &suite); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal suite file: %w", err)
+	}
+
+	if len(suite.Cases) == 0 {
+		return nil, errors.New("suite has no cases")
+	}
+	if suite.Threshold != nil && (*suite.Threshold < 0 || *suite.Threshold > 1) {
+		return nil, fmt.Errorf("threshold must be between 0 and 1, got %g", *suite.Threshold)
+	}
+
+	names := make(map[string]bool, len(suite.Cases))
+	for i, c := range suite.Cases {
+		// Unnamed cases are named after their position.
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case-%d", i+1)
+			suite.Cases[i].Name = c.Name
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("duplicate case name %q", c.Name)
+		}
+		names[c.Name] = true
+
+		if c.Prompt == "" {
+			return nil, fmt.Errorf("case %q has no prompt", c.Name)
+		}
+		if c.Expect.isEmpty() {
+			return nil, fmt.Errorf("case %q has no expectations", c.Name)
+		}
+		if c.Expect.Regex != "" {
+			if _, err := regexp.Compile(c.Expect.Regex); err != nil {
+				return nil, fmt.Errorf("invalid regex in case %q: %w", c.Name, err)
+			}
+		}
+	}
+
+	return &suite, nil
+}
+
+// NeedsJudge returns true if any case of the suite is graded by a judge model.
+func (s *Suite) NeedsJudge() bool {
+	for _, c := range s.Cases {
+		if c.Expect.Judge != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// CompleteFunc obtains the model's response to the given case.
+type CompleteFunc func(ctx context.Context, c Case) (string, error)
+
+// CaseResult is the outcome of all the runs of a case.
+type CaseResult struct {
+	Name   string
+	Runs   int
+	Passed int
+	// Failures holds the reason of every failed run.
+	Failures []string
+}
+
+// PassRate returns the fraction of the runs that passed.
+func (r CaseResult) PassRate() float64 {
+	if r.Runs == 0 {
+		return 0
+	}
+	return float64(r.Passed) / float64(r.Runs)
+}
+
+// Report is the outcome of a whole suite.
+type Report struct {
+	Cases []CaseResult
+}
+
+// PassRate returns the fraction of the runs of all cases that passed.
+func (r Report) PassRate() float64 {
+	var runs, passed int
+	for _, c := range r.Cases {
+		runs, passed = runs+c.Runs, passed+c.Passed
+	}
+	if runs == 0 {
+		return 0
+	}
+	return float64(passed) / float64(runs)
+}
+
+// Run runs every case of the suite the given number of times, and grades the responses.
+// The judge may be nil if the suite doesn't need one. The emit function, if not nil,
+// receives the result of every case as soon as it's ready.
+//
+// A failure to obtain or grade a response fails the run, but not the suite.
+// Run returns early only if the context is canceled.
+func Run(ctx context.Context, suite *Suite, repeat int, complete CompleteFunc, judge JudgeFunc,
+	emit func(CaseResult),
+) (Report, error) {
+	if judge == nil && suite.NeedsJudge() {
+		return Report{}, errors.New("suite needs a judge model")
+	}
+
+	var report Report
+	for _, c := range suite.Cases {
+		result := CaseResult{Name: c.Name}
+
+		for range max(repeat, 1) {
+			result.Runs++
+
+			response, err := complete(ctx, c)
+			if err != nil {
+				if ctx.Err() != nil {
+					return report, ctx.Err()
+				}
+				result.Failures = append(result.Failures, fmt.Sprintf("failed to get response: %v", err))
+				continue
+			}
+
+			verdict, err := Grade(ctx, c, response, judge)
+			if err != nil {
+				if ctx.Err() != nil {
+					return report, ctx.Err()
+				}
+				result.Failures = append(result.Failures, fmt.Sprintf("failed to grade response: %v", err))
+				continue
+			}
+			if verdict.Pass {
+				result.Passed++
+			} else {
+				result.Failures = append(result.Failures, verdict.Reason)
+			}
+		}
+
+		report.Cases = append(report.Cases, result)
+		if emit != nil {
+			emit(result)
+		}
+	}
+
+	return report, nil
+}
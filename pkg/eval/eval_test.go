@@ -0,0 +1,207 @@
+package eval_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/eval"
+)
+
+// writeSuite writes the content to a suite file in a temporary directory and returns its path.
+func writeSuite(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "suite.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// TestLoadSuite verifies the parsing and validation of suite files.
+func TestLoadSuite(t *testing.T) {
+	t.Run("Valid Suite", func(t *testing.T) {
+		path := writeSuite(t, `
+threshold: 0.8
+cases:
+  - name: capital
+    prompt: What is the capital of France?
+    expect:
+      contains: [Paris]
+  - prompt: Say hi.
+    system: Be brief.
+    expect:
+      judge: The response is a greeting.
+`)
+		suite, err := eval.LoadSuite(path)
+		require.NoError(t, err)
+		require.NotNil(t, suite.Threshold)
+		assert.InDelta(t, 0.8, *suite.Threshold, 1e-9)
+		require.Len(t, suite.Cases, 2)
+		assert.Equal(t, "capital", suite.Cases[0].Name)
+		assert.Equal(t, []string{"Paris"}, suite.Cases[0].Expect.Contains)
+		assert.Equal(t, "case-2", suite.Cases[1].Name, "Unnamed cases are named after their position.")
+		assert.Equal(t, "Be brief.", suite.Cases[1].System)
+		assert.True(t, suite.NeedsJudge())
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "No Cases", content: "threshold: 1", wantErr: "no cases"},
+		{name: "Invalid Threshold", content: "threshold: 2\ncases: [{prompt: p, expect: {equals: x}}]", wantErr: "threshold"},
+		{name: "Duplicate Names", content: "cases: [{name: a, prompt: p, expect: {equals: x}}, {name: a, prompt: q, expect: {equals: y}}]", wantErr: "duplicate"},
+		{name: "No Prompt", content: "cases: [{name: a, expect: {equals: x}}]", wantErr: "no prompt"},
+		{name: "No Expectations", content: "cases: [{name: a, prompt: p}]", wantErr: "no expectations"},
+		{name: "Invalid Regex", content: "cases: [{name: a, prompt: p, expect: {regex: '('}}]", wantErr: "invalid regex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := eval.LoadSuite(writeSuite(t, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// TestGrade verifies the checking of responses against every kind of rule.
+func TestGrade(t *testing.T) {
+	passingJudge := func(ctx context.Context, prompt string) (string, error) { return "PASS\nLooks right.", nil }
+
+	tests := []struct {
+		name     string
+		expect   eval.Expect
+		response string
+		judge    eval.JudgeFunc
+		wantPass bool
+		wantErr  bool
+	}{
+		{name: "Equals", expect: eval.Expect{Equals: "4"}, response: " 4\n", wantPass: true},
+		{name: "Not Equals", expect: eval.Expect{Equals: "4"}, response: "four"},
+		{name: "Contains", expect: eval.Expect{Contains: []string{"Paris", "France"}}, response: "Paris, France", wantPass: true},
+		{name: "Missing Substring", expect: eval.Expect{Contains: []string{"Paris", "Lyon"}}, response: "Paris"},
+		{name: "Forbidden Substring", expect: eval.Expect{NotContains: []string{"sorry"}}, response: "I'm sorry"},
+		{name: "Regex Match", expect: eval.Expect{Regex: `^\d+$`}, response: "42", wantPass: true},
+		{name: "Regex Mismatch", expect: eval.Expect{Regex: `^\d+$`}, response: "forty-two"},
+		{name: "Judge Pass", expect: eval.Expect{Judge: "Is correct."}, response: "x", judge: passingJudge, wantPass: true},
+		{name: "Judge Skipped on Failure", expect: eval.Expect{Equals: "y", Judge: "Is correct."}, response: "x"},
+		{name: "Missing Judge", expect: eval.Expect{Judge: "Is correct."}, response: "x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := eval.Grade(context.Background(), eval.Case{Prompt: "p", Expect: tt.expect}, tt.response, tt.judge)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPass, verdict.Pass)
+			if !tt.wantPass {
+				assert.NotEmpty(t, verdict.Reason)
+			}
+		})
+	}
+}
+
+// TestParseVerdict verifies the parsing of judge replies.
+func TestParseVerdict(t *testing.T) {
+	tests := []struct {
+		name       string
+		reply      string
+		wantPass   bool
+		wantReason string
+		wantErr    bool
+	}{
+		{name: "Pass", reply: "PASS\nThe answer is correct.", wantPass: true, wantReason: "judge: The answer is correct."},
+		{name: "Decorated Fail", reply: "**Verdict: fail**\nWrong city.", wantReason: "judge: Wrong city."},
+		{name: "No Explanation", reply: "PASS", wantPass: true, wantReason: "judge: judge gave no explanation"},
+		{name: "No Verdict", reply: "The answer seems fine.", wantErr: true},
+		{name: "Ambiguous", reply: "PASS or FAIL", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := eval.ParseVerdict(tt.reply)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPass, verdict.Pass)
+			assert.Equal(t, tt.wantReason, verdict.Reason)
+		})
+	}
+}
+
+// TestRun verifies that every case is run the given number of times and graded.
+func TestRun(t *testing.T) {
+	suite := &eval.Suite{Cases: []eval.Case{
+		{Name: "stable", Prompt: "a", Expect: eval.Expect{Equals: "A"}},
+		{Name: "flaky", Prompt: "b", Expect: eval.Expect{Equals: "B"}},
+		{Name: "judged", Prompt: "c", Expect: eval.Expect{Judge: "Is a letter."}},
+	}}
+
+	var flakyCalls int
+	complete := func(ctx context.Context, c eval.Case) (string, error) {
+		if c.Name == "flaky" {
+			flakyCalls++
+			switch flakyCalls {
+			case 1:
+				return "B", nil
+			case 2:
+				return "", errors.New("server error")
+			}
+			return "wrong", nil
+		}
+		return strings.ToUpper(c.Prompt), nil
+	}
+	judge := func(ctx context.Context, prompt string) (string, error) {
+		assert.Contains(t, prompt, "Is a letter.")
+		return "PASS", nil
+	}
+
+	var emitted []string
+	report, err := eval.Run(context.Background(), suite, 4, complete, judge, func(r eval.CaseResult) {
+		emitted = append(emitted, r.Name)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"stable", "flaky", "judged"}, emitted)
+	require.Len(t, report.Cases, 3)
+	assert.Equal(t, eval.CaseResult{Name: "stable", Runs: 4, Passed: 4}, report.Cases[0])
+	assert.Equal(t, 4, report.Cases[1].Runs)
+	assert.Equal(t, 1, report.Cases[1].Passed)
+	assert.InDelta(t, 0.25, report.Cases[1].PassRate(), 1e-9)
+	require.Len(t, report.Cases[1].Failures, 3)
+	assert.Contains(t, report.Cases[1].Failures[0], "server error")
+	assert.InDelta(t, 9.0/12.0, report.PassRate(), 1e-9)
+
+	t.Run("Missing Judge", func(t *testing.T) {
+		_, err := eval.Run(context.Background(), suite, 1, complete, nil, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Judge Without Verdict", func(t *testing.T) {
+		undecided := func(ctx context.Context, prompt string) (string, error) { return "Hmm.", nil }
+		report, err := eval.Run(context.Background(), suite, 1, complete, undecided, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.Cases[2].Passed)
+		assert.Contains(t, report.Cases[2].Failures[0], "no verdict")
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		canceled := func(ctx context.Context, c eval.Case) (string, error) { return "", ctx.Err() }
+		_, err := eval.Run(ctx, suite, 1, canceled, judge, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,103 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// JudgeFunc sends the given prompt to the judge model and returns its reply.
+type JudgeFunc func(ctx context.Context, prompt string) (string, error)
+
+// Verdict is the grade of a single response.
+type Verdict struct {
+	Pass bool
+	// Reason explains why the response failed, or what the judge said about it.
+	Reason string
+}
+
+// Grade checks the response to the given case against all of the case's rules.
+// The judge is only called if the case has a rubric, and the other rules pass.
+func Grade(ctx context.Context, c Case, response string, judge JudgeFunc) (Verdict, error) {
+	expect := c.Expect
+
+	if expect.Equals != "" && strings.TrimSpace(response) != strings.TrimSpace(expect.Equals) {
+		return Verdict{Reason: fmt.Sprintf("response does not equal %q", expect.Equals)}, nil
+	}
+
+	for _, s := range expect.Contains {
+		if !strings.Contains(response, s) {
+			return Verdict{Reason: fmt.Sprintf("response does not contain %q", s)}, nil
+		}
+	}
+
+	for _, s := range expect.NotContains {
+		if strings.Contains(response, s) {
+			return Verdict{Reason: fmt.Sprintf("response contains %q", s)}, nil
+		}
+	}
+
+	if expect.Regex != "" {
+		re, err := regexp.Compile(expect.Regex)
+		if err != nil {
+			return Verdict{}, fmt.Errorf("invalid regex: %w", err)
+		}
+		if !re.MatchString(response) {
+			return Verdict{Reason: fmt.Sprintf("response does not match /%s/", expect.Regex)}, nil
+		}
+	}
+
+	if expect.Judge == "" {
+		return Verdict{Pass: true}, nil
+	}
+	if judge == nil {
+		return Verdict{}, errors.New("no judge for the rubric")
+	}
+
+	reply, err := judge(ctx, JudgePrompt(c.Prompt, response, expect.Judge))
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to get judge verdict: %w", err)
+	}
+	return ParseVerdict(reply)
+}
+
+// JudgePrompt returns the prompt that asks a judge model to grade a response against a rubric.
+func JudgePrompt(prompt, response, rubric string) string {
+	return fmt.Sprintf(`You are grading the response of an AI assistant against a rubric.
+
+<prompt>
+%s
+</prompt>
+
+<response>
+%s
+</response>
+
+<rubric>
+%s
+</rubric>
+
+Reply with PASS if the response satisfies the rubric, or FAIL if it doesn't, on the first line.
+Then explain your verdict in one sentence.`, prompt, response, rubric)
+}
+
+// ParseVerdict parses the reply of a judge model to the JudgePrompt.
+func ParseVerdict(reply string) (Verdict, error) {
+	reply = strings.TrimSpace(reply)
+	first, rest, _ := strings.Cut(reply, "\n")
+
+	// Models like to decorate the verdict, as in "**PASS**" or "Verdict: FAIL".
+	first = strings.ToUpper(first)
+	pass, fail := strings.Contains(first, "PASS"), strings.Contains(first, "FAIL")
+	if pass == fail {
+		return Verdict{}, fmt.Errorf("no verdict in judge reply: %q", reply)
+	}
+
+	reason := strings.TrimSpace(rest)
+	if reason == "" {
+		reason = "judge gave no explanation"
+	}
+	return Verdict{Pass: pass, Reason: "judge: " + reason}, nil
+}
@@ -0,0 +1,233 @@
+// Package highlight provides syntax highlighting of source code for terminals.
+//
+// The highlighting is lexical: keywords, strings, comments and numbers are colored by the
+// rules of the language, without parsing the code. This suits the code blocks of model
+// responses, which are often incomplete, and it works line by line, as they are streamed.
+package highlight
+
+import (
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// The colors of the kinds of tokens.
+var (
+	keywordColor = text.Colors{text.FgMagenta}
+	stringColor  = text.Colors{text.FgGreen}
+	commentColor = text.Colors{text.Faint}
+	numberColor  = text.Colors{text.FgCyan}
+)
+
+// language holds the lexical rules of a language.
+type language struct {
+	keywords map[string]bool
+	// ignoreCase makes the keywords match regardless of case, like in SQL.
+	ignoreCase bool
+	// lineComments start comments that run to the end of the line, like "//".
+	lineComments []string
+	// blockComment holds the delimiters of comments that may span lines, like "/*" and "*/", if any.
+	blockComment [2]string
+	// quotes are the delimiters of the strings that end on the same line, like `"`.
+	quotes []string
+	// multilineQuotes are the delimiters of the strings that may span lines, like "`" or `"""`.
+	multilineQuotes []string
+}
+
+// newLanguage returns a language with the given space-separated keywords.
+func newLanguage(keywords string, base language) *language {
+	base.keywords = map[string]bool{}
+	for _, keyword := range strings.Fields(keywords) {
+		base.keywords[keyword] = true
+	}
+	return &base
+}
+
+var (
+	cStyle = language{lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: []string{`"`, "'"}}
+	hashes = language{lineComments: []string{"#"}, quotes: []string{`"`, "'"}}
+
+	golang = newLanguage(`break case chan const continue default defer else fallthrough for func go goto if import
+		interface map package range return select struct switch type var nil true false iota`,
+		language{lineComments: cStyle.lineComments, blockComment: cStyle.blockComment,
+			quotes: cStyle.quotes, multilineQuotes: []string{"`"}})
+	python = newLanguage(`and as assert async await break class continue def del elif else except finally for from
+		global if import in is lambda nonlocal not or pass raise return try while with yield None True False self`,
+		language{lineComments: hashes.lineComments, quotes: hashes.quotes, multilineQuotes: []string{`"""`, `'''`}})
+	javascript = newLanguage(`async await break case catch class const continue debugger default delete do else export
+		extends finally for from function if import in instanceof let new of return static super switch this throw try
+		typeof var void while yield null undefined true false interface type enum implements`,
+		language{lineComments: cStyle.lineComments, blockComment: cStyle.blockComment,
+			quotes: cStyle.quotes, multilineQuotes: []string{"`"}})
+	cFamily = newLanguage(`auto break case catch char class const continue default delete do double else enum extends
+		extern final float for goto if implements import include int long namespace new package private protected public
+		return short signed sizeof static struct super switch template this throw try typedef union unsigned using virtual
+		void volatile while bool boolean byte string var null nullptr true false`, cStyle)
+	rust = newLanguage(`as async await break const continue crate dyn else enum extern false fn for if impl in let loop
+		match mod move mut pub ref return self Self static struct super trait true type unsafe use where while`, cStyle)
+	shell = newLanguage(`if then else elif fi case esac for while until do done in function return local export
+		readonly declare unset shift exit source alias echo cd`, hashes)
+	sql = newLanguage(`select from where and or not insert into values update set delete create table drop alter
+		index join inner left right outer full on as group by order having limit offset distinct union all null is in
+		like between case when then else end primary key foreign references default exists asc desc with`,
+		language{ignoreCase: true, lineComments: []string{"--"}, blockComment: cStyle.blockComment, quotes: []string{"'"}})
+	data = newLanguage(`true false null yes no`, hashes)
+)
+
+// languages holds the languages by the names and aliases used in the info strings of Markdown code fences.
+var languages = map[string]*language{
+	"go": golang, "golang": golang,
+	"python": python, "py": python,
+	"javascript": javascript, "js": javascript, "jsx": javascript, "mjs": javascript,
+	"typescript": javascript, "ts": javascript, "tsx": javascript,
+	"c": cFamily, "h": cFamily, "cpp": cFamily, "c++": cFamily, "cc": cFamily, "hpp": cFamily,
+	"java": cFamily, "csharp": cFamily, "cs": cFamily, "kotlin": cFamily, "kt": cFamily,
+	"rust": rust, "rs": rust,
+	"sh": shell, "bash": shell, "shell": shell, "zsh": shell, "console": shell,
+	"sql":  sql,
+	"json": data, "yaml": data, "yml": data, "toml": data,
+}
+
+// Supported tells whether the language with the given name or alias, like "go" or "py", is highlighted.
+func Supported(name string) bool {
+	_, ok := languages[strings.ToLower(name)]
+	return ok
+}
+
+// Highlighter highlights the lines of a piece of code in a language, one after the other.
+// It carries comments and strings that span lines over to the next line.
+//
+// A Highlighter must not be used concurrently.
+type Highlighter struct {
+	lang *language
+	// closer is the delimiter that ends the comment or string that the last line left open, if any.
+	closer string
+	// closerColor is the color of that comment or string.
+	closerColor text.Colors
+}
+
+// New returns a Highlighter for the language with the given name or alias, like "go" or "py",
+// as found in the info string of a code fence. Lines of unsupported languages are left as they are.
+func New(name string) *Highlighter {
+	return &Highlighter{lang: languages[strings.ToLower(name)]}
+}
+
+// Line returns the next line of the code, without its line ending, with its tokens colored.
+func (h *Highlighter) Line(line string) string {
+	if h.lang == nil {
+		return line
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(line); {
+		rest := line[i:]
+
+		// Continue the comment or string that the previous line left open.
+		if h.closer != "" {
+			end := strings.Index(rest, h.closer)
+			if end < 0 {
+				out.WriteString(h.closerColor.Sprint(rest))
+				break
+			}
+			end += len(h.closer)
+			out.WriteString(h.closerColor.Sprint(rest[:end]))
+			h.closer = ""
+			i += end
+			continue
+		}
+
+		if hasAnyPrefix(rest, h.lang.lineComments) != "" {
+			out.WriteString(commentColor.Sprint(rest))
+			break
+		}
+
+		if opener := h.lang.blockComment[0]; opener != "" && strings.HasPrefix(rest, opener) {
+			h.closer, h.closerColor = h.lang.blockComment[1], commentColor
+			out.WriteString(commentColor.Sprint(opener))
+			i += len(opener)
+			continue
+		}
+
+		if quote := hasAnyPrefix(rest, h.lang.multilineQuotes); quote != "" {
+			h.closer, h.closerColor = quote, stringColor
+			out.WriteString(stringColor.Sprint(quote))
+			i += len(quote)
+			continue
+		}
+
+		if quote := hasAnyPrefix(rest, h.lang.quotes); quote != "" {
+			end := stringEnd(rest, quote)
+			out.WriteString(stringColor.Sprint(rest[:end]))
+			i += end
+			continue
+		}
+
+		if isWordStart(rest[0]) {
+			end := wordEnd(rest)
+			word := rest[:end]
+			switch {
+			case rest[0] >= '0' && rest[0] <= '9':
+				out.WriteString(numberColor.Sprint(word))
+			case h.isKeyword(word):
+				out.WriteString(keywordColor.Sprint(word))
+			default:
+				out.WriteString(word)
+			}
+			i += end
+			continue
+		}
+
+		out.WriteByte(rest[0])
+		i++
+	}
+	return out.String()
+}
+
+// isKeyword tells whether the given word is a keyword of the language.
+func (h *Highlighter) isKeyword(word string) bool {
+	if h.lang.ignoreCase {
+		word = strings.ToLower(word)
+	}
+	return h.lang.keywords[word]
+}
+
+// hasAnyPrefix returns the first of the given prefixes that the text starts with, or an empty string.
+func hasAnyPrefix(text string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(text, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// stringEnd returns the length of the string at the start of the text, which starts and ends with
+// the given quote, skipping escaped quotes. An unterminated string runs to the end of the text.
+func stringEnd(text, quote string) int {
+	for i := len(quote); i < len(text); i++ {
+		switch {
+		case text[i] == '\\':
+			i++
+		case strings.HasPrefix(text[i:], quote):
+			return i + len(quote)
+		}
+	}
+	return len(text)
+}
+
+// isWordStart tells whether the byte can start an identifier, a keyword or a number.
+func isWordStart(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// wordEnd returns the length of the identifier, keyword or number at the start of the text.
+// Numbers may hold dots, like "3.14".
+func wordEnd(text string) int {
+	number := text[0] >= '0' && text[0] <= '9'
+	for i := 1; i < len(text); i++ {
+		if !isWordStart(text[i]) && !(number && text[i] == '.') {
+			return i
+		}
+	}
+	return len(text)
+}
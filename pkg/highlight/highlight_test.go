@@ -0,0 +1,151 @@
+package highlight_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shivanshkc/llmb/pkg/highlight"
+)
+
+var (
+	keyword = text.Colors{text.FgMagenta}.Sprint
+	str     = text.Colors{text.FgGreen}.Sprint
+	comment = text.Colors{text.Faint}.Sprint
+	number  = text.Colors{text.FgCyan}.Sprint
+)
+
+// TestHighlighter verifies that the tokens of code are colored by the rules of its language,
+// including the comments and strings that span lines.
+func TestHighlighter(t *testing.T) {
+	type testCase struct {
+		name     string
+		language string
+		lines    []string
+		expected []string
+	}
+
+	testCases := []testCase{
+		{
+			name:     "Go Keywords, Strings And Numbers",
+			language: "go",
+			lines:    []string{`func main() { x := "a \"b\"" + 42 }`},
+			expected: []string{keyword("func") + " main() { x := " + str(`"a \"b\""`) + " + " + number("42") + " }"},
+		},
+		{
+			name:     "Line Comment",
+			language: "py",
+			lines:    []string{`return 3.14  # pi`},
+			expected: []string{keyword("return") + " " + number("3.14") + "  " + comment("# pi")},
+		},
+		{
+			name:     "Block Comment Spanning Lines",
+			language: "c",
+			lines:    []string{"int x; /* one", "two */ return x;"},
+			expected: []string{
+				keyword("int") + " x; " + comment("/*") + comment(" one"),
+				comment("two */") + " " + keyword("return") + " x;",
+			},
+		},
+		{
+			name:     "String Spanning Lines",
+			language: "python",
+			lines:    []string{`s = """a`, `b""" if s else None`},
+			expected: []string{"s = " + str(`"""`) + str("a"), str(`b"""`) + " " + keyword("if") + " s " + keyword("else") + " " + keyword("None")},
+		},
+		{
+			name:     "Case Insensitive Keywords",
+			language: "SQL",
+			lines:    []string{"SELECT name FROM users -- all"},
+			expected: []string{keyword("SELECT") + " name " + keyword("FROM") + " users " + comment("-- all")},
+		},
+		{
+			name:     "Unsupported Language",
+			language: "brainfuck",
+			lines:    []string{`if "x" // y`},
+			expected: []string{`if "x" // y`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			highlighter := highlight.New(tc.language)
+			for i, line := range tc.lines {
+				assert.Equal(t, tc.expected[i], highlighter.Line(line), "line %d", i)
+			}
+		})
+	}
+}
+
+// TestWriter verifies that only the lines of fenced code blocks are highlighted,
+// regardless of how the text is split into pieces.
+func TestWriter(t *testing.T) {
+	type testCase struct {
+		name     string
+		pieces   []string
+		expected string
+	}
+
+	testCases := []testCase{
+		{
+			name:     "No Code Block",
+			pieces:   []string{"if it ", "works\n", "```", " is fine"},
+			expected: "if it works\n``` is fine",
+		},
+		{
+			name:     "Code Block",
+			pieces:   []string{"Run:\n```go\nreturn nil\n```\nDone."},
+			expected: "Run:\n```go\n" + keyword("return") + " " + keyword("nil") + "\n```\nDone.",
+		},
+		{
+			name:     "Code Block Split Into Pieces",
+			pieces:   []string{"Run:\n`", "``g", "o\nre", "turn", " nil\n`", "``\nDone."},
+			expected: "Run:\n```go\n" + keyword("return") + " " + keyword("nil") + "\n```\nDone.",
+		},
+		{
+			name:     "Unterminated Code Block",
+			pieces:   []string{"~~~~sh\necho ", "hi"},
+			expected: "~~~~sh\n" + keyword("echo") + " hi",
+		},
+		{
+			name:     "Longer Fence Inside Code Block",
+			pieces:   []string{"````md\n```\nif\n````\nif"},
+			expected: "````md\n```\nif\n````\nif",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			writer := highlight.NewWriter(&out)
+			for _, piece := range tc.pieces {
+				n, err := writer.Write([]byte(piece))
+				assert.NoError(t, err)
+				assert.Equal(t, len(piece), n)
+			}
+			assert.NoError(t, writer.Flush())
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+// TestWriter_Streaming verifies that the text outside of code blocks is written as soon as it
+// arrives, while the lines of code are held back until they're complete.
+func TestWriter_Streaming(t *testing.T) {
+	var out strings.Builder
+	writer := highlight.NewWriter(&out)
+
+	_, _ = writer.Write([]byte("Some "))
+	assert.Equal(t, "Some ", out.String())
+
+	_, _ = writer.Write([]byte("text\n``"))
+	assert.Equal(t, "Some text\n", out.String(), "a possible fence is held back")
+
+	_, _ = writer.Write([]byte("`go\nfunc"))
+	assert.Equal(t, "Some text\n```go\n", out.String(), "an incomplete line of code is held back")
+
+	_, _ = writer.Write([]byte("\n"))
+	assert.Equal(t, "Some text\n```go\n"+keyword("func")+"\n", out.String())
+}
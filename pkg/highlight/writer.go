@@ -0,0 +1,126 @@
+package highlight
+
+import (
+	"io"
+	"strings"
+)
+
+// Writer highlights the fenced code blocks of a Markdown text that is written to it in pieces, like a
+// streamed response, and writes the text to the underlying writer. The text outside of code blocks is
+// written as soon as it arrives, while the lines of code are written once they are complete, highlighted
+// by the language of the info string of their fence, like "```go".
+//
+// Flush must be called once the text ends. A Writer must not be used concurrently.
+type Writer struct {
+	out io.Writer
+
+	// line holds the part of the current line that is held back.
+	line strings.Builder
+	// lineStarted tells whether some of the current line was already written, so it can't be a fence.
+	lineStarted bool
+
+	// code highlights the lines of the current code block. It is nil outside of code blocks.
+	code *Highlighter
+	// fence is the fence that opened the current code block, like "```", which must close it too.
+	fence string
+}
+
+// NewWriter returns a Writer that writes the highlighted text to the given writer.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Write highlights the given piece of the text and writes whatever can be written of it.
+func (w *Writer) Write(p []byte) (int, error) {
+	rest := string(p)
+	for {
+		before, after, complete := strings.Cut(rest, "\n")
+		w.line.WriteString(before)
+		if !complete {
+			break
+		}
+		if err := w.endLine(); err != nil {
+			return 0, err
+		}
+		rest = after
+	}
+
+	if err := w.writePartialLine(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the text that is held back, which is the last line, if it doesn't end with a line break.
+// The Writer is then ready for a new text.
+func (w *Writer) Flush() error {
+	line := w.line.String()
+	if w.code != nil && !isFence(line) {
+		line = w.code.Line(line)
+	}
+
+	*w = Writer{out: w.out}
+	_, err := io.WriteString(w.out, line)
+	return err
+}
+
+// endLine writes the current line, which is complete, along with its line break.
+func (w *Writer) endLine() error {
+	line := w.line.String()
+	w.line.Reset()
+	started := w.lineStarted
+	w.lineStarted = false
+
+	switch {
+	case started:
+	case w.code != nil && isClosingFence(line, w.fence):
+		w.code, w.fence = nil, ""
+	case w.code != nil:
+		line = w.code.Line(line)
+	case isFence(line):
+		trimmed := strings.TrimLeft(line, " \t")
+		w.fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+		lang, _, _ := strings.Cut(strings.TrimSpace(trimmed[len(w.fence):]), " ")
+		w.code = New(lang)
+	}
+
+	_, err := io.WriteString(w.out, line+"\n")
+	return err
+}
+
+// writePartialLine writes the part of the current line that is held back, unless the line is
+// a line of code, which is only highlighted once complete, or may still turn out to be a fence.
+func (w *Writer) writePartialLine() error {
+	if w.code != nil || w.line.Len() == 0 {
+		return nil
+	}
+	line := w.line.String()
+	if !w.lineStarted && mayBeFence(line) {
+		return nil
+	}
+
+	w.line.Reset()
+	w.lineStarted = true
+	_, err := io.WriteString(w.out, line)
+	return err
+}
+
+// isFence tells whether the line is a code fence, which is at least three backticks or tildes,
+// after an indentation, and optionally followed by an info string, like "```go".
+func isFence(line string) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// mayBeFence tells whether the start of a line may still turn out to be a code fence once it's complete.
+func mayBeFence(start string) bool {
+	trimmed := strings.TrimLeft(start, " \t")
+	return isFence(trimmed) || strings.HasPrefix("```", trimmed) || strings.HasPrefix("~~~", trimmed)
+}
+
+// isClosingFence tells whether the line closes the code block that was opened with the given fence.
+// The closing fence is made of the same character, at least as many times, and nothing else.
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
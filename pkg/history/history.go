@@ -0,0 +1,145 @@
+// Package history persists the inputs of interactive sessions across runs, like the history of a shell.
+//
+// The history is a file with one input per line, each encoded as a JSON string, so that multi-line
+// inputs take a single line too. Inputs are appended as they are added, so that concurrent sessions
+// don't lose each other's inputs.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// History is the list of past inputs, from the oldest, backed by a file.
+type History struct {
+	path    string
+	limit   int
+	entries []string
+}
+
+// Load reads the history from the file at the given path, keeping only the last inputs, up to the
+// given limit. A missing file is an empty history. The file is compacted once it holds twice as many
+// inputs as the limit.
+func Load(path string, limit int) (*History, error) {
+	h := &History{path: path, limit: limit}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var lines int
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		lines++
+		var entry string
+		// Lines that aren't valid, like those of a partial write, are skipped.
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			h.entries = append(h.entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	if len(h.entries) > limit {
+		h.entries = h.entries[len(h.entries)-limit:]
+	}
+	if lines >= 2*limit {
+		if err := h.compact(); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Entries returns the inputs of the history, from the oldest. Their numbers, as used by Expand, start at 1.
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Add appends the input to the history and its file. Blank inputs, and those that repeat the last one, are
+// not added, like in shells.
+func (h *History) Add(input string) error {
+	if strings.TrimSpace(input) == "" || len(h.entries) > 0 && h.entries[len(h.entries)-1] == input {
+		return nil
+	}
+
+	h.entries = append(h.entries, input)
+	if len(h.entries) > h.limit {
+		h.entries = h.entries[1:]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	line, _ := json.Marshal(input) // Strings always marshal.
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return nil
+}
+
+// Expand returns the input that the given one recalls from the history, like in shells: "!!" recalls the
+// last input, "!n" the input number n, and "!-n" the nth last one. Other inputs are returned as they are,
+// and ok tells whether the input was a recall.
+func (h *History) Expand(input string) (expanded string, ok bool, err error) {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "!!" {
+		trimmed = "!-1"
+	}
+	reference, isRecall := strings.CutPrefix(trimmed, "!")
+	if !isRecall {
+		return input, false, nil
+	}
+	number, err := strconv.Atoi(reference)
+	if err != nil {
+		return input, false, nil
+	}
+
+	index := number - 1
+	if number < 0 {
+		index = len(h.entries) + number
+	}
+	if index < 0 || index >= len(h.entries) {
+		return "", true, fmt.Errorf("%s: no such input in the history", trimmed)
+	}
+	return h.entries[index], true, nil
+}
+
+// compact rewrites the file with only the kept inputs. The write is atomic, so that a crash never loses them all.
+func (h *History) compact() error {
+	var content strings.Builder
+	for _, entry := range h.entries {
+		line, _ := json.Marshal(entry)
+		content.Write(line)
+		content.WriteByte('\n')
+	}
+
+	temp := h.path + ".tmp"
+	if err := os.WriteFile(temp, []byte(content.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	if err := os.Rename(temp, h.path); err != nil {
+		return fmt.Errorf("failed to replace history file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package history_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/history"
+)
+
+// TestHistory_AddAndLoad verifies that inputs are persisted across loads, including multi-line ones,
+// and that blank and repeated inputs are left out.
+func TestHistory_AddAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "llmb", "history")
+
+	h, err := history.Load(path, 10)
+	require.NoError(t, err)
+	assert.Empty(t, h.Entries())
+
+	for _, input := range []string{"first", "  ", "second\nline", "second\nline", "/help"} {
+		require.NoError(t, h.Add(input))
+	}
+	assert.Equal(t, []string{"first", "second\nline", "/help"}, h.Entries())
+
+	loaded, err := history.Load(path, 10)
+	require.NoError(t, err)
+	assert.Equal(t, h.Entries(), loaded.Entries())
+}
+
+// TestHistory_Limit verifies that only the last inputs are kept, and that the file is compacted.
+func TestHistory_Limit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h, err := history.Load(path, 2)
+	require.NoError(t, err)
+	for _, input := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, h.Add(input))
+	}
+	assert.Equal(t, []string{"c", "d"}, h.Entries())
+
+	loaded, err := history.Load(path, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, loaded.Entries())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(content), "\n"), "the file must be compacted")
+}
+
+// TestHistory_Expand verifies the recall of past inputs by number.
+func TestHistory_Expand(t *testing.T) {
+	h, err := history.Load(filepath.Join(t.TempDir(), "history"), 10)
+	require.NoError(t, err)
+	for _, input := range []string{"one", "two", "three"} {
+		require.NoError(t, h.Add(input))
+	}
+
+	type testCase struct {
+		name          string
+		input         string
+		expected      string
+		expectedOK    bool
+		expectedError bool
+	}
+
+	testCases := []testCase{
+		{name: "Last", input: "!!", expected: "three", expectedOK: true},
+		{name: "By Number", input: "!1", expected: "one", expectedOK: true},
+		{name: "From The End", input: " !-2 ", expected: "two", expectedOK: true},
+		{name: "Out Of Range", input: "!4", expectedOK: true, expectedError: true},
+		{name: "Not A Recall", input: "!important", expected: "!important"},
+		{name: "Plain Input", input: "hello", expected: "hello"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expanded, ok, err := h.Expand(tc.input)
+			assert.Equal(t, tc.expectedOK, ok)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, expanded)
+		})
+	}
+}
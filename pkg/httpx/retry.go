@@ -2,8 +2,12 @@ package httpx
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/shivanshkc/llmb/pkg/clock"
 )
 
 // RetryClient is an extension of the standard HTTP client.
@@ -11,11 +15,77 @@ import (
 // Here, success means the `Do` method does not return a transient error.
 type RetryClient struct {
 	*http.Client
+
+	// Logger, if set, is used to report the failed attempts.
+	Logger *slog.Logger
+
+	// MaxElapsed, if positive, stops the retries once the next attempt would start
+	// later than this duration after the first one.
+	MaxElapsed time.Duration
+
+	// Backoff, if set, computes the delays between attempts. It defaults to ConstantBackoff.
+	Backoff Backoff
+
+	// Clock, if set, measures the elapsed time and the delays between attempts, instead of the real clock.
+	Clock clock.Clock
+}
+
+// Backoff returns the delay before the next attempt of a request, given the number of
+// failed attempts so far, starting at 1, and the base delay given to DoRetry.
+type Backoff func(failedAttempts int, delay time.Duration) time.Duration
+
+// maxBackoffDelay caps the delays that ExponentialBackoff grows to.
+const maxBackoffDelay = time.Minute
+
+// ConstantBackoff waits the base delay before every attempt.
+func ConstantBackoff(_ int, delay time.Duration) time.Duration {
+	return delay
+}
+
+// ExponentialBackoff waits the base delay before the second attempt, and doubles it
+// before every following one, up to a minute.
+func ExponentialBackoff(failedAttempts int, delay time.Duration) time.Duration {
+	backoff := delay
+	for i := 1; i < failedAttempts && backoff < maxBackoffDelay; i++ {
+		backoff *= 2
+	}
+	return max(delay, min(backoff, maxBackoffDelay))
+}
+
+// IsRetryableStatus reports whether a response with the given status code is worth retrying,
+// because it is caused by a transient condition, like overload or rate limiting.
+func IsRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds
+// or an HTTP date, into the time to wait from now. It returns zero if the value is absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
 }
 
 // DoRetry internally calls the `Do` method of the standard HTTP client on the given request.
-// If `Do` returns an error, the operation is retried up to maxAttempts times.
-func (rc *RetryClient) DoRetry(req *http.Request, maxAttempts int, delay time.Duration) (*http.Response, error) {
+// If `Do` returns an error, or a response with a retryable status (see IsRetryableStatus), the
+// operation is retried up to maxAttempts times, after the delay computed by the Backoff from the
+// given base delay. A Retry-After header of the response replaces that delay.
+//
+// Once the attempts run out, the last response is returned even if its status is retryable.
+func (rc *RetryClient) DoRetry(req *http.Request, maxAttempts int, baseDelay time.Duration) (*http.Response, error) {
 	// Request must be rewindable for retries.
 	if req.GetBody == nil {
 		return nil, fmt.Errorf("GetBody function must be set on the request for retrying")
@@ -23,6 +93,12 @@ func (rc *RetryClient) DoRetry(req *http.Request, maxAttempts int, delay time.Du
 
 	// This will hold the error that will be returned of all retries fail.
 	var errFinal error
+	clk := clock.OrReal(rc.Clock)
+	start := clk.Now()
+	backoff := rc.Backoff
+	if backoff == nil {
+		backoff = ConstantBackoff
+	}
 
 	for i := 0; i < maxAttempts; i++ {
 		// Clone the request for each attempt.
@@ -38,9 +114,15 @@ func (rc *RetryClient) DoRetry(req *http.Request, maxAttempts int, delay time.Du
 
 		// Attempt the request.
 		response, err := rc.Do(reqClone)
+		var retryAfter time.Duration
 		if err == nil {
-			// Success! The caller is now responsible for closing the response body.
-			return response, nil
+			if !IsRetryableStatus(response.StatusCode) || i == maxAttempts-1 {
+				// Success, or a failure that the caller handles.
+				// The caller is now responsible for closing the response body.
+				return response, nil
+			}
+			retryAfter = parseRetryAfter(response.Header.Get("Retry-After"), clk.Now())
+			err = fmt.Errorf("unexpected status code: %d", response.StatusCode)
 		}
 
 		// Record the error. If this is the final retry, this error will be returned.
@@ -50,14 +132,37 @@ func (rc *RetryClient) DoRetry(req *http.Request, maxAttempts int, delay time.Du
 			break
 		}
 
+		delay := backoff(i+1, baseDelay)
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+
+		// Give up early if the next attempt would be too late.
+		if rc.MaxElapsed > 0 && clk.Now().Sub(start)+delay > rc.MaxElapsed {
+			if response != nil {
+				return response, nil // The caller handles the status.
+			}
+			return nil, fmt.Errorf("gave up after %d attempts in %s, last error: %w", i+1, rc.MaxElapsed, errFinal)
+		}
+
+		// The response is replaced by the one of the next attempt.
+		if response != nil {
+			_ = response.Body.Close()
+		}
+
+		if rc.Logger != nil {
+			rc.Logger.Info("request attempt failed, retrying",
+				"attempt", i+1, "max_attempts", maxAttempts, "delay", delay, "error", err)
+		}
+
 		// Timer to wait before next retry.
-		timer := time.NewTimer(delay)
+		timer := clk.NewTimer(delay)
 		// Wait before the next retry while respecting the request's context.
 		select {
 		case <-reqClone.Context().Done():
 			timer.Stop()                         // Cleanup the timer. `time.After` does not allow this optimization.
 			return nil, reqClone.Context().Err() // Return the context's error.
-		case <-timer.C:
+		case <-timer.C():
 			// Continue to the next attempt.
 		}
 	}
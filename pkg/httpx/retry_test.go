@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/shivanshkc/llmb/pkg/clock"
 	"github.com/shivanshkc/llmb/pkg/httpx"
 )
 
@@ -165,3 +167,173 @@ func TestRetryClient_DoRetry_NoGetBody(t *testing.T) {
 	assert.Nil(t, resp)
 	assert.Contains(t, err.Error(), "GetBody function must be set")
 }
+
+// TestRetryClient_DoRetry_Logger verifies that the failed attempts are reported to the logger.
+func TestRetryClient_DoRetry_Logger(t *testing.T) {
+	var logs bytes.Buffer
+	client := &httpx.RetryClient{
+		Client: &http.Client{Transport: &mockRoundTripper{
+			responses: []func(*http.Request) (*http.Response, error){
+				func(r *http.Request) (*http.Response, error) { return nil, errors.New("network error") },
+				func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+				},
+			},
+		}},
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/test", strings.NewReader(""))
+	require.NoError(t, err)
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("")), nil }
+
+	response, err := client.DoRetry(req, 3, time.Millisecond)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+
+	assert.Equal(t, 1, strings.Count(logs.String(), "request attempt failed"), "Only the failed attempt should be logged.")
+	assert.Contains(t, logs.String(), "attempt=1")
+	assert.Contains(t, logs.String(), "network error")
+}
+
+// TestRetryClient_DoRetry_MaxElapsed verifies that the retries stop once the time limit would be exceeded.
+func TestRetryClient_DoRetry_MaxElapsed(t *testing.T) {
+	var attempts int
+	failure := func(r *http.Request) (*http.Response, error) {
+		attempts++
+		return nil, errors.New("network error")
+	}
+
+	client := &httpx.RetryClient{
+		Client: &http.Client{Transport: &mockRoundTripper{
+			responses: []func(*http.Request) (*http.Response, error){failure, failure, failure, failure, failure},
+		}},
+		MaxElapsed: 50 * time.Millisecond,
+		Clock:      clock.NewFake(time.Time{}),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/test", strings.NewReader(""))
+	require.NoError(t, err)
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("")), nil }
+
+	_, err = client.DoRetry(req, 5, 20*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gave up after 3 attempts")
+	assert.Equal(t, 3, attempts, "Attempts at 0ms, 20ms and 40ms fit in 50ms, the one at 60ms doesn't.")
+}
+
+// TestBackoff verifies the delays of the backoff strategies.
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		name           string
+		backoff        httpx.Backoff
+		failedAttempts int
+		delay          time.Duration
+		expected       time.Duration
+	}{
+		{name: "Constant", backoff: httpx.ConstantBackoff, failedAttempts: 5, delay: time.Second, expected: time.Second},
+		{name: "Exponential First", backoff: httpx.ExponentialBackoff, failedAttempts: 1, delay: time.Second, expected: time.Second},
+		{name: "Exponential Third", backoff: httpx.ExponentialBackoff, failedAttempts: 3, delay: time.Second, expected: 4 * time.Second},
+		{name: "Exponential Capped", backoff: httpx.ExponentialBackoff, failedAttempts: 100, delay: time.Second, expected: time.Minute},
+		{name: "Exponential Above Cap", backoff: httpx.ExponentialBackoff, failedAttempts: 3, delay: 2 * time.Minute, expected: 2 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.backoff(tc.failedAttempts, tc.delay))
+		})
+	}
+}
+
+// TestRetryClient_DoRetry_Backoff verifies that the attempts are spaced by the delays of the backoff.
+func TestRetryClient_DoRetry_Backoff(t *testing.T) {
+	fake := clock.NewFake(time.Time{})
+	var attemptTimes []time.Duration
+	failure := func(r *http.Request) (*http.Response, error) {
+		attemptTimes = append(attemptTimes, fake.Now().Sub(time.Time{}))
+		return nil, errors.New("network error")
+	}
+
+	client := &httpx.RetryClient{
+		Client: &http.Client{Transport: &mockRoundTripper{
+			responses: []func(*http.Request) (*http.Response, error){failure, failure, failure, failure},
+		}},
+		Backoff: httpx.ExponentialBackoff,
+		Clock:   fake,
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/test", strings.NewReader(""))
+	require.NoError(t, err)
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("")), nil }
+
+	_, err = client.DoRetry(req, 4, 10*time.Millisecond)
+	require.Error(t, err)
+	assert.Equal(t, []time.Duration{0, 10 * time.Millisecond, 30 * time.Millisecond, 70 * time.Millisecond}, attemptTimes)
+}
+
+// TestRetryClient_DoRetry_Status verifies that responses with retryable statuses are retried,
+// after the delay given by their Retry-After header, and that the last one is returned.
+func TestRetryClient_DoRetry_Status(t *testing.T) {
+	respond := func(status int, retryAfter string) func(*http.Request) (*http.Response, error) {
+		return func(r *http.Request) (*http.Response, error) {
+			header := http.Header{}
+			if retryAfter != "" {
+				header.Set("Retry-After", retryAfter)
+			}
+			return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+	}
+
+	tests := []struct {
+		name           string
+		responses      []func(*http.Request) (*http.Response, error)
+		expectedStatus int
+		expectedWait   time.Duration
+	}{
+		{
+			name:           "Retryable Status, then Success",
+			responses:      []func(*http.Request) (*http.Response, error){respond(503, ""), respond(200, "")},
+			expectedStatus: http.StatusOK,
+			expectedWait:   10 * time.Millisecond,
+		},
+		{
+			name:           "Retry-After Replaces the Delay",
+			responses:      []func(*http.Request) (*http.Response, error){respond(429, "2"), respond(200, "")},
+			expectedStatus: http.StatusOK,
+			expectedWait:   2 * time.Second,
+		},
+		{
+			name:           "Non-Retryable Status",
+			responses:      []func(*http.Request) (*http.Response, error){respond(400, "")},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Attempts Run Out",
+			responses: []func(*http.Request) (*http.Response, error){
+				respond(502, ""), respond(502, ""), respond(502, ""),
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedWait:   20 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Time{})
+			client := &httpx.RetryClient{
+				Client: &http.Client{Transport: &mockRoundTripper{responses: tc.responses}},
+				Clock:  fake,
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "http://localhost/test", strings.NewReader(""))
+			require.NoError(t, err)
+			req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("")), nil }
+
+			resp, err := client.DoRetry(req, 3, 10*time.Millisecond)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tc.expectedWait, fake.Now().Sub(time.Time{}))
+		})
+	}
+}
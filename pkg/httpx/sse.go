@@ -8,6 +8,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/shivanshkc/llmb/pkg/clock"
 )
 
 // ServerSentEvent represents a single event sent by the server.
@@ -21,8 +23,12 @@ type ServerSentEvent struct {
 // ReadServerSentEvents reads the given response body assuming it is a stream of Server-Sent events
 // and returns a channel for the caller to consume the events.
 //
+// The arrival of every event is timestamped with the given clock, or the real clock if it is nil.
+//
 // It takes ownership of the response body and guarantees it will be closed.
-func ReadServerSentEvents(ctx context.Context, body io.ReadCloser) <-chan ServerSentEvent {
+func ReadServerSentEvents(ctx context.Context, body io.ReadCloser, clk clock.Clock) <-chan ServerSentEvent {
+	clk = clock.OrReal(clk)
+
 	eventChan := make(chan ServerSentEvent, 100)
 
 	// producerCtx is a local context for managing the producer's lifecycle.
@@ -62,7 +68,7 @@ func ReadServerSentEvents(ctx context.Context, body io.ReadCloser) <-chan Server
 
 		for index := 0; ; index++ {
 			line, err := reader.ReadString('\n')
-			timestamp := time.Now() // Capture timestamp immediately after read.
+			timestamp := clk.Now() // Capture timestamp immediately after read.
 
 			if err != nil {
 				// If the error is due to context cancellation, report it.
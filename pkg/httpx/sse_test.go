@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/shivanshkc/llmb/pkg/clock"
 	"github.com/shivanshkc/llmb/pkg/httpx"
 )
 
@@ -106,7 +107,7 @@ func TestReadServerSentEvents(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Execution.
-			eventChan := httpx.ReadServerSentEvents(tc.ctx, tc.body)
+			eventChan := httpx.ReadServerSentEvents(tc.ctx, tc.body, nil)
 			events := drainChannel(t, eventChan)
 
 			// Assertions for events.
@@ -142,3 +143,15 @@ func TestReadServerSentEvents(t *testing.T) {
 		})
 	}
 }
+
+// TestReadServerSentEvents_Clock verifies that the events are timestamped with the given clock.
+func TestReadServerSentEvents_Clock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	body := io.NopCloser(strings.NewReader("data: one\n\ndata: two\n\n"))
+
+	events := drainChannel(t, httpx.ReadServerSentEvents(context.Background(), body, fake))
+	require.Len(t, events, 2)
+	for _, event := range events {
+		assert.Equal(t, fake.Now(), event.Timestamp)
+	}
+}
@@ -0,0 +1,51 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// NewTLSConfig returns the TLS configuration for connecting to servers with untrusted certificates.
+//
+// If caFile is set, the PEM encoded certificates in it are trusted in addition to the system ones.
+// If insecure is true, server certificates are not verified at all.
+func NewTLSConfig(insecure bool, caFile string) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: insecure}
+	if caFile == "" {
+		return config, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	// The system pool may not be available on every platform, in which case only the file is trusted.
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in CA file")
+	}
+
+	config.RootCAs = pool
+	return config, nil
+}
+
+// LoadClientCertificate loads the certificate and the private key that authenticate the client
+// to servers that require mutual TLS, from the given PEM files.
+func LoadClientCertificate(certFile, keyFile string) (tls.Certificate, error) {
+	if certFile == "" || keyFile == "" {
+		return tls.Certificate{}, errors.New("both a client certificate and its key are required")
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	return certificate, nil
+}
@@ -0,0 +1,78 @@
+package jsonschema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/jsonschema"
+)
+
+// TestSchema_ValidateJSON verifies validation across the supported keywords.
+func TestSchema_ValidateJSON(t *testing.T) {
+	schema, err := jsonschema.Parse([]byte(`{
+		"type": "object",
+		"required": ["name", "tags"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+			"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+			"kind": {"enum": ["cat", "dog"]},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}},
+			"score": {"anyOf": [{"type": "number"}, {"type": "null"}]}
+		}
+	}`))
+	require.NoError(t, err)
+
+	type testCase struct {
+		name        string
+		document    string
+		expectedErr string
+	}
+
+	testCases := []testCase{
+		{name: "Valid", document: `{"name":"tom","age":3,"kind":"cat","tags":["a"],"score":null}`},
+		{name: "Invalid JSON", document: `{"name":`, expectedErr: "invalid JSON"},
+		{name: "Trailing Data", document: `{"name":"tom","tags":[]} {}`, expectedErr: "unexpected data"},
+		{name: "Wrong Root Type", document: `[]`, expectedErr: "at /: expected object, got array"},
+		{name: "Missing Required", document: `{"name":"tom"}`, expectedErr: `missing required property "tags"`},
+		{name: "Additional Property", document: `{"name":"tom","tags":[],"x":1}`, expectedErr: `unexpected property "x"`},
+		{name: "Pattern Mismatch", document: `{"name":"Tom","tags":[]}`, expectedErr: "at /name: expected to match"},
+		{name: "Integer Required", document: `{"name":"tom","tags":[],"age":2.5}`, expectedErr: "at /age: expected integer"},
+		{name: "Exclusive Maximum", document: `{"name":"tom","tags":[],"age":150}`, expectedErr: "expected a number < 150"},
+		{name: "Enum Mismatch", document: `{"name":"tom","tags":[],"kind":"cow"}`, expectedErr: "expected one of"},
+		{name: "Too Many Items", document: `{"name":"tom","tags":["a","b","c"]}`, expectedErr: "at most 2 items"},
+		{name: "Item Type", document: `{"name":"tom","tags":[1]}`, expectedErr: "at /tags/0: expected string"},
+		{name: "AnyOf Mismatch", document: `{"name":"tom","tags":[],"score":"x"}`, expectedErr: "any of the schemas"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := schema.ValidateJSON([]byte(tc.document))
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+// TestParse_Invalid verifies that malformed schemas are rejected.
+func TestParse_Invalid(t *testing.T) {
+	_, err := jsonschema.Parse([]byte(`{"type": 5}`))
+	assert.Error(t, err)
+
+	_, err = jsonschema.Parse([]byte(`{"pattern": "("}`))
+	assert.Error(t, err)
+}
+
+// TestParse_BooleanSchemas verifies the `true` and `false` schemas.
+func TestParse_BooleanSchemas(t *testing.T) {
+	schema, err := jsonschema.Parse([]byte(`{"properties": {"a": true, "b": false}}`))
+	require.NoError(t, err)
+
+	assert.NoError(t, schema.ValidateJSON([]byte(`{"a": 1}`)))
+	assert.Error(t, schema.ValidateJSON([]byte(`{"b": 1}`)))
+}
@@ -0,0 +1,186 @@
+// Package jsonschema provides validation of JSON values against a practical
+// subset of JSON Schema.
+//
+// The supported keywords are the ones commonly used to describe structured
+// outputs of language models:
+//
+//   - type (a single type or a list of types)
+//   - enum and const
+//   - properties, required, and additionalProperties (boolean or schema)
+//   - items, minItems, and maxItems
+//   - minLength, maxLength, and pattern
+//   - minimum, maximum, exclusiveMinimum, and exclusiveMaximum
+//   - allOf, anyOf, and oneOf
+//
+// Unsupported keywords are ignored, which makes the validation lenient rather
+// than incorrect: a value that passes may still violate an unsupported keyword.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Schema is a parsed JSON schema.
+type Schema struct {
+	Type  []string
+	Enum  []any
+	Const *any
+
+	Properties           map[string]*Schema
+	Required             []string
+	AdditionalProperties *Schema
+	// noAdditionalProperties is true if additionalProperties is false.
+	noAdditionalProperties bool
+
+	Items              *Schema
+	MinItems, MaxItems *int
+
+	MinLength, MaxLength *int
+	Pattern              *regexp.Regexp
+
+	Minimum, Maximum                   *float64
+	ExclusiveMinimum, ExclusiveMaximum *float64
+
+	AllOf, AnyOf, OneOf []*Schema
+
+	// rejectAll is true for the `false` schema, which no value satisfies.
+	rejectAll bool
+}
+
+// rawSchema is the JSON representation of a Schema, used for parsing.
+type rawSchema struct {
+	Type  json.RawMessage `json:"type"`
+	Enum  []any           `json:"enum"`
+	Const *any            `json:"const"`
+
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+
+	Items    json.RawMessage `json:"items"`
+	MinItems *int            `json:"minItems"`
+	MaxItems *int            `json:"maxItems"`
+
+	MinLength *int   `json:"minLength"`
+	MaxLength *int   `json:"maxLength"`
+	Pattern   string `json:"pattern"`
+
+	Minimum          *float64 `json:"minimum"`
+	Maximum          *float64 `json:"maximum"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum"`
+
+	AllOf []json.RawMessage `json:"allOf"`
+	AnyOf []json.RawMessage `json:"anyOf"`
+	OneOf []json.RawMessage `json:"oneOf"`
+}
+
+// Parse parses the given JSON schema document.
+func Parse(data []byte) (*Schema, error) {
+	data = bytes.TrimSpace(data)
+
+	// The boolean schemas: true accepts everything, false accepts nothing.
+	switch string(data) {
+	case "true":
+		return &Schema{}, nil
+	case "false":
+		return &Schema{rejectAll: true}, nil
+	}
+
+	var raw rawSchema
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	schema := &Schema{
+		Enum:      raw.Enum,
+		Const:     raw.Const,
+		Required:  raw.Required,
+		MinItems:  raw.MinItems,
+		MaxItems:  raw.MaxItems,
+		MinLength: raw.MinLength,
+		MaxLength: raw.MaxLength,
+		Minimum:   raw.Minimum,
+		Maximum:   raw.Maximum,
+
+		ExclusiveMinimum: raw.ExclusiveMinimum,
+		ExclusiveMaximum: raw.ExclusiveMaximum,
+	}
+
+	var err error
+	if schema.Type, err = parseType(raw.Type); err != nil {
+		return nil, err
+	}
+
+	if raw.Pattern != "" {
+		if schema.Pattern, err = regexp.Compile(raw.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", raw.Pattern, err)
+		}
+	}
+
+	if len(raw.Properties) > 0 {
+		schema.Properties = make(map[string]*Schema, len(raw.Properties))
+		for name, property := range raw.Properties {
+			if schema.Properties[name], err = Parse(property); err != nil {
+				return nil, fmt.Errorf("invalid property %q: %w", name, err)
+			}
+		}
+	}
+
+	switch string(bytes.TrimSpace(raw.AdditionalProperties)) {
+	case "", "true":
+	case "false":
+		schema.noAdditionalProperties = true
+	default:
+		if schema.AdditionalProperties, err = Parse(raw.AdditionalProperties); err != nil {
+			return nil, fmt.Errorf("invalid additionalProperties: %w", err)
+		}
+	}
+
+	if len(raw.Items) > 0 {
+		if schema.Items, err = Parse(raw.Items); err != nil {
+			return nil, fmt.Errorf("invalid items: %w", err)
+		}
+	}
+
+	for keyword, list := range map[string][]json.RawMessage{"allOf": raw.AllOf, "anyOf": raw.AnyOf, "oneOf": raw.OneOf} {
+		schemas := make([]*Schema, len(list))
+		for i, item := range list {
+			if schemas[i], err = Parse(item); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", keyword, err)
+			}
+		}
+
+		switch keyword {
+		case "allOf":
+			schema.AllOf = schemas
+		case "anyOf":
+			schema.AnyOf = schemas
+		case "oneOf":
+			schema.OneOf = schemas
+		}
+	}
+
+	return schema, nil
+}
+
+// parseType parses the value of the type keyword, which may be a string or a list of strings.
+func parseType(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(raw, &multiple); err != nil {
+		return nil, fmt.Errorf("invalid type: %s", string(raw))
+	}
+	return multiple, nil
+}
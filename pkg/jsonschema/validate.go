@@ -0,0 +1,327 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes why a value does not satisfy a schema.
+type ValidationError struct {
+	// Path is the JSON pointer of the offending value, like "/items/0/name".
+	Path    string
+	Message string
+}
+
+// Error satisfies the error interface.
+func (e *ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("at %s: %s", path, e.Message)
+}
+
+// ValidateJSON parses the given JSON document and validates it against the schema.
+func (s *Schema) ValidateJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Numbers are decoded precisely, so integers can be told apart from floats.
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if decoder.More() {
+		return errors.New("invalid JSON: unexpected data after the top-level value")
+	}
+
+	return s.Validate(value)
+}
+
+// Validate validates the given decoded JSON value against the schema.
+// Numbers may be either float64 or json.Number.
+func (s *Schema) Validate(value any) error {
+	return s.validate(value, "")
+}
+
+// validate validates the value found at the given path.
+func (s *Schema) validate(value any, path string) error {
+	fail := func(format string, args ...any) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if s.rejectAll {
+		return fail("no value is allowed here")
+	}
+
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		return fail("expected %s, got %s", strings.Join(s.Type, " or "), typeOf(value))
+	}
+
+	if s.Const != nil && !equal(value, *s.Const) {
+		return fail("expected the constant %v", *s.Const)
+	}
+
+	if s.Enum != nil && !containsEqual(s.Enum, value) {
+		return fail("expected one of %v", s.Enum)
+	}
+
+	var err error
+	switch v := value.(type) {
+	case map[string]any:
+		err = s.validateObject(v, path)
+	case []any:
+		err = s.validateArray(v, path)
+	case string:
+		err = s.validateString(v, path)
+	case json.Number, float64:
+		number, _ := toFloat(v)
+		err = s.validateNumber(number, path)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.validateCombinators(value, path)
+}
+
+// validateObject validates the object-specific keywords.
+func (s *Schema) validateObject(object map[string]any, path string) error {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("missing required property %q", name)}
+		}
+	}
+
+	// Sorted for deterministic error reporting.
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := path + "/" + escapePointer(name)
+
+		if property, ok := s.Properties[name]; ok {
+			if err := property.validate(object[name], propertyPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if s.noAdditionalProperties {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("unexpected property %q", name)}
+		}
+		if s.AdditionalProperties != nil {
+			if err := s.AdditionalProperties.validate(object[name], propertyPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateArray validates the array-specific keywords.
+func (s *Schema) validateArray(array []any, path string) error {
+	if s.MinItems != nil && len(array) < *s.MinItems {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected at least %d items", *s.MinItems)}
+	}
+	if s.MaxItems != nil && len(array) > *s.MaxItems {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected at most %d items", *s.MaxItems)}
+	}
+
+	if s.Items != nil {
+		for i, item := range array {
+			if err := s.Items.validate(item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateString validates the string-specific keywords.
+func (s *Schema) validateString(str, path string) error {
+	length := utf8.RuneCountInString(str)
+	if s.MinLength != nil && length < *s.MinLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected at least %d characters", *s.MinLength)}
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected at most %d characters", *s.MaxLength)}
+	}
+	if s.Pattern != nil && !s.Pattern.MatchString(str) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected to match the pattern %q", s.Pattern)}
+	}
+	return nil
+}
+
+// validateNumber validates the number-specific keywords.
+func (s *Schema) validateNumber(number float64, path string) error {
+	fail := func(relation string, bound float64) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected a number %s %v", relation, bound)}
+	}
+
+	switch {
+	case s.Minimum != nil && number < *s.Minimum:
+		return fail(">=", *s.Minimum)
+	case s.Maximum != nil && number > *s.Maximum:
+		return fail("<=", *s.Maximum)
+	case s.ExclusiveMinimum != nil && number <= *s.ExclusiveMinimum:
+		return fail(">", *s.ExclusiveMinimum)
+	case s.ExclusiveMaximum != nil && number >= *s.ExclusiveMaximum:
+		return fail("<", *s.ExclusiveMaximum)
+	}
+	return nil
+}
+
+// validateCombinators validates the allOf, anyOf, and oneOf keywords.
+func (s *Schema) validateCombinators(value any, path string) error {
+	for _, sub := range s.AllOf {
+		if err := sub.validate(value, path); err != nil {
+			return err
+		}
+	}
+
+	if len(s.AnyOf) > 0 {
+		var firstErr error
+		for _, sub := range s.AnyOf {
+			err := sub.validate(value, path)
+			if err == nil {
+				firstErr = nil
+				break
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr != nil {
+			return &ValidationError{Path: path, Message: "expected to match any of the schemas, " + firstErr.Error()}
+		}
+	}
+
+	if len(s.OneOf) > 0 {
+		var matches int
+		for _, sub := range s.OneOf {
+			if sub.validate(value, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return &ValidationError{Path: path,
+				Message: fmt.Sprintf("expected to match exactly one of the schemas, matched %d", matches)}
+		}
+	}
+
+	return nil
+}
+
+// matchesType reports whether the value is of any of the schema's types.
+func (s *Schema) matchesType(value any) bool {
+	actual := typeOf(value)
+	for _, expected := range s.Type {
+		if expected == actual {
+			return true
+		}
+		// Integers are numbers too.
+		if expected == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON schema type name of the given decoded value.
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case json.Number, float64:
+		number, ok := toFloat(v)
+		if ok && number == math.Trunc(number) && !math.IsInf(number, 0) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// toFloat converts a decoded JSON number to float64.
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// equal reports whether the two decoded JSON values are equal, treating all number representations alike.
+func equal(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+
+	switch va := a.(type) {
+	case []any:
+		vb, ok := b.([]any)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !equal(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		vb, ok := b.(map[string]any)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for key, value := range va {
+			if other, ok := vb[key]; !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// containsEqual reports whether the list contains a value equal to the given one.
+func containsEqual(list []any, value any) bool {
+	for _, item := range list {
+		if equal(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapePointer escapes a property name for use in a JSON pointer.
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
@@ -0,0 +1,214 @@
+// Package mock provides a synthetic OpenAI compatible API server, for testing clients offline.
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+// Config controls the behavior of the mock server.
+type Config struct {
+	// TTFT is the delay before the first token.
+	TTFT time.Duration
+	// TokenRate is the number of tokens produced per second. Zero means no delay between tokens.
+	TokenRate float64
+	// Tokens is the number of tokens in every response.
+	Tokens int
+	// Jitter randomly varies every delay by up to this fraction of it, for example 0.1 for ±10%.
+	Jitter float64
+	// ErrorRate is the fraction of requests that fail with ErrorStatus, between 0 and 1.
+	ErrorRate float64
+	// ErrorStatus is the status code of failed requests.
+	ErrorStatus int
+	// Seed seeds the randomness of jitter and errors, so that runs are reproducible.
+	Seed int64
+}
+
+// words make up the responses of the mock server, one token per word.
+var words = strings.Fields(`Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor
+	incididunt ut labore et dolore magna aliqua. Ut enim ad minim veniam, quis nostrud exercitation ullamco
+	laboris nisi ut aliquip ex ea commodo consequat.`)
+
+// Server is an http.Handler that serves the models and chat completions APIs.
+type Server struct {
+	config Config
+	mux    *http.ServeMux
+
+	// rng is not safe for concurrent use, hence the mutex.
+	rngMu sync.Mutex
+	rng   *rand.Rand
+}
+
+// New returns a mock Server with the given configuration.
+func New(config Config) *Server {
+	s := &Server{config: config, mux: http.NewServeMux(), rng: rand.New(rand.NewSource(config.Seed))}
+	s.mux.HandleFunc("GET /v1/models", s.handleModels)
+	s.mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+	return s
+}
+
+// ServeHTTP serves the request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleModels serves the list of models.
+// The chat completions API accepts any model name, so the list only holds a placeholder.
+func (s *Server) handleModels(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"object": "list",
+		"data":   []map[string]any{{"id": "mock", "object": "model", "owned_by": "llmb"}},
+	})
+}
+
+// chatCompletionRequest holds the fields of a chat completion request that affect the mock's response.
+type chatCompletionRequest struct {
+	Model         string `json:"model"`
+	Stream        bool   `json:"stream"`
+	StreamOptions struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options"`
+	Messages []api.ChatMessage `json:"messages"`
+}
+
+// handleChatCompletions serves a synthetic chat completion, streamed or not.
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var request chatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	if s.random() < s.config.ErrorRate {
+		writeError(w, s.config.ErrorStatus, "injected error")
+		return
+	}
+
+	id := fmt.Sprintf("chatcmpl-mock-%d", time.Now().UnixNano())
+	created := time.Now().Unix()
+	// Like the completion tokens, the prompt tokens are counted as words.
+	var promptTokens int
+	for _, message := range request.Messages {
+		promptTokens += len(strings.Fields(message.Content))
+	}
+	usage := map[string]int{
+		"prompt_tokens":     promptTokens,
+		"completion_tokens": s.config.Tokens,
+		"total_tokens":      promptTokens + s.config.Tokens,
+	}
+
+	if !request.Stream {
+		if !s.sleep(r, s.config.TTFT+time.Duration(s.config.Tokens)*s.tokenInterval()) {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"id": id, "object": "chat.completion", "created": created, "model": request.Model,
+			"choices": []map[string]any{{
+				"index":         0,
+				"message":       map[string]any{"role": "assistant", "content": strings.Join(s.tokens(), "")},
+				"finish_reason": "stop",
+			}},
+			"usage": usage,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	writeEvent := func(choices []map[string]any, extra map[string]any) {
+		event := map[string]any{
+			"id": id, "object": "chat.completion.chunk", "created": created, "model": request.Model, "choices": choices,
+		}
+		for key, value := range extra {
+			event[key] = value
+		}
+		data, _ := json.Marshal(event)
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+
+	for i, token := range s.tokens() {
+		delay := s.tokenInterval()
+		if i == 0 {
+			delay = s.config.TTFT
+		}
+		if !s.sleep(r, delay) {
+			return
+		}
+		writeEvent([]map[string]any{{"index": 0, "delta": map[string]any{"content": token}}}, nil)
+	}
+
+	writeEvent([]map[string]any{{"index": 0, "delta": map[string]any{}, "finish_reason": "stop"}}, nil)
+	if request.StreamOptions.IncludeUsage {
+		writeEvent([]map[string]any{}, map[string]any{"usage": usage})
+	}
+	_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+// tokens returns the tokens of a response.
+func (s *Server) tokens() []string {
+	tokens := make([]string, s.config.Tokens)
+	for i := range tokens {
+		tokens[i] = words[i%len(words)] + " "
+	}
+	return tokens
+}
+
+// tokenInterval returns the delay between consecutive tokens.
+func (s *Server) tokenInterval() time.Duration {
+	if s.config.TokenRate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / s.config.TokenRate)
+}
+
+// sleep waits for the given delay, varied by the jitter.
+// It returns false if the request was canceled in the meantime.
+func (s *Server) sleep(r *http.Request, delay time.Duration) bool {
+	if s.config.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + s.config.Jitter*(2*s.random()-1)))
+	}
+	if delay <= 0 {
+		return r.Context().Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-r.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// random returns a pseudo-random number in [0, 1).
+func (s *Server) random() float64 {
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+	return s.rng.Float64()
+}
+
+// writeJSON writes the given value as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes an error response in the format of the OpenAI API.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]any{"message": message, "type": "mock_error"}})
+}
@@ -0,0 +1,126 @@
+package mock_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/mock"
+)
+
+// newServer starts a mock server with the given configuration and returns its URL.
+func newServer(t *testing.T, config mock.Config) string {
+	t.Helper()
+	server := httptest.NewServer(mock.New(config))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// TestServer_Stream verifies that streamed responses follow the configuration.
+func TestServer_Stream(t *testing.T) {
+	ttft := 50 * time.Millisecond
+	url := newServer(t, mock.Config{TTFT: ttft, TokenRate: 1000, Tokens: 10})
+
+	client := api.NewClient(url)
+	messages := []api.ChatMessage{{Role: api.RoleUser, Content: "Hello there"}}
+
+	start := time.Now()
+	stream, err := client.ChatCompletionStream(context.Background(), "any-model", messages, api.ChatOptions{})
+	require.NoError(t, err)
+
+	events, err := stream.Drain(context.Background())
+	require.NoError(t, err)
+
+	var content strings.Builder
+	var firstTokenAt time.Time
+	for _, event := range events {
+		if len(event.Choices) > 0 && event.Choices[0].Delta.Content != "" {
+			if firstTokenAt.IsZero() {
+				firstTokenAt = event.Timestamp()
+			}
+			content.WriteString(event.Choices[0].Delta.Content)
+		}
+	}
+
+	assert.Len(t, strings.Fields(content.String()), 10, "The response should have the configured number of tokens.")
+	assert.GreaterOrEqual(t, firstTokenAt.Sub(start), ttft, "The first token should arrive after the TTFT.")
+}
+
+// TestServer_NonStream verifies non-streamed responses and their usage.
+func TestServer_NonStream(t *testing.T) {
+	url := newServer(t, mock.Config{Tokens: 5})
+
+	body := `{"model":"m","messages":[{"role":"user","content":"one two three"}]}`
+	response, err := http.Post(url+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer func() { _ = response.Body.Close() }()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var completion struct {
+		Choices []struct {
+			Message api.ChatMessage `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&completion))
+
+	require.Len(t, completion.Choices, 1)
+	assert.Len(t, strings.Fields(completion.Choices[0].Message.Content), 5)
+	assert.Equal(t, 3, completion.Usage.PromptTokens)
+	assert.Equal(t, 5, completion.Usage.CompletionTokens)
+}
+
+// TestServer_Errors verifies error injection and its reproducibility.
+func TestServer_Errors(t *testing.T) {
+	// statuses returns the status codes of a number of requests to a fresh server.
+	statuses := func(config mock.Config) []int {
+		url := newServer(t, config)
+		var codes []int
+		for range 20 {
+			response, err := http.Post(url+"/v1/chat/completions", "application/json", strings.NewReader(`{"stream":true}`))
+			require.NoError(t, err)
+			_ = response.Body.Close()
+			codes = append(codes, response.StatusCode)
+		}
+		return codes
+	}
+
+	t.Run("Always Failing", func(t *testing.T) {
+		for _, code := range statuses(mock.Config{Tokens: 1, ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable}) {
+			assert.Equal(t, http.StatusServiceUnavailable, code)
+		}
+	})
+
+	t.Run("Never Failing", func(t *testing.T) {
+		for _, code := range statuses(mock.Config{Tokens: 1, ErrorStatus: http.StatusServiceUnavailable}) {
+			assert.Equal(t, http.StatusOK, code)
+		}
+	})
+
+	t.Run("Reproducible With Seed", func(t *testing.T) {
+		config := mock.Config{Tokens: 1, ErrorRate: 0.5, ErrorStatus: http.StatusInternalServerError, Seed: 42}
+		first := statuses(config)
+		assert.Equal(t, first, statuses(config), "The same seed should fail the same requests.")
+		assert.Contains(t, first, http.StatusOK)
+		assert.Contains(t, first, http.StatusInternalServerError)
+	})
+}
+
+// TestServer_Models verifies the models API.
+func TestServer_Models(t *testing.T) {
+	response, err := http.Get(newServer(t, mock.Config{}) + "/v1/models")
+	require.NoError(t, err)
+	defer func() { _ = response.Body.Close() }()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
@@ -0,0 +1,304 @@
+// Package plugin runs providers implemented by external programs, so that backends
+// that don't speak the OpenAI compatible API can be added without changing llmb.
+//
+// A provider is an executable named llmb-provider-<name>, found through PATH.
+// For every API request, it is started and given the request as one JSON object on stdin:
+//
+//	{"method": "POST", "path": "/v1/chat/completions", "body": {...}}
+//
+// where the body is the OpenAI compatible request body. It must answer with JSON lines on stdout.
+// For streamed requests, every line is a chat completion chunk object. Otherwise, the single line
+// is the response object. An object with an "error" field, as the first line, fails the request.
+// The provider must exit with status 0 once done.
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Prefix is the prefix of the names of provider executables.
+const Prefix = "llmb-provider-"
+
+// Discover returns the paths of the provider executables found in PATH, by provider name.
+// Like a shell, it prefers the one in the earliest PATH directory when a name appears more than once.
+func Discover() map[string]string {
+	providers := map[string]string{}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue // PATH may have missing directories.
+		}
+
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), Prefix)
+			if !ok || name == "" || entry.IsDir() {
+				continue
+			}
+			name = strings.TrimSuffix(name, filepath.Ext(name)) // Like .exe on Windows.
+			if _, exists := providers[name]; exists {
+				continue
+			}
+			if path, err := exec.LookPath(filepath.Join(dir, entry.Name())); err == nil {
+				providers[name] = path
+			}
+		}
+	}
+
+	return providers
+}
+
+// Find returns the path of the executable of the provider with the given name.
+func Find(name string) (string, error) {
+	providers := Discover()
+	if path, ok := providers[name]; ok {
+		return path, nil
+	}
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	if len(names) == 0 {
+		return "", fmt.Errorf("provider %q not found: no %s* executables in PATH", name, Prefix)
+	}
+	return "", fmt.Errorf("provider %q not found, available: %s", name, strings.Join(names, ", "))
+}
+
+// request is the JSON object given to a provider on stdin.
+type request struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Transport is an http.RoundTripper that executes requests with a provider executable.
+type Transport struct {
+	// Path is the path of the provider executable.
+	Path string
+}
+
+// RoundTrip runs the provider for the request, and converts its output to an HTTP response.
+// Streamed responses are converted to server-sent events as the provider produces them.
+func (t *Transport) RoundTrip(httpRequest *http.Request) (*http.Response, error) {
+	input := request{Method: httpRequest.Method, Path: httpRequest.URL.Path}
+	if httpRequest.Body != nil {
+		body, err := io.ReadAll(httpRequest.Body)
+		_ = httpRequest.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		if len(bytes.TrimSpace(body)) > 0 {
+			input.Body = body
+		}
+	}
+
+	var options struct {
+		Stream bool `json:"stream"`
+	}
+	_ = json.Unmarshal(input.Body, &options) // Absent for requests without a body.
+
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal provider input: %w", err)
+	}
+
+	// The process is killed if the request is canceled, or once the response body is closed.
+	ctx, cancel := context.WithCancel(httpRequest.Context())
+	cmd := exec.CommandContext(ctx, t.Path)
+	cmd.Stdin = bytes.NewReader(inputJSON)
+	stderr := &limitedBuffer{limit: 4096}
+	cmd.Stderr = stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create provider stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start provider: %w", err)
+	}
+
+	process := &process{cmd: cmd, cancel: cancel, stderr: stderr}
+	lines := bufio.NewReader(stdout)
+
+	// The first line decides the status of the response, like headers would.
+	first, err := readLine(lines)
+	if err != nil {
+		return errorResponse(httpRequest, http.StatusBadGateway, process.fail(err)), nil
+	}
+	if isError(first) {
+		_, _ = io.Copy(io.Discard, lines) // The rest is of no use, but must not block the provider.
+		_ = process.wait()
+		return newResponse(httpRequest, http.StatusBadGateway, "application/json", io.NopCloser(bytes.NewReader(first))), nil
+	}
+
+	if !options.Stream {
+		_, _ = io.Copy(io.Discard, lines)
+		if err := process.wait(); err != nil {
+			return errorResponse(httpRequest, http.StatusBadGateway, err), nil
+		}
+		return newResponse(httpRequest, http.StatusOK, "application/json", io.NopCloser(bytes.NewReader(first))), nil
+	}
+
+	body := &eventBody{process: process, lines: lines}
+	body.pending.WriteString(event(first))
+	return newResponse(httpRequest, http.StatusOK, "text/event-stream", body), nil
+}
+
+// process is a running provider.
+type process struct {
+	cmd    *exec.Cmd
+	cancel context.CancelFunc
+	stderr *limitedBuffer
+
+	waitOnce sync.Once
+	waitErr  error
+}
+
+// wait waits for the process to exit, and returns an error describing its failure, if any.
+func (p *process) wait() error {
+	p.waitOnce.Do(func() {
+		defer p.cancel()
+		if err := p.cmd.Wait(); err != nil {
+			p.waitErr = fmt.Errorf("provider failed: %w: %s", err, strings.TrimSpace(p.stderr.String()))
+		}
+	})
+	return p.waitErr
+}
+
+// fail waits for the process after the given error in reading its output, and returns the combined error.
+func (p *process) fail(err error) error {
+	if waitErr := p.wait(); waitErr != nil {
+		return waitErr
+	}
+	if errors.Is(err, io.EOF) {
+		return errors.New("provider exited without output")
+	}
+	return fmt.Errorf("failed to read provider output: %w", err)
+}
+
+// eventBody is a response body that converts the JSON lines of a provider to server-sent events.
+type eventBody struct {
+	process *process
+	lines   *bufio.Reader
+	pending bytes.Buffer
+	done    bool
+}
+
+// Read reads the events converted so far, converting the next line when they run out.
+func (b *eventBody) Read(p []byte) (int, error) {
+	for b.pending.Len() == 0 {
+		if b.done {
+			return 0, io.EOF
+		}
+
+		line, err := readLine(b.lines)
+		switch {
+		case err == nil:
+			b.pending.WriteString(event(line))
+		case errors.Is(err, io.EOF):
+			b.done = true
+			if err := b.process.wait(); err != nil {
+				return 0, err
+			}
+			b.pending.WriteString("data: [DONE]\n\n")
+		default:
+			b.done = true
+			return 0, b.process.fail(err)
+		}
+	}
+	return b.pending.Read(p)
+}
+
+// Close stops the provider, if it's still running.
+func (b *eventBody) Close() error {
+	b.process.cancel()
+	_ = b.process.wait()
+	return nil
+}
+
+// readLine reads the next non-blank line.
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	for {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// event formats the given JSON line as a server-sent event.
+func event(line []byte) string {
+	return "data: " + string(line) + "\n\n"
+}
+
+// isError reports whether the given JSON line is an error object.
+func isError(line []byte) bool {
+	var object struct {
+		Error json.RawMessage `json:"error"`
+	}
+	return json.Unmarshal(line, &object) == nil && len(object.Error) > 0 && string(object.Error) != "null"
+}
+
+// newResponse returns an HTTP response with the given status, content type, and body.
+func newResponse(request *http.Request, status int, contentType string, body io.ReadCloser) *http.Response {
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode: status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{contentType}},
+		Body:       body,
+		Request:    request,
+	}
+}
+
+// errorResponse returns an HTTP response with the given status, and the error in the OpenAI format.
+func errorResponse(request *http.Request, status int, err error) *http.Response {
+	body, _ := json.Marshal(map[string]any{"error": map[string]any{"message": err.Error(), "type": "provider_error"}})
+	return newResponse(request, status, "application/json", io.NopCloser(bytes.NewReader(body)))
+}
+
+// limitedBuffer keeps the first bytes written to it, up to a limit, discarding the rest.
+type limitedBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+	limit  int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - b.buffer.Len(); room > 0 {
+		b.buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.String()
+}
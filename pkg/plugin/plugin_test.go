@@ -0,0 +1,128 @@
+package plugin_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/plugin"
+)
+
+// echoProvider is a provider that answers according to its input: with a stream of two chunks,
+// a whole response, an error object, or a crash.
+const echoProvider = `#!/bin/sh
+input=$(cat)
+case "$input" in
+  *'"stream":true'*)
+    echo '{"choices":[{"delta":{"content":"streamed "}}]}'
+    echo '{"choices":[{"delta":{"content":"reply"}}]}'
+    ;;
+  *'"fail"'*)
+    echo '{"error":{"message":"model overloaded"}}'
+    ;;
+  *'"crash"'*)
+    echo "something broke" >&2
+    exit 3
+    ;;
+  *)
+    echo '{"choices":[{"message":{"role":"assistant","content":"whole reply"}}]}'
+    ;;
+esac
+`
+
+// installProvider writes a provider script with the given name to a new directory in PATH.
+func installProvider(t *testing.T, name, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("Provider scripts need a Unix shell.")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, plugin.Prefix+name)
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return path
+}
+
+// TestDiscover verifies that providers are found in PATH.
+func TestDiscover(t *testing.T) {
+	path := installProvider(t, "echo", echoProvider)
+
+	assert.Equal(t, path, plugin.Discover()["echo"])
+
+	found, err := plugin.Find("echo")
+	require.NoError(t, err)
+	assert.Equal(t, path, found)
+
+	_, err = plugin.Find("missing")
+	assert.ErrorContains(t, err, "echo", "The error should list the available providers.")
+}
+
+// TestTransport verifies that requests are executed by the provider.
+func TestTransport(t *testing.T) {
+	path := installProvider(t, "echo", echoProvider)
+	httpClient := &http.Client{Transport: &plugin.Transport{Path: path}}
+
+	// post sends the body to the provider and returns the status code and the response body.
+	post := func(body string) (int, string) {
+		response, err := httpClient.Post("http://provider/v1/chat/completions", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer func() { _ = response.Body.Close() }()
+		content, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		return response.StatusCode, string(content)
+	}
+
+	t.Run("Stream Through the Client", func(t *testing.T) {
+		client := api.NewClient("http://provider", api.WithTransport(&plugin.Transport{Path: path}))
+		stream, err := client.ChatCompletionStream(context.Background(), "m",
+			[]api.ChatMessage{{Role: api.RoleUser, Content: "Hi"}}, api.ChatOptions{})
+		require.NoError(t, err)
+
+		events, err := stream.Drain(context.Background())
+		require.NoError(t, err)
+
+		var content strings.Builder
+		for _, event := range events {
+			if len(event.Choices) > 0 {
+				content.WriteString(event.Choices[0].Delta.Content)
+			}
+		}
+		assert.Equal(t, "streamed reply", content.String())
+	})
+
+	t.Run("Stream Format", func(t *testing.T) {
+		status, body := post(`{"stream":true}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "data: {\"choices\":[{\"delta\":{\"content\":\"streamed \"}}]}\n\n"+
+			"data: {\"choices\":[{\"delta\":{\"content\":\"reply\"}}]}\n\n"+
+			"data: [DONE]\n\n", body)
+	})
+
+	t.Run("Not Streamed", func(t *testing.T) {
+		status, body := post(`{"stream":false}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "whole reply")
+	})
+
+	t.Run("Error Object", func(t *testing.T) {
+		status, body := post(`{"model":"fail"}`)
+		assert.Equal(t, http.StatusBadGateway, status)
+		assert.Contains(t, body, "model overloaded")
+	})
+
+	t.Run("Failed Process", func(t *testing.T) {
+		status, body := post(`{"model":"crash"}`)
+		assert.Equal(t, http.StatusBadGateway, status)
+		assert.Contains(t, body, "something broke", "The error should include the provider's stderr.")
+	})
+}
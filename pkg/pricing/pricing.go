@@ -0,0 +1,54 @@
+// Package pricing provides cost estimation of API calls based on per-model token prices.
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Price holds the token prices of a model, in currency units per million tokens.
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// Table maps model names to their prices.
+type Table map[string]Price
+
+// Load reads a pricing table from the JSON file at the given path.
+//
+// The file must map model names to their prices per million tokens, for example:
+//
+//	{"gpt-4.1": {"input": 2.0, "output": 8.0}}
+func Load(path string) (Table, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing file: %w", err)
+	}
+
+	var table Table
+	if err := json.Unmarshal(content, &table); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pricing file: %w", err)
+	}
+
+	for model, price := range table {
+		if price.Input < 0 || price.Output < 0 {
+			return nil, fmt.Errorf("negative price for model %q", model)
+		}
+	}
+
+	return table, nil
+}
+
+// Cost returns the cost of a call to the given model with the given token counts.
+// The boolean is false if the model is not present in the table.
+func (t Table) Cost(model string, inputTokens, outputTokens int) (float64, bool) {
+	price, ok := t[model]
+	if !ok {
+		return 0, false
+	}
+
+	const million = 1_000_000
+	return (float64(inputTokens)*price.Input + float64(outputTokens)*price.Output) / million, true
+}
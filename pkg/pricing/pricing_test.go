@@ -0,0 +1,51 @@
+package pricing_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/pricing"
+)
+
+// TestLoad verifies loading valid and invalid pricing files.
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("Valid File", func(t *testing.T) {
+		path := filepath.Join(dir, "valid.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"m": {"input": 1.5, "output": 3}}`), 0o644))
+
+		table, err := pricing.Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, pricing.Table{"m": {Input: 1.5, Output: 3}}, table)
+	})
+
+	t.Run("Negative Price", func(t *testing.T) {
+		path := filepath.Join(dir, "negative.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"m": {"input": -1}}`), 0o644))
+
+		_, err := pricing.Load(path)
+		assert.ErrorContains(t, err, "negative price")
+	})
+
+	t.Run("Missing File", func(t *testing.T) {
+		_, err := pricing.Load(filepath.Join(dir, "missing.json"))
+		assert.Error(t, err)
+	})
+}
+
+// TestTable_Cost verifies the cost calculation.
+func TestTable_Cost(t *testing.T) {
+	table := pricing.Table{"m": {Input: 2, Output: 8}}
+
+	cost, ok := table.Cost("m", 1_000_000, 500_000)
+	assert.True(t, ok)
+	assert.InDelta(t, 6.0, cost, 1e-9)
+
+	_, ok = table.Cost("unknown", 1, 1)
+	assert.False(t, ok)
+}
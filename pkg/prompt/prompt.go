@@ -0,0 +1,126 @@
+// Package prompt provides reusable prompt templates, with variables in the Go template syntax,
+// like "Summarize this:\n{{.text}}".
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Extension is the file extension of stored templates.
+const Extension = ".tmpl"
+
+// Template is a parsed prompt template.
+type Template struct {
+	Text     string
+	template *template.Template
+}
+
+// Parse parses the given template text.
+func Parse(text string) (*Template, error) {
+	// Rendering fails on variables without values, instead of silently rendering "<no value>".
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return &Template{Text: text, template: tmpl}, nil
+}
+
+// Load reads and parses the template in the file at the given path.
+func Load(path string) (*Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template file: %w", err)
+	}
+	return Parse(string(content))
+}
+
+// Save writes the template to the file at the given path, creating parent directories as required.
+func (t *Template) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create template directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(t.Text), 0o644); err != nil {
+		return fmt.Errorf("failed to write template file: %w", err)
+	}
+	return nil
+}
+
+// Render returns the prompt with the variables replaced by the given values.
+// It fails if the template uses a variable that has no value.
+func (t *Template) Render(vars map[string]string) (string, error) {
+	var builder strings.Builder
+	if err := t.template.Execute(&builder, vars); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return builder.String(), nil
+}
+
+// Variables returns the sorted names of the variables used by the template.
+func (t *Template) Variables() []string {
+	var names []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch node := node.(type) {
+		case *parse.ListNode:
+			if node != nil {
+				for _, child := range node.Nodes {
+					walk(child)
+				}
+			}
+		case *parse.ActionNode:
+			walk(node.Pipe)
+		case *parse.PipeNode:
+			if node != nil {
+				for _, cmd := range node.Cmds {
+					walk(cmd)
+				}
+			}
+		case *parse.CommandNode:
+			for _, arg := range node.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			names = append(names, node.Ident[0])
+		case *parse.IfNode:
+			walk(node.Pipe)
+			walk(node.List)
+			walk(node.ElseList)
+		case *parse.RangeNode:
+			// Fields within the body refer to the elements, not to the variables.
+			walk(node.Pipe)
+			walk(node.ElseList)
+		case *parse.WithNode:
+			walk(node.Pipe)
+			walk(node.ElseList)
+		}
+	}
+	walk(t.template.Tree.Root)
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// List returns the sorted names of the templates stored in the given directory.
+// A missing directory has no templates.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read template directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), Extension); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,82 @@
+package prompt_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/prompt"
+)
+
+// TestTemplate_Render verifies the substitution of variables.
+func TestTemplate_Render(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		vars    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "No Variables", text: "Hello.", want: "Hello."},
+		{name: "Variables", text: "Summarize in {{.words}} words:\n{{.text}}", vars: map[string]string{"words": "50", "text": "Notes."},
+			want: "Summarize in 50 words:\nNotes."},
+		{name: "Conditional", text: "Hi{{if .name}} {{.name}}{{end}}!", vars: map[string]string{"name": "Ann"}, want: "Hi Ann!"},
+		{name: "Missing Variable", text: "{{.text}}", vars: map[string]string{}, wantErr: true},
+		{name: "Extra Variable", text: "Hi.", vars: map[string]string{"unused": "x"}, want: "Hi."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := prompt.Parse(tt.text)
+			require.NoError(t, err)
+
+			got, err := tmpl.Render(tt.vars)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := prompt.Parse("{{.text")
+	assert.Error(t, err, "Invalid templates must fail to parse.")
+}
+
+// TestTemplate_Variables verifies the listing of the variables used by a template.
+func TestTemplate_Variables(t *testing.T) {
+	tmpl, err := prompt.Parse(`{{.b}} {{.a}} {{if .c}}{{.d}}{{else}}{{.e}}{{end}} {{range .items}}{{.inner}}{{end}} {{.b | printf "%s"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "items"}, tmpl.Variables())
+}
+
+// TestSaveLoadList verifies the storage of templates in a directory.
+func TestSaveLoadList(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "prompts")
+
+	names, err := prompt.List(dir)
+	require.NoError(t, err, "A missing directory has no templates.")
+	assert.Empty(t, names)
+
+	for _, name := range []string{"summarize", "review"} {
+		tmpl, err := prompt.Parse("Do {{.what}}.")
+		require.NoError(t, err)
+		require.NoError(t, tmpl.Save(filepath.Join(dir, name+prompt.Extension)))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a template"), 0o600))
+
+	names, err = prompt.List(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"review", "summarize"}, names)
+
+	loaded, err := prompt.Load(filepath.Join(dir, "review"+prompt.Extension))
+	require.NoError(t, err)
+	assert.Equal(t, "Do {{.what}}.", loaded.Text)
+
+	_, err = prompt.Load(filepath.Join(dir, "missing"+prompt.Extension))
+	assert.Error(t, err)
+}
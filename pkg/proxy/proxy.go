@@ -0,0 +1,301 @@
+// Package proxy provides a reverse proxy for OpenAI compatible APIs that records every request it forwards,
+// and optionally spreads the requests over several upstreams.
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Record holds the measurements of one proxied request.
+type Record struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	Model  string    `json:"model,omitempty"`
+	Stream bool      `json:"stream"`
+	Status int       `json:"status"`
+
+	// LatencyMS is the time taken by the whole request, in milliseconds.
+	LatencyMS float64 `json:"latency_ms"`
+	// TTFTMS is the time taken by the first content chunk of a streamed response, in milliseconds.
+	TTFTMS float64 `json:"ttft_ms,omitempty"`
+	// Chunks is the number of content chunks of a streamed response.
+	Chunks int `json:"chunks,omitempty"`
+
+	// Token counts, if the upstream reported the usage.
+	PromptTokens     int `json:"prompt_tokens,omitempty"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	// Upstream is the upstream that served the request, and Attempts is the number of upstreams tried.
+	// They are only set if the proxy has several upstreams.
+	Upstream string `json:"upstream,omitempty"`
+	Attempts int    `json:"attempts,omitempty"`
+}
+
+// maxBufferedBody is the maximum size of a non-streamed response body that is inspected for its usage.
+const maxBufferedBody = 10 << 20
+
+// errRetry is returned by ModifyResponse to discard a failed response that is retried on another upstream.
+var errRetry = errors.New("retrying on another upstream")
+
+// attemptKey is the context key under which the in-progress attempt of a request is kept.
+type attemptKey struct{}
+
+// attempt is the forwarding of a request to one upstream.
+type attempt struct {
+	record   *Record
+	upstream *url.URL
+	start    time.Time
+	// last is true if there are no more upstreams to try after this one.
+	last bool
+	// retry is set if the attempt failed before anything was written, and the next upstream should be tried.
+	retry bool
+}
+
+// Proxy forwards requests to an upstream API, streaming the responses through unchanged,
+// and records each request once it is complete.
+type Proxy struct {
+	reverseProxy *httputil.ReverseProxy
+	router       *Router
+	record       func(Record)
+}
+
+// New returns a Proxy that forwards requests to the given upstream base URL and passes the
+// record of every completed request to the given function, which must be safe for concurrent use.
+func New(upstream *url.URL, record func(Record)) *Proxy {
+	// A single upstream with a known strategy cannot fail.
+	router, _ := NewRouter([]Upstream{{URL: upstream}}, Failover)
+	return NewWithRouter(router, record)
+}
+
+// NewWithRouter is like New, but spreads the requests over the upstreams of the router.
+//
+// A request that fails with a connection error or a 5xx status is retried on the next upstream
+// in the router's order, until one succeeds or all have been tried.
+func NewWithRouter(router *Router, record func(Record)) *Proxy {
+	p := &Proxy{router: router, record: record}
+
+	p.reverseProxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(r.In.Context().Value(attemptKey{}).(*attempt).upstream)
+			// The responses are inspected, so they must not be compressed.
+			r.Out.Header.Del("Accept-Encoding")
+		},
+		// Flush immediately, so streamed responses are passed through as they arrive.
+		FlushInterval: -1,
+		ModifyResponse: func(response *http.Response) error {
+			a := response.Request.Context().Value(attemptKey{}).(*attempt)
+			failed := response.StatusCode >= http.StatusInternalServerError
+			if failed {
+				p.router.observe(a.upstream, false, 0)
+			} else {
+				p.router.observe(a.upstream, true, time.Since(a.start))
+			}
+			if failed && !a.last {
+				a.retry = true
+				return errRetry
+			}
+
+			a.record.Status, a.record.Error = response.StatusCode, ""
+			response.Body = &observer{ReadCloser: response.Body, record: a.record}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			a := r.Context().Value(attemptKey{}).(*attempt)
+			if errors.Is(err, errRetry) {
+				return
+			}
+
+			a.record.Error = err.Error()
+			// A request canceled by the client says nothing about the upstream.
+			if r.Context().Err() == nil {
+				p.router.observe(a.upstream, false, 0)
+				if !a.last {
+					a.retry = true
+					return
+				}
+			}
+
+			a.record.Status = http.StatusBadGateway
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	return p
+}
+
+// ServeHTTP forwards the request to the upstream and records it.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &Record{Time: time.Now(), Method: r.Method, Path: r.URL.Path}
+
+	// Peek at the request body for the model and the stream mode. It is kept to be sent to every upstream tried.
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		var request struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		// Not every request has a JSON body, so failures are ignored.
+		_ = json.Unmarshal(body, &request)
+		rec.Model, rec.Stream = request.Model, request.Stream
+	}
+
+	// The reverse proxy copies the whole response before returning, so the record is complete afterwards.
+	// It panics if the response is cut short, hence the defer.
+	defer func() {
+		rec.LatencyMS = milliseconds(time.Since(rec.Time))
+		p.record(*rec)
+	}()
+
+	upstreams := p.router.order()
+	for i, upstream := range upstreams {
+		a := &attempt{record: rec, upstream: upstream, start: time.Now(), last: i == len(upstreams)-1}
+		if len(upstreams) > 1 {
+			rec.Upstream, rec.Attempts = upstream.String(), i+1
+		}
+
+		attemptRequest := r.WithContext(context.WithValue(r.Context(), attemptKey{}, a))
+		if body != nil {
+			attemptRequest.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		p.reverseProxy.ServeHTTP(w, attemptRequest)
+
+		if !a.retry {
+			return
+		}
+	}
+}
+
+// observer is a response body that records the measurements of the response as it is read.
+type observer struct {
+	io.ReadCloser
+	record *Record
+
+	// pending holds the incomplete trailing line of a streamed response,
+	// or the whole body of a non-streamed one.
+	pending  []byte
+	overflow bool
+}
+
+// Read reads from the underlying body and inspects what was read.
+func (o *observer) Read(p []byte) (int, error) {
+	n, err := o.ReadCloser.Read(p)
+	if n > 0 {
+		o.inspect(p[:n])
+	}
+
+	switch {
+	case errors.Is(err, io.EOF) && o.record.Stream:
+		// The last line may not end with a line break.
+		o.inspectEvent(string(o.pending))
+	case errors.Is(err, io.EOF):
+		o.inspectBody()
+	case err != nil:
+		o.record.Error = err.Error()
+	}
+	return n, err
+}
+
+// inspect processes the given part of the response body.
+func (o *observer) inspect(data []byte) {
+	if !o.record.Stream {
+		if len(o.pending)+len(data) > maxBufferedBody {
+			o.overflow, o.pending = true, nil
+		}
+		if !o.overflow {
+			o.pending = append(o.pending, data...)
+		}
+		return
+	}
+
+	// Only complete lines are processed, the trailing part waits for more data.
+	o.pending = append(o.pending, data...)
+	for {
+		end := bytes.IndexByte(o.pending, '\n')
+		if end < 0 {
+			return
+		}
+		o.inspectEvent(string(o.pending[:end]))
+		o.pending = o.pending[end+1:]
+	}
+}
+
+// inspectEvent processes one line of a streamed response.
+func (o *observer) inspectEvent(line string) {
+	data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:")
+	if !ok {
+		return
+	}
+
+	var event completion
+	if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+		return // Like [DONE].
+	}
+
+	for _, choice := range event.Choices {
+		if choice.Delta.Content == "" && choice.Delta.ReasoningContent == "" && choice.Delta.Reasoning == "" {
+			continue
+		}
+		if o.record.Chunks == 0 {
+			o.record.TTFTMS = milliseconds(time.Since(o.record.Time))
+		}
+		o.record.Chunks++
+	}
+	o.recordUsage(event)
+}
+
+// inspectBody processes the whole body of a non-streamed response.
+func (o *observer) inspectBody() {
+	var response completion
+	if !o.overflow && json.Unmarshal(o.pending, &response) == nil {
+		o.recordUsage(response)
+	}
+	o.pending = nil
+}
+
+// recordUsage records the token usage of the response, if it has any.
+func (o *observer) recordUsage(response completion) {
+	if response.Usage != nil {
+		o.record.PromptTokens = response.Usage.PromptTokens
+		o.record.CompletionTokens = response.Usage.CompletionTokens
+	}
+}
+
+// completion holds the parts of a chat completion response, or of one of its streamed events,
+// that are relevant for recording.
+type completion struct {
+	Choices []struct {
+		Delta struct {
+			Content          string `json:"content"`
+			ReasoningContent string `json:"reasoning_content"`
+			Reasoning        string `json:"reasoning"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// milliseconds converts the given duration to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,136 @@
+package proxy_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/proxy"
+)
+
+// newRecordingProxy starts a proxy in front of the given upstream URL
+// and returns its URL along with the channel that receives its records.
+func newRecordingProxy(t *testing.T, upstream string) (string, <-chan proxy.Record) {
+	t.Helper()
+
+	upstreamURL, err := url.Parse(upstream)
+	require.NoError(t, err)
+
+	records := make(chan proxy.Record, 10)
+	proxyServer := httptest.NewServer(proxy.New(upstreamURL, func(r proxy.Record) { records <- r }))
+	t.Cleanup(proxyServer.Close)
+
+	return proxyServer.URL, records
+}
+
+// newUpstream starts a server with the given handler and returns its URL.
+func newUpstream(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// nextRecord waits for the next record, as it is made only after the response is sent.
+func nextRecord(t *testing.T, records <-chan proxy.Record) proxy.Record {
+	t.Helper()
+	select {
+	case record := <-records:
+		return record
+	case <-time.After(time.Second):
+		require.FailNow(t, "Timed out waiting for the record.")
+		return proxy.Record{}
+	}
+}
+
+// post sends a POST request with the given body and returns the status code and response body.
+func post(t *testing.T, url, body string) (int, string) {
+	t.Helper()
+	response, err := http.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer func() { _ = response.Body.Close() }()
+
+	responseBody, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return response.StatusCode, string(responseBody)
+}
+
+// TestProxy verifies that requests are forwarded unchanged and recorded.
+func TestProxy(t *testing.T) {
+	t.Run("Streamed Response", func(t *testing.T) {
+		events := "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n" +
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":2}}\n\n" +
+			"data: [DONE]\n\n"
+
+		proxyURL, records := newRecordingProxy(t, newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, line := range strings.SplitAfter(events, "\n\n") {
+				_, _ = io.WriteString(w, line)
+				w.(http.Flusher).Flush()
+			}
+		}))
+
+		status, body := post(t, proxyURL+"/v1/chat/completions", `{"model":"gpt-4.1","stream":true}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, events, body, "The response should be passed through unchanged.")
+
+		record := nextRecord(t, records)
+		assert.Equal(t, "gpt-4.1", record.Model)
+		assert.True(t, record.Stream)
+		assert.Equal(t, http.StatusOK, record.Status)
+		assert.Equal(t, 2, record.Chunks)
+		assert.Equal(t, 7, record.PromptTokens)
+		assert.Equal(t, 2, record.CompletionTokens)
+		assert.Positive(t, record.TTFTMS)
+		assert.GreaterOrEqual(t, record.LatencyMS, record.TTFTMS)
+	})
+
+	t.Run("Non-Streamed Response", func(t *testing.T) {
+		const response = `{"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":5}}`
+		proxyURL, records := newRecordingProxy(t, newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, response)
+		}))
+
+		status, body := post(t, proxyURL+"/v1/chat/completions", `{"model":"m"}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, response, body)
+
+		record := nextRecord(t, records)
+		assert.False(t, record.Stream)
+		assert.Equal(t, 3, record.PromptTokens)
+		assert.Equal(t, 5, record.CompletionTokens)
+		assert.Zero(t, record.TTFTMS)
+	})
+
+	t.Run("Upstream Error Status", func(t *testing.T) {
+		proxyURL, records := newRecordingProxy(t, newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "overloaded", http.StatusTooManyRequests)
+		}))
+
+		status, body := post(t, proxyURL+"/v1/chat/completions", `{"model":"m","stream":true}`)
+		assert.Equal(t, http.StatusTooManyRequests, status)
+		assert.Equal(t, "overloaded\n", body)
+
+		assert.Equal(t, http.StatusTooManyRequests, nextRecord(t, records).Status)
+	})
+
+	t.Run("Unreachable Upstream", func(t *testing.T) {
+		proxyURL, records := newRecordingProxy(t, "http://127.0.0.1:1")
+
+		status, _ := post(t, proxyURL+"/v1/models", "")
+		assert.Equal(t, http.StatusBadGateway, status)
+
+		record := nextRecord(t, records)
+		assert.Equal(t, http.StatusBadGateway, record.Status)
+		assert.NotEmpty(t, record.Error)
+	})
+}
@@ -0,0 +1,210 @@
+package proxy
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Strategy decides which upstream of a Router serves a request.
+type Strategy string
+
+// The supported strategies.
+const (
+	// RoundRobin sends requests to the healthy upstreams in turn.
+	RoundRobin Strategy = "round-robin"
+	// LeastLatency sends requests to the healthy upstream with the lowest recent response latency.
+	LeastLatency Strategy = "least-latency"
+	// Weighted sends requests to the healthy upstreams at random, in proportion to their weights.
+	Weighted Strategy = "weighted"
+	// Failover sends requests to the first healthy upstream, in the given order.
+	Failover Strategy = "failover"
+)
+
+// Strategies returns the names of all supported strategies.
+func Strategies() []string {
+	return []string{string(RoundRobin), string(LeastLatency), string(Weighted), string(Failover)}
+}
+
+// latencySmoothing is the weight of the newest latency in the moving average of an upstream's latency.
+const latencySmoothing = 0.3
+
+// Upstream is an API that a Router sends requests to.
+type Upstream struct {
+	URL *url.URL
+	// Weight is the relative share of requests for the Weighted strategy. Zero counts as 1.
+	Weight int
+}
+
+// backend holds the state of an upstream.
+type backend struct {
+	Upstream
+	healthy bool
+	// latency is the moving average of the time taken by the upstream to respond.
+	latency time.Duration
+}
+
+// Router spreads requests over several upstreams according to a Strategy.
+//
+// Upstreams that fail with a connection error or a 5xx status are marked unhealthy and tried last,
+// until they succeed again, either with a request or with a health check.
+type Router struct {
+	strategy Strategy
+
+	mu       sync.Mutex
+	backends []*backend
+	next     int
+	rng      *rand.Rand
+}
+
+// NewRouter returns a Router for the given upstreams, which are all assumed healthy to begin with.
+func NewRouter(upstreams []Upstream, strategy Strategy) (*Router, error) {
+	if len(upstreams) == 0 {
+		return nil, errors.New("at least one upstream is required")
+	}
+	if !slices.Contains(Strategies(), string(strategy)) {
+		return nil, fmt.Errorf("unknown strategy %q", strategy)
+	}
+
+	router := &Router{strategy: strategy, rng: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))}
+	for _, upstream := range upstreams {
+		if upstream.Weight < 0 {
+			return nil, fmt.Errorf("negative weight for upstream %s", upstream.URL)
+		}
+		router.backends = append(router.backends, &backend{Upstream: upstream, healthy: true})
+	}
+	return router, nil
+}
+
+// Len returns the number of upstreams.
+func (r *Router) Len() int {
+	return len(r.backends)
+}
+
+// order returns all the upstreams in the order they should be tried for a request.
+// The upstream chosen by the strategy comes first, followed by the other healthy upstreams,
+// and then the unhealthy ones.
+func (r *Router) order() []*url.URL {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var healthy, unhealthy []*backend
+	for _, b := range r.backends {
+		if b.healthy {
+			healthy = append(healthy, b)
+		} else {
+			unhealthy = append(unhealthy, b)
+		}
+	}
+
+	if len(healthy) > 0 {
+		switch r.strategy {
+		case RoundRobin:
+			start := r.next % len(healthy)
+			r.next++
+			healthy = append(healthy[start:], healthy[:start]...)
+		case LeastLatency:
+			// Upstreams without a measurement yet have zero latency, so they are tried first.
+			slices.SortStableFunc(healthy, func(a, b *backend) int { return cmp.Compare(a.latency, b.latency) })
+		case Weighted:
+			chosen := r.pickWeighted(healthy)
+			first := healthy[chosen]
+			healthy = append([]*backend{first}, slices.Delete(healthy, chosen, chosen+1)...)
+		case Failover:
+			// The given order is the failover order.
+		}
+	}
+
+	urls := make([]*url.URL, 0, len(r.backends))
+	for _, b := range append(healthy, unhealthy...) {
+		urls = append(urls, b.URL)
+	}
+	return urls
+}
+
+// pickWeighted returns the index of a random backend, chosen in proportion to the weights.
+func (r *Router) pickWeighted(backends []*backend) int {
+	var total int
+	for _, b := range backends {
+		total += max(b.Weight, 1)
+	}
+
+	n := r.rng.IntN(total)
+	for i, b := range backends {
+		if n -= max(b.Weight, 1); n < 0 {
+			return i
+		}
+	}
+	return len(backends) - 1
+}
+
+// observe updates the state of the upstream with the outcome of a request to it.
+// A latency is only given for requests that succeeded.
+func (r *Router) observe(upstream *url.URL, healthy bool, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, b := range r.backends {
+		if b.URL != upstream {
+			continue
+		}
+		b.healthy = healthy
+		if latency > 0 {
+			if b.latency == 0 {
+				b.latency = latency
+			} else {
+				b.latency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(b.latency))
+			}
+		}
+	}
+}
+
+// CheckHealth checks the health of every upstream at the given interval, until the context is canceled.
+// An upstream is healthy if it responds to a request for its model list without a 5xx status.
+func (r *Router) CheckHealth(ctx context.Context, client *http.Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var wg sync.WaitGroup
+		for _, b := range r.backends {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.observe(b.URL, checkHealth(ctx, client, b.URL), 0)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// checkHealth returns true if the upstream responds to a request for its model list without a 5xx status.
+func checkHealth(ctx context.Context, client *http.Client, upstream *url.URL) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.JoinPath("v1/models").String(), nil)
+	if err != nil {
+		return false
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return false
+	}
+	_ = response.Body.Close()
+	return response.StatusCode < http.StatusInternalServerError
+}
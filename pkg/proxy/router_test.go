@@ -0,0 +1,187 @@
+package proxy_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/proxy"
+)
+
+// countingUpstream starts an upstream that responds with the given status, and counts its chat requests.
+func countingUpstream(t *testing.T, status *atomic.Int32) (*url.URL, *atomic.Int32) {
+	t.Helper()
+	var count atomic.Int32
+	upstream, err := url.Parse(newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		count.Add(1)
+		w.WriteHeader(int(status.Load()))
+		_, _ = fmt.Fprint(w, `{"choices":[]}`)
+	}))
+	require.NoError(t, err)
+	return upstream, &count
+}
+
+// newStatus returns a status holder that can be changed while the upstream runs.
+func newStatus(status int) *atomic.Int32 {
+	var s atomic.Int32
+	s.Store(int32(status))
+	return &s
+}
+
+// newRoutedProxy starts a proxy in front of the given router and returns its URL along with its records.
+func newRoutedProxy(t *testing.T, router *proxy.Router) (string, <-chan proxy.Record) {
+	t.Helper()
+	records := make(chan proxy.Record, 100)
+	server := newUpstream(t, proxy.NewWithRouter(router, func(r proxy.Record) { records <- r }).ServeHTTP)
+	return server, records
+}
+
+// TestNewRouter verifies the validation of the router settings.
+func TestNewRouter(t *testing.T) {
+	upstream, err := url.Parse("http://localhost:8080")
+	require.NoError(t, err)
+
+	_, err = proxy.NewRouter(nil, proxy.RoundRobin)
+	assert.Error(t, err, "At least one upstream is required.")
+
+	_, err = proxy.NewRouter([]proxy.Upstream{{URL: upstream}}, "random")
+	assert.Error(t, err, "The strategy must be known.")
+
+	_, err = proxy.NewRouter([]proxy.Upstream{{URL: upstream, Weight: -1}}, proxy.Weighted)
+	assert.Error(t, err, "Weights must not be negative.")
+}
+
+// TestRouter verifies the spreading of requests by every strategy, and the failover between upstreams.
+func TestRouter(t *testing.T) {
+	t.Run("Round Robin", func(t *testing.T) {
+		a, countA := countingUpstream(t, newStatus(http.StatusOK))
+		b, countB := countingUpstream(t, newStatus(http.StatusOK))
+		router, err := proxy.NewRouter([]proxy.Upstream{{URL: a}, {URL: b}}, proxy.RoundRobin)
+		require.NoError(t, err)
+		proxyURL, records := newRoutedProxy(t, router)
+
+		for range 6 {
+			status, _ := post(t, proxyURL+"/v1/chat/completions", `{}`)
+			assert.Equal(t, http.StatusOK, status)
+		}
+		assert.Equal(t, int32(3), countA.Load())
+		assert.Equal(t, int32(3), countB.Load())
+
+		record := nextRecord(t, records)
+		assert.Equal(t, a.String(), record.Upstream)
+		assert.Equal(t, 1, record.Attempts)
+	})
+
+	t.Run("Failover on 5xx", func(t *testing.T) {
+		statusA := newStatus(http.StatusServiceUnavailable)
+		a, countA := countingUpstream(t, statusA)
+		b, countB := countingUpstream(t, newStatus(http.StatusOK))
+		router, err := proxy.NewRouter([]proxy.Upstream{{URL: a}, {URL: b}}, proxy.Failover)
+		require.NoError(t, err)
+		proxyURL, records := newRoutedProxy(t, router)
+
+		status, _ := post(t, proxyURL+"/v1/chat/completions", `{}`)
+		assert.Equal(t, http.StatusOK, status)
+		record := nextRecord(t, records)
+		assert.Equal(t, b.String(), record.Upstream)
+		assert.Equal(t, 2, record.Attempts)
+
+		// The failed upstream is now tried last.
+		status, _ = post(t, proxyURL+"/v1/chat/completions", `{}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, int32(1), countA.Load())
+		assert.Equal(t, int32(2), countB.Load())
+	})
+
+	t.Run("Failover on Connection Error", func(t *testing.T) {
+		dead, err := url.Parse("http://127.0.0.1:1")
+		require.NoError(t, err)
+		b, countB := countingUpstream(t, newStatus(http.StatusOK))
+		router, err := proxy.NewRouter([]proxy.Upstream{{URL: dead}, {URL: b}}, proxy.Failover)
+		require.NoError(t, err)
+		proxyURL, _ := newRoutedProxy(t, router)
+
+		status, _ := post(t, proxyURL+"/v1/chat/completions", `{"model":"m"}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, int32(1), countB.Load())
+	})
+
+	t.Run("All Upstreams Failing", func(t *testing.T) {
+		a, _ := countingUpstream(t, newStatus(http.StatusInternalServerError))
+		b, _ := countingUpstream(t, newStatus(http.StatusBadGateway))
+		router, err := proxy.NewRouter([]proxy.Upstream{{URL: a}, {URL: b}}, proxy.Failover)
+		require.NoError(t, err)
+		proxyURL, records := newRoutedProxy(t, router)
+
+		status, _ := post(t, proxyURL+"/v1/chat/completions", `{}`)
+		assert.Equal(t, http.StatusBadGateway, status, "The response of the last upstream is passed through.")
+		assert.Equal(t, 2, nextRecord(t, records).Attempts)
+	})
+
+	t.Run("Weighted", func(t *testing.T) {
+		a, countA := countingUpstream(t, newStatus(http.StatusOK))
+		b, countB := countingUpstream(t, newStatus(http.StatusOK))
+		router, err := proxy.NewRouter([]proxy.Upstream{{URL: a, Weight: 1}, {URL: b, Weight: 9}}, proxy.Weighted)
+		require.NoError(t, err)
+		proxyURL, _ := newRoutedProxy(t, router)
+
+		for range 100 {
+			post(t, proxyURL+"/v1/chat/completions", `{}`)
+		}
+		assert.Equal(t, int32(100), countA.Load()+countB.Load())
+		assert.Greater(t, countB.Load(), countA.Load()*2, "The heavier upstream gets most requests.")
+	})
+
+	t.Run("Least Latency", func(t *testing.T) {
+		slow, err := url.Parse(newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+		}))
+		require.NoError(t, err)
+		fast, countFast := countingUpstream(t, newStatus(http.StatusOK))
+		router, err := proxy.NewRouter([]proxy.Upstream{{URL: slow}, {URL: fast}}, proxy.LeastLatency)
+		require.NoError(t, err)
+		proxyURL, _ := newRoutedProxy(t, router)
+
+		// The first two requests measure both upstreams, the rest go to the fastest.
+		for range 6 {
+			post(t, proxyURL+"/v1/chat/completions", `{}`)
+		}
+		assert.Equal(t, int32(5), countFast.Load())
+	})
+
+	t.Run("Health Checks", func(t *testing.T) {
+		statusA := newStatus(http.StatusInternalServerError)
+		a, countA := countingUpstream(t, statusA)
+		b, countB := countingUpstream(t, newStatus(http.StatusOK))
+		router, err := proxy.NewRouter([]proxy.Upstream{{URL: a}, {URL: b}}, proxy.Failover)
+		require.NoError(t, err)
+		proxyURL, _ := newRoutedProxy(t, router)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go router.CheckHealth(ctx, http.DefaultClient, 10*time.Millisecond)
+
+		// Once the health check marks the first upstream unhealthy, requests skip it.
+		time.Sleep(50 * time.Millisecond)
+		post(t, proxyURL+"/v1/chat/completions", `{}`)
+		assert.Equal(t, int32(0), countA.Load())
+		assert.Equal(t, int32(1), countB.Load())
+
+		// Once it recovers, it is preferred again.
+		statusA.Store(http.StatusOK)
+		time.Sleep(50 * time.Millisecond)
+		post(t, proxyURL+"/v1/chat/completions", `{}`)
+		assert.Equal(t, int32(1), countA.Load())
+	})
+}
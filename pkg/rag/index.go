@@ -0,0 +1,154 @@
+// Package rag provides a small, file-backed vector store for Retrieval-Augmented Generation.
+//
+// Documents are split into overlapping chunks, embedded using a caller-provided
+// function, and persisted as a single JSON file. At query time, the chunks most
+// similar to the query (by cosine similarity) are retrieved so they can be
+// injected into the conversation as context.
+//
+// The store is intentionally simple: it is loaded fully into memory and searched
+// linearly. This is more than fast enough for the personal knowledge bases a CLI
+// tool is expected to handle, and keeps the on-disk format trivially inspectable.
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// EmbedFunc converts a batch of texts into embedding vectors, one per text, in order.
+type EmbedFunc func(ctx context.Context, texts []string) ([][]float64, error)
+
+// Chunk is a single embedded piece of a source document.
+type Chunk struct {
+	Source string    `json:"source"`
+	Text   string    `json:"text"`
+	Vector []float64 `json:"vector"`
+}
+
+// Index is an in-memory collection of embedded chunks.
+type Index struct {
+	// EmbeddingModel is the model used to embed all chunks of this index.
+	// Queries must be embedded using the same model for the similarity to be meaningful.
+	EmbeddingModel string  `json:"embedding_model"`
+	Chunks         []Chunk `json:"chunks"`
+}
+
+// Match is a search result, holding a chunk and its similarity score with the query.
+type Match struct {
+	Chunk
+	Score float64
+}
+
+// Load reads an index from the given file path.
+func Load(path string) (*Index, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index file: %w", err)
+	}
+
+	var index Index
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index file: %w", err)
+	}
+
+	return &index, nil
+}
+
+// Save writes the index to the given file path, creating parent directories as required.
+func (idx *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	content, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %w", err)
+	}
+
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write index file: %w", err)
+	}
+
+	return nil
+}
+
+// Add chunks the given text, embeds all chunks in batches and adds them to the index.
+// Any existing chunks from the same source are replaced, so re-adding a file is idempotent.
+func (idx *Index) Add(ctx context.Context, source, text string, chunkSize, overlap int, embed EmbedFunc) error {
+	texts := Split(text, chunkSize, overlap)
+
+	// Embedding in batches keeps request bodies at a reasonable size.
+	const batchSize = 32
+
+	chunks := make([]Chunk, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+
+		vectors, err := embed(ctx, texts[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to embed chunks of %s: %w", source, err)
+		}
+
+		for i, vector := range vectors {
+			chunks = append(chunks, Chunk{Source: source, Text: texts[start+i], Vector: vector})
+		}
+	}
+
+	// Remove the stale chunks of this source.
+	kept := idx.Chunks[:0]
+	for _, chunk := range idx.Chunks {
+		if chunk.Source != source {
+			kept = append(kept, chunk)
+		}
+	}
+
+	idx.Chunks = append(kept, chunks...)
+	return nil
+}
+
+// Search returns the top k chunks most similar to the given query vector, most similar first.
+func (idx *Index) Search(query []float64, k int) ([]Match, error) {
+	if len(query) == 0 {
+		return nil, errors.New("query vector is empty")
+	}
+
+	matches := make([]Match, 0, len(idx.Chunks))
+	for _, chunk := range idx.Chunks {
+		// Vectors of a different dimension were not produced by the same model.
+		if len(chunk.Vector) != len(query) {
+			return nil, fmt.Errorf("query dimension %d does not match index dimension %d",
+				len(query), len(chunk.Vector))
+		}
+		matches = append(matches, Match{Chunk: chunk, Score: cosineSimilarity(query, chunk.Vector)})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+
+	return matches, nil
+}
+
+// cosineSimilarity returns the cosine of the angle between the two given vectors of equal length.
+// It returns 0 if either of the vectors has zero magnitude.
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,95 @@
+package rag_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/rag"
+)
+
+// mockEmbed is an EmbedFunc that embeds a text as a 2-D vector of its counts of 'a' and 'b' runes.
+func mockEmbed(_ context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float64{float64(strings.Count(text, "a")), float64(strings.Count(text, "b"))}
+	}
+	return vectors, nil
+}
+
+// TestSplit verifies the chunking behavior across various sizes and overlaps.
+func TestSplit(t *testing.T) {
+	type testCase struct {
+		name     string
+		text     string
+		size     int
+		overlap  int
+		expected []string
+	}
+
+	testCases := []testCase{
+		{name: "Text Smaller Than Chunk", text: "hello world", size: 100, expected: []string{"hello world"}},
+		{name: "Cut at Whitespace", text: "aaa bbb ccc", size: 5, expected: []string{"aaa", "bbb", "ccc"}},
+		{name: "Overlapping Chunks", text: "abcdefgh", size: 4, overlap: 2, expected: []string{"abcd", "cdef", "efgh"}},
+		{name: "Blank Text", text: "   \n\t ", size: 4, expected: nil},
+		{name: "Invalid Size", text: "hello", size: 0, expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, rag.Split(tc.text, tc.size, tc.overlap))
+		})
+	}
+}
+
+// TestIsText verifies the binary content detection.
+func TestIsText(t *testing.T) {
+	assert.True(t, rag.IsText([]byte("plain text ✓")))
+	assert.False(t, rag.IsText([]byte{0x7f, 'E', 'L', 'F', 0x00}))
+	assert.False(t, rag.IsText([]byte{0xff, 0xfe, 0xfd}))
+}
+
+// TestIndex verifies adding, searching, saving, and loading an index.
+func TestIndex(t *testing.T) {
+	index := &rag.Index{EmbeddingModel: "test-model"}
+	require.NoError(t, index.Add(context.Background(), "a.txt", "aaaa", 100, 0, mockEmbed))
+	require.NoError(t, index.Add(context.Background(), "b.txt", "bbbb", 100, 0, mockEmbed))
+
+	t.Run("Search Returns Most Similar First", func(t *testing.T) {
+		matches, err := index.Search([]float64{0, 1}, 1)
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, "b.txt", matches[0].Source)
+		assert.InDelta(t, 1.0, matches[0].Score, 1e-9)
+	})
+
+	t.Run("Search With Mismatched Dimension", func(t *testing.T) {
+		_, err := index.Search([]float64{1, 2, 3}, 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("Re-Adding a Source Replaces Its Chunks", func(t *testing.T) {
+		require.NoError(t, index.Add(context.Background(), "a.txt", "aaab", 100, 0, mockEmbed))
+		assert.Len(t, index.Chunks, 2)
+	})
+
+	t.Run("Embedding Failure", func(t *testing.T) {
+		failing := func(context.Context, []string) ([][]float64, error) { return nil, errors.New("boom") }
+		err := index.Add(context.Background(), "c.txt", "cccc", 100, 0, failing)
+		assert.ErrorContains(t, err, "boom")
+	})
+
+	t.Run("Save and Load Round Trip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "index.json")
+		require.NoError(t, index.Save(path))
+
+		loaded, err := rag.Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, index, loaded)
+	})
+}
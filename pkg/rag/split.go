@@ -0,0 +1,82 @@
+package rag
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Split splits the given text into chunks of roughly `size` characters, where
+// consecutive chunks share `overlap` characters so that no piece of information
+// is lost at a chunk boundary.
+//
+// Chunks are cut at whitespace where possible, so words are not broken in half.
+// Blank chunks are never returned.
+func Split(text string, size, overlap int) []string {
+	if size <= 0 {
+		return nil
+	}
+	// The overlap must be smaller than the chunk size, otherwise the split never progresses.
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	runes := []rune(text)
+	var chunks []string
+
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+
+		// Prefer to cut at the last whitespace within the chunk, unless this is the final chunk.
+		if end < len(runes) {
+			if cut := lastSpace(runes[start:end]); cut > overlap {
+				end = start + cut
+			}
+		}
+
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+
+		if end == len(runes) {
+			break
+		}
+		start = end - overlap
+	}
+
+	return chunks
+}
+
+// IsText reports whether the given content looks like human-readable text.
+// It is used to skip binary files while indexing directories.
+func IsText(content []byte) bool {
+	// Only sniff the beginning of the content, like http.DetectContentType does.
+	const sniffLen = 512
+	sample := content[:min(len(content), sniffLen)]
+	truncated := len(sample) < len(content)
+
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		if r == utf8.RuneError && size == 1 {
+			// A rune cut in half by the sniffing limit is not a sign of binary content.
+			return truncated && len(sample)-i < utf8.UTFMax
+		}
+		// NUL bytes essentially never occur in text files.
+		if r == 0 {
+			return false
+		}
+		i += size
+	}
+
+	return true
+}
+
+// lastSpace returns the index of the last whitespace rune in the given slice, or -1.
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		switch runes[i] {
+		case ' ', '\n', '\t', '\r':
+			return i
+		}
+	}
+	return -1
+}
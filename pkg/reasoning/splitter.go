@@ -0,0 +1,87 @@
+// Package reasoning provides utilities for separating a model's reasoning
+// (chain-of-thought) from its final answer.
+//
+// Many reasoning models emit their thoughts inline with the answer, wrapped in
+// `<think>…</think>` tags. Since the response is streamed, a tag may be split
+// across any number of tokens, so the separation must be done incrementally.
+package reasoning
+
+import (
+	"strings"
+)
+
+const (
+	openTag  = "<think>"
+	closeTag = "</think>"
+)
+
+// Splitter incrementally separates `<think>…</think>` sections from the rest of a streamed text.
+//
+// The zero value is ready to use. A Splitter must not be used concurrently.
+type Splitter struct {
+	// inside is true while within a think section.
+	inside bool
+	// pending holds the trailing text that could be the beginning of a tag,
+	// which can only be classified once more text arrives.
+	pending string
+}
+
+// Write consumes the next piece of the text and returns the parts of it that
+// are now known to be reasoning and answer respectively.
+func (s *Splitter) Write(chunk string) (reasoning, answer string) {
+	text := s.pending + chunk
+	s.pending = ""
+
+	var reasoningBuilder, answerBuilder strings.Builder
+	for text != "" {
+		// The tag that ends the current section, and where its content goes.
+		tag, builder := openTag, &answerBuilder
+		if s.inside {
+			tag, builder = closeTag, &reasoningBuilder
+		}
+
+		if index := strings.Index(text, tag); index >= 0 {
+			builder.WriteString(text[:index])
+			text = text[index+len(tag):]
+			s.inside = !s.inside
+			continue
+		}
+
+		// Hold back the longest suffix that could be the start of the tag.
+		held := partialTagLen(text, tag)
+		builder.WriteString(text[:len(text)-held])
+		s.pending = text[len(text)-held:]
+		break
+	}
+
+	return reasoningBuilder.String(), answerBuilder.String()
+}
+
+// Flush returns any text held back by Write, to be called once the stream ends.
+func (s *Splitter) Flush() (reasoning, answer string) {
+	pending := s.pending
+	s.pending = ""
+
+	if s.inside {
+		return pending, ""
+	}
+	return "", pending
+}
+
+// Split separates all `<think>…</think>` sections of the given complete text from the rest.
+func Split(text string) (reasoning, answer string) {
+	var splitter Splitter
+	reasoning, answer = splitter.Write(text)
+	flushedReasoning, flushedAnswer := splitter.Flush()
+	return reasoning + flushedReasoning, answer + flushedAnswer
+}
+
+// partialTagLen returns the length of the longest suffix of text that is a proper prefix of tag.
+func partialTagLen(text, tag string) int {
+	for n := min(len(tag)-1, len(text)); n > 0; n-- {
+		if strings.HasPrefix(tag, text[len(text)-n:]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package reasoning_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shivanshkc/llmb/pkg/reasoning"
+)
+
+// TestSplitter verifies that think sections are separated correctly regardless
+// of how the text is split into chunks.
+func TestSplitter(t *testing.T) {
+	type testCase struct {
+		name              string
+		chunks            []string
+		expectedReasoning string
+		expectedAnswer    string
+	}
+
+	testCases := []testCase{
+		{
+			name:           "No Think Section",
+			chunks:         []string{"Hello", " world"},
+			expectedAnswer: "Hello world",
+		},
+		{
+			name:              "Think Section in One Chunk",
+			chunks:            []string{"<think>hmm</think>Answer"},
+			expectedReasoning: "hmm",
+			expectedAnswer:    "Answer",
+		},
+		{
+			name:              "Tags Split Across Chunks",
+			chunks:            []string{"<th", "ink>let me ", "think</", "thi", "nk>The answer"},
+			expectedReasoning: "let me think",
+			expectedAnswer:    "The answer",
+		},
+		{
+			name:           "Partial Tag That Never Completes",
+			chunks:         []string{"a <thi", "s is not a tag"},
+			expectedAnswer: "a <this is not a tag",
+		},
+		{
+			name:              "Unterminated Think Section",
+			chunks:            []string{"<think>still thinking</thi"},
+			expectedReasoning: "still thinking</thi",
+		},
+		{
+			name:           "Trailing Partial Tag Is Flushed",
+			chunks:         []string{"The end <"},
+			expectedAnswer: "The end <",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var splitter reasoning.Splitter
+			var actualReasoning, actualAnswer string
+
+			for _, chunk := range tc.chunks {
+				r, a := splitter.Write(chunk)
+				actualReasoning += r
+				actualAnswer += a
+			}
+			r, a := splitter.Flush()
+			actualReasoning += r
+			actualAnswer += a
+
+			assert.Equal(t, tc.expectedReasoning, actualReasoning)
+			assert.Equal(t, tc.expectedAnswer, actualAnswer)
+		})
+	}
+}
+
+// TestSplit verifies the convenience function for complete texts.
+func TestSplit(t *testing.T) {
+	r, a := reasoning.Split("<think>a</think>b<think>c</think>d")
+	assert.Equal(t, "ac", r)
+	assert.Equal(t, "bd", a)
+}
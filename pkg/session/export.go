@@ -0,0 +1,102 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+// The formats a session can be exported to.
+const (
+	// FormatMarkdown is a readable document with a section per message, for sharing.
+	FormatMarkdown = "markdown"
+	// FormatJSON is a chat completion request body in the OpenAI format, with the model
+	// and the messages, for replaying the conversation.
+	FormatJSON = "json"
+)
+
+// Formats returns the names of the export formats.
+func Formats() []string {
+	return []string{FormatMarkdown, FormatJSON}
+}
+
+// FormatForPath returns the export format implied by the extension of the given path,
+// which is FormatJSON for .json files, and FormatMarkdown for all others.
+func FormatForPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatMarkdown
+}
+
+// Export writes the messages of the current branch of the session to the writer, in the given format.
+func (s *Session) Export(writer io.Writer, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return exportMarkdown(writer, s.Model, s.Messages())
+	case FormatJSON:
+		return exportJSON(writer, s.Model, s.Messages())
+	default:
+		return fmt.Errorf("unknown export format %q, must be one of: %s", format, strings.Join(Formats(), ", "))
+	}
+}
+
+// exportJSON writes the messages as an indented chat completion request body.
+func exportJSON(writer io.Writer, model string, messages []api.ChatMessage) error {
+	// The body is complete, even without messages, so that it's always valid.
+	if messages == nil {
+		messages = []api.ChatMessage{}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	body := struct {
+		Model    string            `json:"model"`
+		Messages []api.ChatMessage `json:"messages"`
+	}{Model: model, Messages: messages}
+	if err := encoder.Encode(body); err != nil {
+		return fmt.Errorf("failed to encode conversation: %w", err)
+	}
+	return nil
+}
+
+// exportMarkdown writes the messages as a Markdown document, with a section per message, headed by its role.
+func exportMarkdown(writer io.Writer, model string, messages []api.ChatMessage) error {
+	var doc strings.Builder
+	fmt.Fprintf(&doc, "# Conversation with %s\n", model)
+
+	for _, message := range messages {
+		fmt.Fprintf(&doc, "\n## %s\n", roleTitle(message.Role))
+		if message.Content != "" {
+			fmt.Fprintf(&doc, "\n%s\n", strings.TrimSpace(message.Content))
+		}
+		for _, part := range message.Parts {
+			if part.Type == api.ContentTypeImageURL {
+				doc.WriteString("\n*(image attached)*\n")
+			}
+		}
+		for _, call := range message.ToolCalls {
+			fmt.Fprintf(&doc, "\nCalled `%s` with:\n\n```json\n%s\n```\n", call.Function.Name, call.Function.Arguments)
+		}
+	}
+
+	if _, err := io.WriteString(writer, doc.String()); err != nil {
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	return nil
+}
+
+// roleTitle returns the section title of the messages of the given role, like "User".
+func roleTitle(role string) string {
+	if role == api.RoleTool {
+		return "Tool Result"
+	}
+	if role == "" {
+		return "Message"
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
@@ -0,0 +1,61 @@
+package session_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/session"
+)
+
+// TestSession_Export verifies the Markdown and JSON exports of the current branch.
+func TestSession_Export(t *testing.T) {
+	saved := &session.Session{
+		Model:  "test-model",
+		Branch: "main",
+		Branches: map[string][]api.ChatMessage{
+			"main": {
+				{Role: api.RoleSystem, Content: "Be terse."},
+				{Role: api.RoleUser, Content: "What time is it?", Parts: []api.ContentPart{api.NewImagePart("data:image/png;base64,AA==")}},
+				{Role: api.RoleAssistant, ToolCalls: []api.ToolCall{
+					{ID: "call_1", Type: "function", Function: api.FunctionCall{Name: "get_time", Arguments: `{}`}},
+				}},
+				{Role: api.RoleTool, Content: "12:00", ToolCallID: "call_1"},
+				{Role: api.RoleAssistant, Content: "Noon.\n"},
+			},
+			"other": {{Role: api.RoleUser, Content: "Elsewhere."}},
+		},
+	}
+
+	var markdown bytes.Buffer
+	require.NoError(t, saved.Export(&markdown, session.FormatMarkdown))
+	assert.Equal(t, "# Conversation with test-model\n"+
+		"\n## System\n\nBe terse.\n"+
+		"\n## User\n\nWhat time is it?\n\n*(image attached)*\n"+
+		"\n## Assistant\n\nCalled `get_time` with:\n\n```json\n{}\n```\n"+
+		"\n## Tool Result\n\n12:00\n"+
+		"\n## Assistant\n\nNoon.\n", markdown.String())
+
+	var body bytes.Buffer
+	require.NoError(t, saved.Export(&body, session.FormatJSON))
+	var request struct {
+		Model    string            `json:"model"`
+		Messages []api.ChatMessage `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(body.Bytes(), &request))
+	assert.Equal(t, "test-model", request.Model)
+	assert.Equal(t, saved.Messages(), request.Messages)
+
+	assert.ErrorContains(t, saved.Export(&body, "html"), "unknown export format")
+}
+
+// TestFormatForPath verifies that the export format is implied by the file extension.
+func TestFormatForPath(t *testing.T) {
+	assert.Equal(t, session.FormatJSON, session.FormatForPath("chat.JSON"))
+	assert.Equal(t, session.FormatMarkdown, session.FormatForPath("chat.md"))
+	assert.Equal(t, session.FormatMarkdown, session.FormatForPath("chat"))
+}
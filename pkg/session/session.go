@@ -0,0 +1,236 @@
+// Package session provides persistence of chat sessions.
+//
+// A session holds the complete state of a conversation, including all of its
+// branches, so that it can be resumed exactly where it was left off.
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/api/tokenizer"
+)
+
+// Extension is the file extension of saved sessions.
+const Extension = ".json"
+
+// Session is the persistable state of a chat session.
+type Session struct {
+	Model string `json:"model"`
+	// Branch is the name of the current conversation branch.
+	Branch string `json:"branch"`
+	// Branches holds the message histories of all branches by name.
+	Branches map[string][]api.ChatMessage `json:"branches"`
+	// Pins holds the indices of the pinned messages of every branch by name.
+	// Pinned messages are never trimmed to fit the context window.
+	Pins      map[string][]int `json:"pins,omitempty"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// Messages returns the message history of the current branch.
+func (s *Session) Messages() []api.ChatMessage {
+	return s.Branches[s.Branch]
+}
+
+// Turns returns the number of turns of the current branch, which is the number of its user messages.
+func (s *Session) Turns() int {
+	var turns int
+	for _, message := range s.Messages() {
+		if message.Role == api.RoleUser {
+			turns++
+		}
+	}
+	return turns
+}
+
+// Fork returns a new session with a copy of the current branch, and its pins, keeping only its first turns,
+// or all of them if turns is zero. The messages before the first turn, like the system prompt, are always kept.
+func (s *Session) Fork(turns int) *Session {
+	messages := s.Messages()
+	if turns > 0 {
+		var seen int
+		for i, message := range messages {
+			if message.Role != api.RoleUser {
+				continue
+			}
+			if seen == turns {
+				messages = messages[:i]
+				break
+			}
+			seen++
+		}
+	}
+
+	fork := &Session{
+		Model:     s.Model,
+		Branch:    s.Branch,
+		Branches:  map[string][]api.ChatMessage{s.Branch: slices.Clone(messages)},
+		UpdatedAt: time.Now(),
+	}
+	for _, index := range s.Pins[s.Branch] {
+		if index < len(messages) {
+			if fork.Pins == nil {
+				fork.Pins = map[string][]int{}
+			}
+			fork.Pins[s.Branch] = append(fork.Pins[s.Branch], index)
+		}
+	}
+	return fork
+}
+
+// ChatMessages is a message history.
+type ChatMessages []api.ChatMessage
+
+// Tokens returns the number of prompt tokens of the messages for the given model, as counted by tokenizer.CountTokens.
+func (m ChatMessages) Tokens(model string) int {
+	return tokenizer.CountTokens(model, m)
+}
+
+// TruncateToTokens returns the messages that fit within the given number of prompt tokens for the given model,
+// dropping the oldest turns first. A turn starts with a user message and holds the responses to it, including
+// tool calls and their results, so that no response is sent without its question.
+//
+// The system messages, the turns with pinned messages, given by index, and the last turn are never dropped,
+// so the result may still exceed the limit.
+func (m ChatMessages) TruncateToTokens(model string, limit int, pinned []int) ChatMessages {
+	kept := m.KeptInTokens(model, limit, pinned)
+	if !slices.Contains(kept, false) {
+		return m
+	}
+
+	truncated := make(ChatMessages, 0, len(m))
+	for i, message := range m {
+		if kept[i] {
+			truncated = append(truncated, message)
+		}
+	}
+	return truncated
+}
+
+// KeptInTokens tells which of the messages, by index, TruncateToTokens keeps to fit within the given number of
+// prompt tokens for the given model.
+func (m ChatMessages) KeptInTokens(model string, limit int, pinned []int) []bool {
+	keep := make([]bool, len(m))
+	for i := range keep {
+		keep[i] = true
+	}
+
+	total := m.Tokens(model)
+	if total <= limit {
+		return keep
+	}
+
+	// The turns, as the indices of their first messages, and whether they can be dropped.
+	var starts []int
+	droppable := map[int]bool{}
+	turn := -1
+	for i, message := range m {
+		if message.Role == api.RoleSystem {
+			continue
+		}
+		if turn < 0 || message.Role == api.RoleUser {
+			turn = i
+			starts = append(starts, turn)
+			droppable[turn] = true
+		}
+		if slices.Contains(pinned, i) {
+			droppable[turn] = false
+		}
+	}
+
+	// Drop the oldest droppable turns, except the last one, until the rest fits.
+	for k := 0; k < len(starts)-1 && total > limit; k++ {
+		if !droppable[starts[k]] {
+			continue
+		}
+		for i := starts[k]; i < starts[k+1]; i++ {
+			if m[i].Role != api.RoleSystem {
+				keep[i] = false
+				total -= tokenizer.CountTokens(model, m[i:i+1]) - tokenizer.CountTokens(model, nil)
+			}
+		}
+	}
+	return keep
+}
+
+// Load reads a session from the file at the given path.
+func Load(path string) (*Session, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(content, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session file: %w", err)
+	}
+
+	if _, ok := session.Branches[session.Branch]; !ok {
+		return nil, fmt.Errorf("session file is corrupt: current branch %q does not exist", session.Branch)
+	}
+
+	return &session, nil
+}
+
+// List returns the sorted names of the sessions saved in the given directory.
+// A missing directory has no sessions.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read session directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), Extension); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Save writes the session to the file at the given path, creating parent directories as required.
+//
+// The write is atomic: the file is either fully replaced or left untouched, so
+// a crash during the save never corrupts the previously saved session.
+func (s *Session) Save(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	// Write to a temporary file in the same directory, so the rename is atomic.
+	tempFile, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary session file: %w", err)
+	}
+	// Cleanup in case of failure. This is a no-op after a successful rename.
+	defer func() { _ = os.Remove(tempFile.Name()) }()
+
+	if _, err := tempFile.Write(content); err != nil {
+		_ = tempFile.Close()
+		return fmt.Errorf("failed to write temporary session file: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary session file: %w", err)
+	}
+
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace session file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package session_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/session"
+)
+
+// TestSaveAndLoad verifies that a saved session is loaded back identically.
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "session.json")
+
+	original := &session.Session{
+		Model:  "test-model",
+		Branch: "alt",
+		Branches: map[string][]api.ChatMessage{
+			"main": {{Role: api.RoleUser, Content: "hello"}},
+			"alt":  {{Role: api.RoleUser, Content: "hello"}, {Role: api.RoleAssistant, Content: "hi"}},
+		},
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	require.NoError(t, original.Save(path))
+	// Saving again must replace the file.
+	require.NoError(t, original.Save(path))
+
+	loaded, err := session.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, loaded)
+	assert.Len(t, loaded.Messages(), 2)
+	assert.Equal(t, 1, loaded.Turns())
+
+	// No temporary files must be left behind.
+	files, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+// TestList verifies that the saved sessions are listed by name, leaving out other files.
+func TestList(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+
+	names, err := session.List(dir)
+	require.NoError(t, err, "A missing directory has no sessions.")
+	assert.Empty(t, names)
+
+	saved := &session.Session{Branch: "main", Branches: map[string][]api.ChatMessage{"main": nil}}
+	for _, name := range []string{"work", "last"} {
+		require.NoError(t, saved.Save(filepath.Join(dir, name+session.Extension)))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a session"), 0o600))
+
+	names, err = session.List(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"last", "work"}, names)
+}
+
+// TestSession_Fork verifies that a fork copies the current branch, up to the given number of turns.
+func TestSession_Fork(t *testing.T) {
+	original := &session.Session{
+		Model:  "test-model",
+		Branch: "alt",
+		Branches: map[string][]api.ChatMessage{
+			"main": {{Role: api.RoleUser, Content: "hello"}},
+			"alt": {
+				{Role: api.RoleSystem, Content: "be brief"},
+				{Role: api.RoleUser, Content: "one"}, {Role: api.RoleAssistant, Content: "1"},
+				{Role: api.RoleUser, Content: "two"}, {Role: api.RoleAssistant, Content: "2"},
+			},
+		},
+		Pins: map[string][]int{"alt": {0, 3}},
+	}
+
+	type testCase struct {
+		name             string
+		turns            int
+		expectedMessages int
+		expectedPins     map[string][]int
+	}
+
+	testCases := []testCase{
+		{name: "All Turns", turns: 0, expectedMessages: 5, expectedPins: map[string][]int{"alt": {0, 3}}},
+		{name: "First Turn", turns: 1, expectedMessages: 3, expectedPins: map[string][]int{"alt": {0}}},
+		{name: "More Turns Than There Are", turns: 5, expectedMessages: 5, expectedPins: map[string][]int{"alt": {0, 3}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fork := original.Fork(tc.turns)
+			assert.Equal(t, "test-model", fork.Model)
+			assert.Equal(t, "alt", fork.Branch)
+			assert.Len(t, fork.Branches, 1, "only the current branch must be copied")
+			assert.Equal(t, original.Messages()[:tc.expectedMessages], fork.Messages())
+			assert.Equal(t, tc.expectedPins, fork.Pins)
+		})
+	}
+
+	// The fork must not share the messages of the original.
+	fork := original.Fork(0)
+	fork.Branches["alt"][1].Content = "changed"
+	assert.Equal(t, "one", original.Branches["alt"][1].Content)
+}
+
+// TestLoad_Corrupt verifies that a session whose current branch is missing is rejected.
+func TestLoad_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"branch":"main","branches":{}}`), 0o644))
+
+	_, err := session.Load(path)
+	assert.ErrorContains(t, err, "corrupt")
+}
+
+// TestChatMessages_TruncateToTokens verifies that the oldest turns are dropped whole, keeping the system prompt.
+func TestChatMessages_TruncateToTokens(t *testing.T) {
+	messages := session.ChatMessages{
+		{Role: api.RoleSystem, Content: "sys"},
+		{Role: api.RoleUser, Content: "u1"},
+		{Role: api.RoleAssistant, Content: "a1"},
+		{Role: api.RoleUser, Content: "u2"},
+		{Role: api.RoleAssistant, ToolCalls: []api.ToolCall{{ID: "1", Function: api.FunctionCall{Name: "now"}}}},
+		{Role: api.RoleTool, Content: "t2", ToolCallID: "1"},
+		{Role: api.RoleAssistant, Content: "a2"},
+		{Role: api.RoleUser, Content: "u3"},
+	}
+	// pick returns the messages with the given indices.
+	pick := func(indices ...int) session.ChatMessages {
+		picked := session.ChatMessages{}
+		for _, i := range indices {
+			picked = append(picked, messages[i])
+		}
+		return picked
+	}
+
+	testCases := []struct {
+		name     string
+		pinned   []int
+		limit    int
+		expected session.ChatMessages
+	}{
+		{name: "Within Limit", limit: messages.Tokens("m"), expected: messages},
+		{name: "Oldest Turn Dropped", limit: pick(0, 3, 4, 5, 6, 7).Tokens("m"), expected: pick(0, 3, 4, 5, 6, 7)},
+		{name: "Turns Dropped Whole", limit: pick(0, 3, 4, 5, 6, 7).Tokens("m") - 1, expected: pick(0, 7)},
+		{name: "Pinned Turn Kept", pinned: []int{2}, limit: 0, expected: pick(0, 1, 2, 7)},
+		{name: "Last Turn Kept", limit: 0, expected: pick(0, 7)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, messages.TruncateToTokens("m", tc.limit, tc.pinned))
+
+			// The kept messages are the truncated ones.
+			kept := session.ChatMessages{}
+			for i, keep := range messages.KeptInTokens("m", tc.limit, tc.pinned) {
+				if keep {
+					kept = append(kept, messages[i])
+				}
+			}
+			assert.Equal(t, tc.expected, kept)
+		})
+	}
+}
@@ -49,8 +49,14 @@ package streams
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"time"
 )
 
+// ErrTimeout is returned by NextTimeout when no item arrives in time.
+var ErrTimeout = errors.New("timed out waiting for the next item")
+
 // Stream represents a lazy, pull-based, cancellable iterator over a sequence of
 // items of type T.
 //
@@ -115,6 +121,48 @@ func Map[T, U any](sourceStream *Stream[T], conv func(T) U) *Stream[U] {
 	}
 }
 
+// Demux splits a source Stream into n Streams. The split function is called with
+// every item of the source, and emits it, or items derived from it, to the Streams
+// of the given indices. Emits to indices out of range are ignored.
+//
+// Like Map, this is a lazy operation that needs no goroutine: a Stream pulls from the
+// source when it has no pending items, keeping the items emitted to the other Streams
+// until they are pulled. So, the Streams may be consumed one after the other, or
+// concurrently, in which case they take turns to pull from the source.
+func Demux[T any](source *Stream[T], n int, split func(item T, emit func(index int, item T))) []*Stream[T] {
+	var mu sync.Mutex
+	pending := make([][]T, n)
+	emit := func(index int, item T) {
+		if index >= 0 && index < n {
+			pending[index] = append(pending[index], item)
+		}
+	}
+
+	demuxed := make([]*Stream[T], n)
+	for i := range demuxed {
+		demuxed[i] = &Stream[T]{
+			next: func(ctx context.Context) (T, bool, error) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				for len(pending[i]) == 0 {
+					val, ok, err := source.next(ctx)
+					if err != nil || !ok {
+						var zeroT T
+						return zeroT, false, err
+					}
+					split(val, emit)
+				}
+
+				val := pending[i][0]
+				pending[i] = pending[i][1:]
+				return val, true, nil
+			},
+		}
+	}
+	return demuxed
+}
+
 // Next is a convenience method that produces the next item from the stream
 // using a background context. It is not cancellable. For cancellable
 // iteration, use NextContext.
@@ -133,6 +181,24 @@ func (s *Stream[T]) NextContext(ctx context.Context) (T, bool, error) {
 	return s.next(ctx)
 }
 
+// NextTimeout is like NextContext, but gives up waiting for the next item after
+// the given timeout, returning ErrTimeout. The stream remains usable, so the
+// consumer can react to a stall, like by informing the user, and keep waiting.
+//
+// No item is lost on a timeout, provided that the stream's source doesn't consume
+// items once its context is done, which holds for streams created with New.
+func (s *Stream[T]) NextTimeout(ctx context.Context, timeout time.Duration) (T, bool, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	val, ok, err := s.next(timeoutCtx)
+	// Tell the timeout apart from the cancellation of the parent context.
+	if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return val, false, ErrTimeout
+	}
+	return val, ok, err
+}
+
 // Drain blocks until all events are collected from the stream or until the
 // context is canceled. It provides a simple way to collect all results into a
 // slice.
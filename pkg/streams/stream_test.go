@@ -187,3 +187,72 @@ func TestStream_Next(t *testing.T) {
 	assert.False(t, ok)
 	assert.Equal(t, "", item, "Exhausted stream should return zero value.")
 }
+
+// TestStream_NextTimeout verifies that a stall is reported without losing items or ending the stream.
+func TestStream_NextTimeout(t *testing.T) {
+	ch := make(chan int)
+	stream := streams.Map(streams.New(ch), func(i int) string { return fmt.Sprint(i) })
+
+	// Nothing has been sent yet, so the wait times out.
+	_, ok, err := stream.NextTimeout(context.Background(), 10*time.Millisecond)
+	assert.ErrorIs(t, err, streams.ErrTimeout)
+	assert.False(t, ok)
+
+	// The stream is still usable after the timeout.
+	go func() {
+		ch <- 1
+		close(ch)
+	}()
+	item, ok, err := stream.NextTimeout(context.Background(), time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1", item)
+
+	_, ok, err = stream.NextTimeout(context.Background(), time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// The cancellation of the parent context is not a timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = streams.New(make(chan int)).NextTimeout(ctx, time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestDemux verifies that the items are routed to their streams, which may be consumed one after the other.
+func TestDemux(t *testing.T) {
+	ch := make(chan int, 7)
+	for _, i := range []int{1, 2, 3, 4, 5, 6, -1} {
+		ch <- i
+	}
+	close(ch)
+
+	// Odd numbers go to the first stream, even ones to the second, and negative ones to both.
+	split := func(item int, emit func(int, int)) {
+		switch {
+		case item < 0:
+			emit(0, item)
+			emit(1, item)
+		default:
+			emit((item+1)%2, item)
+		}
+		emit(2, item) // Out of range, ignored.
+	}
+	demuxed := streams.Demux(streams.New(ch), 2, split)
+	require.Len(t, demuxed, 2)
+
+	// The second stream is consumed first, so the items of the first are kept meanwhile.
+	even, err := demuxed[1].Drain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4, 6, -1}, even)
+
+	odd, err := demuxed[0].Drain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3, 5, -1}, odd)
+
+	// A canceled context is reported by the stream that pulls.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = streams.Demux(streams.New(make(chan int)), 1, split)[0].NextContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
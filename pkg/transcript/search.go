@@ -0,0 +1,89 @@
+package transcript
+
+import (
+	"strings"
+)
+
+// Hit is a transcript entry that matched a search, along with the matching
+// part of its message content and some surrounding context.
+type Hit struct {
+	Entry
+	// Snippet is the matching excerpt of the message content.
+	Snippet string
+	// MatchStart and MatchEnd are the byte offsets of the match within the Snippet.
+	MatchStart, MatchEnd int
+}
+
+// Search returns the entries whose message content contains the given term,
+// ignoring case, in the given order.
+//
+// Each hit's snippet includes up to `radius` runes of context on either side
+// of the first match, with line breaks flattened so it displays on a single line.
+func Search(entries []Entry, term string, radius int) []Hit {
+	if term == "" {
+		return nil
+	}
+
+	var hits []Hit
+	for _, entry := range entries {
+		if hit, ok := match(entry, term, radius); ok {
+			hits = append(hits, hit)
+		}
+	}
+	return hits
+}
+
+// match searches the given entry for the term and forms its Hit.
+func match(entry Entry, term string, radius int) (Hit, bool) {
+	content := entry.Message.Content
+
+	// Lowercasing may change byte lengths for some scripts, so search rune-wise
+	// on lowercased runes to keep the offsets valid for the original content.
+	contentRunes, termRunes := []rune(content), []rune(strings.ToLower(term))
+	lowerRunes := []rune(strings.ToLower(content))
+	if len(lowerRunes) != len(contentRunes) {
+		// Extremely rare. Fall back to a case-sensitive search.
+		lowerRunes, termRunes = contentRunes, []rune(term)
+	}
+
+	index := runeIndex(lowerRunes, termRunes)
+	if index < 0 {
+		return Hit{}, false
+	}
+
+	start := max(0, index-radius)
+	end := min(len(contentRunes), index+len(termRunes)+radius)
+
+	before := flatten(string(contentRunes[start:index]))
+	matched := flatten(string(contentRunes[index : index+len(termRunes)]))
+	after := flatten(string(contentRunes[index+len(termRunes) : end]))
+
+	if start > 0 {
+		before = "…" + before
+	}
+	if end < len(contentRunes) {
+		after += "…"
+	}
+
+	return Hit{
+		Entry:      entry,
+		Snippet:    before + matched + after,
+		MatchStart: len(before),
+		MatchEnd:   len(before) + len(matched),
+	}, true
+}
+
+// runeIndex returns the index of the first occurrence of sub in runes, or -1.
+func runeIndex(runes, sub []rune) int {
+	for i := 0; i+len(sub) <= len(runes); i++ {
+		if string(runes[i:i+len(sub)]) == string(sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// flatten replaces all line breaks with spaces, so the text displays on a single line.
+func flatten(text string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(text)
+}
@@ -0,0 +1,40 @@
+package transcript_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/transcript"
+)
+
+// TestSearch verifies the matching and snippet formation of the search.
+func TestSearch(t *testing.T) {
+	entries := []transcript.Entry{
+		{Message: api.ChatMessage{Role: api.RoleUser, Content: "How do I use Goroutines?"}},
+		{Message: api.ChatMessage{Role: api.RoleAssistant, Content: "Use the go keyword:\ngo f()\nto start one."}},
+		{Message: api.ChatMessage{Role: api.RoleUser, Content: "Thanks!"}},
+	}
+
+	t.Run("Case Insensitive Matches in Order", func(t *testing.T) {
+		hits := transcript.Search(entries, "GO", 100)
+		require.Len(t, hits, 2)
+		assert.Equal(t, "How do I use Goroutines?", hits[0].Snippet)
+		assert.Equal(t, "Go", hits[0].Snippet[hits[0].MatchStart:hits[0].MatchEnd])
+		assert.Equal(t, "Use the go keyword: go f() to start one.", hits[1].Snippet)
+	})
+
+	t.Run("Snippet Is Trimmed to Radius", func(t *testing.T) {
+		hits := transcript.Search(entries, "keyword", 4)
+		require.Len(t, hits, 1)
+		assert.Equal(t, "… go keyword: go…", hits[0].Snippet)
+		assert.Equal(t, "keyword", hits[0].Snippet[hits[0].MatchStart:hits[0].MatchEnd])
+	})
+
+	t.Run("No Matches", func(t *testing.T) {
+		assert.Empty(t, transcript.Search(entries, "rust", 10))
+		assert.Empty(t, transcript.Search(entries, "", 10))
+	})
+}
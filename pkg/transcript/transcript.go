@@ -0,0 +1,127 @@
+// Package transcript provides reading and writing of chat transcripts.
+//
+// A transcript is a JSONL file where every line is an Entry, holding a single
+// chat message along with when it was sent and the model and parameters that
+// were in effect. Transcripts are append-only, which makes them safe to write
+// incrementally during a session and easy to audit after the fact.
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+)
+
+// Entry is a single line of a transcript.
+type Entry struct {
+	Time       time.Time       `json:"time"`
+	Model      string          `json:"model"`
+	Parameters map[string]any  `json:"parameters,omitempty"`
+	Message    api.ChatMessage `json:"message"`
+
+	// Session identifies the session of the message, to tell sessions apart in transcripts they share.
+	Session string `json:"session,omitempty"`
+}
+
+// Writer appends entries to a transcript file. It is safe for concurrent use.
+type Writer struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Open opens the transcript file at the given path for appending, creating it
+// and its parent directories if required.
+func Open(path string) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript file: %w", err)
+	}
+
+	return &Writer{file: file}, nil
+}
+
+// Write appends the given entries to the transcript.
+//
+// All entries are written with a single write call so that a crash never
+// leaves a partially written entry behind, except in the rarest of cases.
+func (w *Writer) Write(entries ...Entry) error {
+	var buffer []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal transcript entry: %w", err)
+		}
+		buffer = append(append(buffer, line...), '\n')
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.file.Write(buffer); err != nil {
+		return fmt.Errorf("failed to write transcript entries: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying transcript file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// Read reads all entries from the transcript file at the given path.
+func Read(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return decode(file)
+}
+
+// decode reads all entries from the given JSONL reader.
+func decode(reader io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	// Lines can be long, since they hold whole messages.
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal transcript entry at line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+
+	return entries, nil
+}
+
+// FileName returns the name of the transcript file for a session started at the given time.
+// The names sort chronologically.
+func FileName(start time.Time) string {
+	return start.Format("2006-01-02T15-04-05.000") + ".jsonl"
+}
@@ -0,0 +1,56 @@
+package transcript_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/api"
+	"github.com/shivanshkc/llmb/pkg/transcript"
+)
+
+// TestWriterAndRead verifies that written entries can be read back, including across reopening.
+func TestWriterAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", transcript.FileName(time.Now()))
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	first := transcript.Entry{
+		Time:       now,
+		Model:      "test-model",
+		Parameters: map[string]any{"temperature": 0.5},
+		Message:    api.ChatMessage{Role: api.RoleUser, Content: "hello"},
+	}
+	second := transcript.Entry{
+		Time:    now.Add(time.Second),
+		Model:   "test-model",
+		Message: api.ChatMessage{Role: api.RoleAssistant, Content: "hi\nthere"},
+	}
+
+	writer, err := transcript.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(first))
+	require.NoError(t, writer.Close())
+
+	// Reopening must append instead of truncating.
+	writer, err = transcript.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(second))
+	require.NoError(t, writer.Close())
+
+	entries, err := transcript.Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, []transcript.Entry{first, second}, entries)
+}
+
+// TestRead_Malformed verifies that a malformed line is reported with its line number.
+func TestRead_Malformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{}\n{bad\n"), 0o644))
+
+	_, err := transcript.Read(path)
+	assert.ErrorContains(t, err, "line 2")
+}
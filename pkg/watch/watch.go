@@ -0,0 +1,64 @@
+// Package watch detects changes to files by polling them, which works the same on every platform,
+// and with editors that save by replacing the file.
+package watch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// File returns a channel of the contents of the file at the given path. The current content is sent first,
+// followed by every new content of the file, checked at the given interval. The channel is closed once
+// the context is canceled.
+//
+// The file must exist to begin with. While it is missing or unreadable afterwards, it is treated as unchanged.
+// A new content is only sent once it is the same for two checks in a row, so that partly written
+// files, like in the middle of a save, are skipped.
+func File(ctx context.Context, path string, interval time.Duration) (<-chan []byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watched file: %w", err)
+	}
+
+	contents := make(chan []byte, 1)
+	contents <- content
+
+	go func() {
+		defer close(contents)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// pending is the new content seen by the previous check, which is sent if it is still the same.
+		last, pending := content, content
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			stable := bytes.Equal(content, pending)
+			pending = content
+			if !stable || bytes.Equal(content, last) {
+				continue
+			}
+			last = content
+
+			select {
+			case <-ctx.Done():
+				return
+			case contents <- content:
+			}
+		}
+	}()
+
+	return contents, nil
+}
@@ -0,0 +1,71 @@
+package watch_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shivanshkc/llmb/pkg/watch"
+)
+
+// next returns the next content from the channel, failing the test if none arrives in time.
+func next(t *testing.T, contents <-chan []byte) string {
+	t.Helper()
+	select {
+	case content, ok := <-contents:
+		require.True(t, ok, "The channel must not be closed.")
+		return string(content)
+	case <-time.After(time.Second):
+		require.FailNow(t, "No content was sent.")
+		return ""
+	}
+}
+
+// TestFile verifies that the current content and every change are sent, and nothing else.
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.txt")
+	require.NoError(t, os.WriteFile(path, []byte("one"), 0o644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	contents, err := watch.File(ctx, path, 5*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "one", next(t, contents))
+
+	require.NoError(t, os.WriteFile(path, []byte("two"), 0o644))
+	assert.Equal(t, "two", next(t, contents))
+
+	// A replaced file is detected, and a temporarily missing one is ignored.
+	require.NoError(t, os.Remove(path))
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("three"), 0o644))
+	assert.Equal(t, "three", next(t, contents))
+
+	// Rewriting the same content is not a change.
+	require.NoError(t, os.WriteFile(path, []byte("three"), 0o644))
+	select {
+	case content := <-contents:
+		assert.Fail(t, "No change was expected.", "Got %q.", content)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case _, ok := <-contents:
+		assert.False(t, ok, "The channel is closed once the context is canceled.")
+	case <-time.After(time.Second):
+		assert.Fail(t, "The channel was not closed.")
+	}
+}
+
+// TestFile_Missing verifies that a missing file is an error.
+func TestFile_Missing(t *testing.T) {
+	_, err := watch.File(context.Background(), filepath.Join(t.TempDir(), "missing.txt"), time.Millisecond)
+	assert.Error(t, err)
+}